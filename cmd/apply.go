@@ -24,7 +24,7 @@ var applyCmd = &cobra.Command{
 			log.Fatal(err)
 		}
 
-		if changes == nil || len(changes.Bundles) == 0 {
+		if changes == nil || changes.IsEmpty() {
 			fmt.Println("No changes identified, target at same commit level as repository, please confirm with log")
 			return
 		}
@@ -39,62 +39,68 @@ var applyCmd = &cobra.Command{
 
 		fmt.Println("Application Results.....")
 
-		for _, b := range changes.Bundles {
-			fmt.Println(fmt.Sprintf("Change Bundle:[%s]", b.Ref.Hash))
-			if len(b.Items) == 0 {
-				utility.TabbedPrintln(2, "No Changes identified within this bundle")
-			} else {
-				objOrder := operation.GetExecutionOrder()
-				for _, otype := range objOrder {
-					items, err := b.GetChangesOfType(otype)
-					if err == nil {
-						for _, c := range items {
-							fmt.Println(fmt.Sprintf("\t[%s] %s ",
-								c.ObjectType,
-								c.Metadata.Name))
-							utility.TabbedPrintln(2, "Validation Information:........................")
-							for _, v := range c.Validation.Steps {
-								var es string
-								if v.Error != nil {
-									es = v.Error.Error()
+		for _, changeLog := range changes.Logs {
+			fmt.Println(fmt.Sprintf("Target:[%s]", changeLog.Target))
+			for _, b := range changeLog.Bundles {
+				fmt.Println(fmt.Sprintf("Change Bundle:[%s]", b.Ref.Hash))
+				if len(b.Items) == 0 {
+					utility.TabbedPrintln(2, "No Changes identified within this bundle")
+				} else {
+					objOrder := operation.GetExecutionOrder(changeLog.Target)
+					for _, otype := range objOrder {
+						items, err := b.GetChangesOfType(otype)
+						if err == nil {
+							for _, c := range items {
+								fmt.Println(fmt.Sprintf("\t[%s] %s ",
+									c.ObjectType,
+									c.Metadata.Name))
+								utility.TabbedPrintln(2, "Validation Information:........................")
+								for _, v := range c.Validation.OrderedSteps() {
+									var es string
+									if v.Error != nil {
+										es = v.Error.Error()
+									}
+									utility.TabbedPrintlnf(3, "validator: %s passed:[%t] %s", v.ValidatorName, v.Success, es)
+									for _, d := range v.Differences {
+										utility.TabbedPrintln(4, d.String())
+									}
 								}
-								utility.TabbedPrintlnf(3, "validator: %s passed:[%t] %s", v.ValidatorName, v.Success, es)
-							}
 
-							utility.TabbedPrintln(2, "Application information:.......................")
+								utility.TabbedPrintln(2, "Application information:.......................")
 
-							success, partial, err := c.ApplyInformation.IsSuccess()
-							if success {
-								utility.TabbedPrintln(3, "Success: object applied to target")
+								success, partial, err := c.ApplyInformation.IsSuccess()
+								if success {
+									utility.TabbedPrintln(3, "Success: object applied to target")
 
-							} else {
-								if c.ApplyInformation.Executed == false {
-									utility.TabbedPrintln(3, "Skipped: true, this object was not executed due to error preceding it")
 								} else {
-									utility.TabbedPrintlnf(3, "Failed,  Object Partially Applied: %t", partial)
-									if err != nil {
-										utility.TabbedPrintlnf(3, "Error: %s", err.Error())
-									}
-									//print each scope and status to convey detail error context
-									for _, scope := range c.ApplyInformation.GetScopes() {
-										info := scope.GetEffectInfo()
-										var msg string
-										if info.Error != nil {
-											msg = err.Error()
+									if c.ApplyInformation.Executed == false {
+										utility.TabbedPrintln(3, "Skipped: true, this object was not executed due to error preceding it")
+									} else {
+										utility.TabbedPrintlnf(3, "Failed,  Object Partially Applied: %t", partial)
+										if err != nil {
+											utility.TabbedPrintlnf(3, "Error: %s", err.Error())
 										}
+										//print each scope and status to convey detail error context
+										for _, scope := range c.ApplyInformation.GetScopes() {
+											info := scope.GetEffectInfo()
+											var msg string
+											if info.Error != nil {
+												msg = err.Error()
+											}
 
-										utility.TabbedPrintlnf(4, "Scope: %s, Executed: %t, Success: %t, Partial: %t, Error: %s",
-											scope.Name,
-											info.Executed,
-											info.Success,
-											info.Partial,
-											msg)
+											utility.TabbedPrintlnf(4, "Scope: %s, Executed: %t, Success: %t, Partial: %t, Error: %s",
+												scope.Name,
+												info.Executed,
+												info.Success,
+												info.Partial,
+												msg)
 
-										utility.TabbedPrintln(4, "---------command")
-										for _, cmd := range scope.Commands {
-											utility.TabbedPrintln(4, cmd)
+											utility.TabbedPrintln(4, "---------command")
+											for _, cmd := range scope.Commands {
+												utility.TabbedPrintln(4, cmd)
+											}
+											utility.TabbedPrintln(4, "................")
 										}
-										utility.TabbedPrintln(4, "................")
 									}
 								}
 							}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"Plow/plow/objects"
+	"Plow/plow/utility"
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"log"
+	"os"
+)
+
+var driftJson bool
+
+var driftCmd = &cobra.Command{
+	Use:   "drift",
+	Short: "report objects which differ from their specs at the tracked commit",
+	Long: `compare the specs of the repository at the last commit applied to each target with the live objects of the
+target, reporting missing, extra and changed warehouse properties, declarative table columns, owners and grants.
+Exits non-zero when drift is found or a target could not be compared`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		err := initBase()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		results, err := operation.DetectDrift()
+
+		entries := make([]objects.DriftEntry, 0)
+		for _, name := range operation.TargetNames() {
+			entries = append(entries, results[name]...)
+		}
+
+		if driftJson {
+			bytes, err := json.MarshalIndent(entries, "", "  ")
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println(string(bytes))
+		} else {
+			fmt.Println("Drift Results.....")
+			counts := make(map[objects.DriftKind]int)
+			for _, name := range operation.TargetNames() {
+				found, ok := results[name]
+				if !ok {
+					continue
+				}
+				fmt.Println(fmt.Sprintf("Target:[%s]", name))
+				for _, e := range found {
+					utility.TabbedPrintlnf(1, "%s (%s)", e.String(), e.File)
+					counts[e.Kind]++
+				}
+			}
+			fmt.Println(fmt.Sprintf("Missing(%d), Extra(%d), Changed(%d)",
+				counts[objects.DriftMissing], counts[objects.DriftExtra], counts[objects.DriftChanged]))
+		}
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, fmt.Sprintf("Error: %s", err.Error()))
+			os.Exit(1)
+		}
+		if len(entries) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	driftCmd.Flags().BoolVar(&driftJson, "json", false, "print the drift found as json")
+	rootCmd.AddCommand(driftCmd)
+}
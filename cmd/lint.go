@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"Plow/plow"
+	"Plow/plow/objects"
+	"fmt"
+	"github.com/spf13/cobra"
+	"log"
+	"os"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [path...]",
+	Short: "check spec files without connecting to a target",
+	Long: `check spec files for schema conformance, unknown object types, change scopes without an init scope, 
+protected owner roles, USE ROLE commands and unresolved placeholders without connecting to a target. Directories are 
+walked for yaml files, the current directory is linted when no path is provided. Exits non-zero when a critical 
+finding is reported`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		paths := args
+		if len(paths) == 0 {
+			paths = []string{"."}
+		}
+
+		findings, err := plow.LintPaths(paths)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		critical, warning := 0, 0
+		for _, f := range findings {
+			fmt.Println(f.String())
+			switch f.Severity {
+			case objects.ValidationErrorCritical:
+				critical++
+			case objects.ValidationErrorWarn:
+				warning++
+			}
+		}
+		fmt.Println(fmt.Sprintf("Critical(%d), Warning(%d)", critical, warning))
+
+		if critical > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+}
@@ -73,7 +73,7 @@ var listChangesCmd = &cobra.Command{
 		}
 
 		changes, err := operation.GenerateChangeLog()
-		if (changes == nil || len(changes.Bundles) == 0) && err == plow.ErrNoCommitsToProcess {
+		if (changes == nil || changes.IsEmpty()) && err == plow.ErrNoCommitsToProcess {
 			fmt.Println("No changes identified, target at same commit level as repository, please confirm with log")
 			return
 		}
@@ -82,13 +82,16 @@ var listChangesCmd = &cobra.Command{
 			log.Fatal(err)
 		}
 
-		for _, b := range changes.Bundles {
-			fmt.Println(fmt.Sprintf("Change Bundle:[%s]", b.Ref.Hash))
-			if len(b.Items) == 0 {
-				utility.TabbedPrintln(2, "No Changes identified within this bundle")
-			} else {
-				for _, c := range b.Items {
-					fmt.Println(fmt.Sprintf("\t[%s] : %s", c.ObjectType, c.Metadata.Name))
+		for _, changeLog := range changes.Logs {
+			fmt.Println(fmt.Sprintf("Target:[%s]", changeLog.Target))
+			for _, b := range changeLog.Bundles {
+				fmt.Println(fmt.Sprintf("Change Bundle:[%s]", b.Ref.Hash))
+				if len(b.Items) == 0 {
+					utility.TabbedPrintln(2, "No Changes identified within this bundle")
+				} else {
+					for _, c := range b.Items {
+						fmt.Println(fmt.Sprintf("\t[%s] : %s", c.ObjectType, c.Metadata.Name))
+					}
 				}
 			}
 		}
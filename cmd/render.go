@@ -22,7 +22,7 @@ var renderCmd = &cobra.Command{
 			log.Fatal(err)
 		}
 
-		if changes == nil || len(changes.Bundles) == 0 {
+		if changes == nil || changes.IsEmpty() {
 			fmt.Println("No changes identified, target at same commit level as repository, please confirm with log")
 			return
 		}
@@ -33,25 +33,31 @@ var renderCmd = &cobra.Command{
 		}
 
 		fmt.Println("Validation Results.....")
-		for _, b := range changes.Bundles {
-			if len(b.Items) == 0 {
-				utility.TabbedPrintln(2, "No Changes identified within this bundle")
-			} else {
-				fmt.Println(fmt.Sprintf("Change Bundle:[%s]", b.Ref.Hash))
-				for _, c := range b.Items {
-					fmt.Println(fmt.Sprintf("\tCritical(%d), Warning(%d), Success(%d) [%s] %s ",
-						c.Validation.Critical,
-						c.Validation.Warning,
-						c.Validation.Success,
-						c.ObjectType,
-						c.Metadata.Name))
-					for _, v := range c.Validation.Steps {
-						var es string
-						if v.Error != nil {
-							es = v.Error.Error()
+		for _, changeLog := range changes.Logs {
+			fmt.Println(fmt.Sprintf("Target:[%s]", changeLog.Target))
+			for _, b := range changeLog.Bundles {
+				if len(b.Items) == 0 {
+					utility.TabbedPrintln(2, "No Changes identified within this bundle")
+				} else {
+					fmt.Println(fmt.Sprintf("Change Bundle:[%s]", b.Ref.Hash))
+					for _, c := range b.Items {
+						fmt.Println(fmt.Sprintf("\tCritical(%d), Warning(%d), Success(%d) [%s] %s ",
+							c.Validation.Critical,
+							c.Validation.Warning,
+							c.Validation.Success,
+							c.ObjectType,
+							c.Metadata.Name))
+						for _, v := range c.Validation.OrderedSteps() {
+							var es string
+							if v.Error != nil {
+								es = v.Error.Error()
+							}
+							msg := fmt.Sprintf("\t\t validator: %s passed:[%t] %s", v.ValidatorName, v.Success, es)
+							fmt.Println(msg)
+							for _, d := range v.Differences {
+								utility.TabbedPrintln(3, d.String())
+							}
 						}
-						msg := fmt.Sprintf("\t\t validator: %s passed:[%t] %s", v.ValidatorName, v.Success, es)
-						fmt.Println(msg)
 					}
 				}
 			}
@@ -59,7 +65,7 @@ var renderCmd = &cobra.Command{
 
 		fmt.Println("Rendered Commands......")
 		for _, rc := range rendered {
-			fmt.Println(fmt.Sprintf("-- %s [%s]", rc.Item().Metadata.Name, rc.Item().Bundle.Ref.Hash))
+			fmt.Println(fmt.Sprintf("-- %s [%s] target:%s", rc.Item().Metadata.Name, rc.Item().Bundle.Ref.Hash, rc.Item().Bundle.Target()))
 			for _, scope := range rc.Item().ApplyInformation.GetScopes() {
 				fmt.Println(fmt.Sprintf("-- scope:%s", scope.Name))
 				for _, cmd := range scope.Commands {
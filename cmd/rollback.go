@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"Plow/plow/utility"
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"log"
+	"os"
+)
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback <commit>",
+	Short: "restore the tables and schemas changed by a commit",
+	Long: `restore the tables and schemas changed by a commit to their state prior to the commit being applied, 
+using time travel within the retention window of each object`,
+	Args: cobra.ExactArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		err := initBase()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		results, err := operation.RollbackCommit(context.Background(), args[0])
+		if err != nil {
+			fmt.Println("Error occurred during rollback of commit")
+			fmt.Println(fmt.Sprintf("Error: %s", err.Error()))
+		}
+
+		fmt.Println("Rollback Results.....")
+		for _, name := range operation.TargetNames() {
+			entries, ok := results[name]
+			if !ok {
+				continue
+			}
+			fmt.Println(fmt.Sprintf("Target:[%s]", name))
+			for _, e := range entries {
+				utility.TabbedPrintlnf(1, "[%s] %s restored:[%t] %s", e.ObjectType, e.Object, e.Status, e.Message)
+				if len(e.Statement) > 0 {
+					utility.TabbedPrintln(2, e.Statement)
+				}
+			}
+		}
+
+		if err != nil {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rollbackCmd)
+}
@@ -14,6 +14,8 @@ import (
 var cfgFile string
 var fullChangeSet bool
 var fastForward bool
+var terminateOnFailure bool
+var validationWorkers int
 var commitId string
 var environment string
 
@@ -84,12 +86,23 @@ func initializeConfiguration() error {
 }
 
 func initOptions() error {
+	options.Environment = environment
+
 	if fastForward {
 		options.OptionFlags.Set(objects.FastForwardSetting)
 	}
 
 	fmt.Println(fmt.Sprintf("Fast Forward set: %t", options.OptionFlags.Has(objects.FastForwardSetting)))
 
+	if terminateOnFailure {
+		options.OptionFlags.Set(objects.TerminateOnValidationFailureSetting)
+	}
+
+	if validationWorkers < 1 {
+		return fmt.Errorf("error: validation workers must be at least 1, got [%d]", validationWorkers)
+	}
+	options.ValidationWorkers = validationWorkers
+
 	if len(strings.TrimSpace(commitId)) > 0 {
 		options.CommitId = &commitId
 	}
@@ -120,5 +133,7 @@ func init() {
 	rootCmd.PersistentFlags().StringVarP(&environment, "env", "e", "DEFAULT", "Environment name. Required, must match a specified name within config")
 	rootCmd.PersistentFlags().BoolVar(&fullChangeSet, "full", false, "apply all files, not just changes")
 	rootCmd.PersistentFlags().BoolVar(&fastForward, "fast-forward", false, "advance to commit ignoring history, if commit is not supplied HEAD will be assumed ")
+	rootCmd.PersistentFlags().BoolVar(&terminateOnFailure, "fail-fast", false, "stop validation at the first object with a critical validation failure, by default all objects are validated")
+	rootCmd.PersistentFlags().IntVar(&validationWorkers, "validation-workers", 1, "number of objects validated at once")
 	rootCmd.PersistentFlags().StringVar(&commitId, "commit", "", "commit id to process up to and including")
 }
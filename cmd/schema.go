@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"Plow/plow/targets"
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "spec file schemas",
+	Long:  `spec file schemas`,
+}
+
+var schemaExportCmd = &cobra.Command{
+	Use:   "export [directory]",
+	Short: "export the JSON Schema of spec files for each target type",
+	Long: `export the JSON Schema of spec files for each target type, the schemas are generated from the spec definitions 
+plow parses and validates specs against when loaded. Written to the current directory when no directory is provided`,
+	Args: cobra.MaximumNArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		directory := "."
+		if len(args) > 0 {
+			directory = args[0]
+		}
+		if err := os.MkdirAll(directory, 0755); err != nil {
+			log.Fatal(err)
+		}
+
+		schemas := targets.SpecSchemas()
+		names := make([]string, 0)
+		for name := range schemas {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			bytes, err := json.MarshalIndent(schemas[name], "", "  ")
+			if err != nil {
+				log.Fatal(err)
+			}
+			path := filepath.Join(directory, name)
+			if err := os.WriteFile(path, append(bytes, '\n'), 0644); err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println(path)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+	schemaCmd.AddCommand(schemaExportCmd)
+}
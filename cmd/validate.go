@@ -1,12 +1,19 @@
 package cmd
 
 import (
+	"Plow/plow/objects"
+	"Plow/plow/targets/common"
 	"Plow/plow/utility"
+	"context"
+	"errors"
 	"fmt"
 	"github.com/spf13/cobra"
 	"log"
+	"os"
 )
 
+var rehearse bool
+
 var validateCmd = &cobra.Command{
 	Use:   "validate",
 	Short: "validate change(s) to the configured target",
@@ -23,44 +30,98 @@ var validateCmd = &cobra.Command{
 			log.Fatal(err)
 		}
 
-		if changes == nil || len(changes.Bundles) == 0 {
+		if changes == nil || changes.IsEmpty() {
 			fmt.Println("No changes identified, target at same commit level as repository, please confirm with log")
 			return
 		}
 
+		//a terminated validation reports the results up to the failing object
 		err = operation.ValidateChanges(changes)
-		if err != nil {
+		terminated := errors.Is(err, common.ErrValidationTerminated)
+		if err != nil && !terminated {
 			log.Fatal(err)
 		}
 
+		if rehearse && !terminated {
+			err = operation.RehearseChanges(context.Background(), changes)
+			if err != nil {
+				fmt.Println("Error occurred during rehearsal of changes")
+				fmt.Println(fmt.Sprintf("Error: %s", err.Error()))
+			}
+		}
+
 		fmt.Println("Validation Results.....")
-		for _, b := range changes.Bundles {
-			if len(b.Items) == 0 {
-				utility.TabbedPrintln(2, "No Changes identified within this bundle")
-			} else {
-				fmt.Println(fmt.Sprintf("Change Bundle:[%s]", b.Ref.Hash))
-				for _, c := range b.Items {
-					fmt.Println(fmt.Sprintf("\tCritical(%d), Warning(%d), Success(%d) [%s] %s ",
-						c.Validation.Critical,
-						c.Validation.Warning,
-						c.Validation.Success,
-						c.ObjectType,
-						c.Metadata.Name))
-					for _, v := range c.Validation.Steps {
-						var es string
-						if v.Error != nil {
-							es = v.Error.Error()
+		for _, changeLog := range changes.Logs {
+			fmt.Println(fmt.Sprintf("Target:[%s]", changeLog.Target))
+			for _, b := range changeLog.Bundles {
+				if len(b.Items) == 0 {
+					utility.TabbedPrintln(2, "No Changes identified within this bundle")
+				} else {
+					fmt.Println(fmt.Sprintf("Change Bundle:[%s]", b.Ref.Hash))
+					for _, c := range b.Items {
+						fmt.Println(fmt.Sprintf("\tCritical(%d), Warning(%d), Success(%d) [%s] %s ",
+							c.Validation.Critical,
+							c.Validation.Warning,
+							c.Validation.Success,
+							c.ObjectType,
+							c.Metadata.Name))
+						for _, v := range c.Validation.OrderedSteps() {
+							var es string
+							if v.Error != nil {
+								es = v.Error.Error()
+							}
+							msg := fmt.Sprintf("\t\t validator: %s passed:[%t] %s", v.ValidatorName, v.Success, es)
+							fmt.Println(msg)
+							for _, d := range v.Differences {
+								utility.TabbedPrintln(3, d.String())
+							}
+						}
+						if rehearse && !terminated {
+							printRehearsal(c.ApplyInformation)
 						}
-						msg := fmt.Sprintf("\t\t validator: %s passed:[%t] %s", v.ValidatorName, v.Success, es)
-						fmt.Println(msg)
 					}
 				}
 			}
 		}
 
+		if terminated {
+			fmt.Println(fmt.Sprintf("Validation terminated: %s", err.Error()))
+			os.Exit(1)
+		}
+
 	},
 }
 
+// printRehearsal reports the outcome of rehearsing the item, the commands of a failed scope are listed
+func printRehearsal(info objects.ApplyEffectInformation) {
+	success, partial, err := info.IsSuccess()
+	switch {
+	case success:
+		utility.TabbedPrintln(3, "rehearsal: passed")
+	case !info.Executed:
+		var es string
+		if info.Error != nil {
+			es = info.Error.Error()
+		}
+		utility.TabbedPrintlnf(3, "rehearsal: skipped %s", es)
+	default:
+		var es string
+		if err != nil {
+			es = err.Error()
+		}
+		utility.TabbedPrintlnf(3, "rehearsal: failed, partially applied:[%t] %s", partial, es)
+		for _, scope := range info.GetScopes() {
+			if effect := scope.GetEffectInfo(); effect.Executed && !effect.Success {
+				utility.TabbedPrintlnf(4, "Scope: %s", scope.Name)
+				for _, cmd := range scope.Commands {
+					utility.TabbedPrintln(4, cmd)
+				}
+			}
+		}
+	}
+}
+
 func init() {
+	validateCmd.Flags().BoolVar(&rehearse, "rehearse", false, "execute the changes against zero-copy clones of the affected databases, the clones are dropped afterwards")
 	rootCmd.AddCommand(validateCmd)
 }
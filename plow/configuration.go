@@ -1,5 +1,12 @@
 package plow
 
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
 type DirectoryType int
 
 const (
@@ -7,6 +14,14 @@ const (
 	LocalDirectory  = 2
 )
 
+// DefaultTargetName name given to the target declared with the environment level targetType and target elements
+const DefaultTargetName = "default"
+
+var (
+	ErrNoTargetsConfigured = errors.New("no targets configured for environment")
+	ErrNoDefaultTarget     = errors.New("spec does not identify a target and no default target is configured")
+)
+
 type GitConfiguration struct {
 	SSHKeyFile        string `yaml:"sshkey"`
 	KeyPasswordSecret string `yaml:"passwordSecret"`
@@ -14,14 +29,88 @@ type GitConfiguration struct {
 	Branch            string `yaml:"branch"`
 }
 
+type TargetConfiguration struct {
+	TargetType string                 `yaml:"targetType"`
+	Target     map[string]interface{} `yaml:"target"`
+}
+
 type Configuration struct {
-	TargetType      string                 `yaml:"targetType"`
-	SecretStoreType string                 `yaml:"secretStoreType"`
-	SecretStore     map[string]interface{} `yaml:"secretStore"`
-	Target          map[string]interface{} `yaml:"target"`
-	GitConfig       GitConfiguration       `yaml:"git"`
+	TargetType      string                         `yaml:"targetType"`
+	SecretStoreType string                         `yaml:"secretStoreType"`
+	SecretStore     map[string]interface{}         `yaml:"secretStore"`
+	Target          map[string]interface{}         `yaml:"target"`
+	Targets         map[string]TargetConfiguration `yaml:"targets"`
+	TargetOrder     []string                       `yaml:"targetOrder"`
+	DefaultTarget   string                         `yaml:"defaultTarget"`
+	GitConfig       GitConfiguration               `yaml:"git"`
 }
 
 type SystemConfiguration struct {
 	Environments map[string]Configuration `yaml:"environments"`
 }
+
+// ResolveTargets normalizes the single and named target declarations of the environment. Returns the target
+// configurations by name, the order targets are processed in and the name of the target specs without a target
+// header are routed to, empty when there is no default.
+func (c *Configuration) ResolveTargets() (map[string]TargetConfiguration, []string, string, error) {
+	targets := make(map[string]TargetConfiguration)
+	for name, target := range c.Targets {
+		key := normalizeTargetName(name)
+		if _, ok := targets[key]; ok {
+			return nil, nil, "", fmt.Errorf("target [%s] declared more than once", name)
+		}
+		targets[key] = target
+	}
+
+	defaultTarget := normalizeTargetName(c.DefaultTarget)
+	if len(strings.TrimSpace(c.TargetType)) > 0 {
+		if _, ok := targets[DefaultTargetName]; ok {
+			return nil, nil, "", fmt.Errorf("target [%s] conflicts with environment level target", DefaultTargetName)
+		}
+		targets[DefaultTargetName] = TargetConfiguration{TargetType: c.TargetType, Target: c.Target}
+		if len(defaultTarget) == 0 {
+			defaultTarget = DefaultTargetName
+		}
+	}
+
+	if len(targets) == 0 {
+		return nil, nil, "", ErrNoTargetsConfigured
+	}
+
+	if len(defaultTarget) == 0 && len(targets) == 1 {
+		for name := range targets {
+			defaultTarget = name
+		}
+	}
+
+	if _, ok := targets[defaultTarget]; len(defaultTarget) > 0 && !ok {
+		return nil, nil, "", fmt.Errorf("default target [%s] is not declared", c.DefaultTarget)
+	}
+
+	order := make([]string, 0)
+	if len(c.TargetOrder) > 0 {
+		seen := make(map[string]bool)
+		for _, name := range c.TargetOrder {
+			key := normalizeTargetName(name)
+			if _, ok := targets[key]; !ok || seen[key] {
+				return nil, nil, "", fmt.Errorf("target order references undeclared or repeated target [%s]", name)
+			}
+			seen[key] = true
+			order = append(order, key)
+		}
+		if len(order) != len(targets) {
+			return nil, nil, "", errors.New("target order must list every declared target once")
+		}
+	} else {
+		for name := range targets {
+			order = append(order, name)
+		}
+		sort.Strings(order)
+	}
+
+	return targets, order, defaultTarget, nil
+}
+
+func normalizeTargetName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,53 @@
+package plow
+
+import (
+	"Plow/plow/objects"
+	"Plow/plow/targets"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LintPaths lints the spec files at the paths without connecting to a target, directories are walked for yaml files.
+// Findings are ordered by file and line.
+func LintPaths(paths []string) ([]objects.LintFinding, error) {
+	findings := make([]objects.LintFinding, 0)
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				//hidden directories, i.e. .git, never hold specs
+				if file != path && strings.HasPrefix(entry.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			//mirrors the change log, only files with a yaml extension are considered specs
+			if !strings.HasSuffix(strings.ToUpper(file), "YAML") {
+				return nil
+			}
+
+			bytes, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			findings = append(findings, targets.LintSpec(filepath.ToSlash(file), bytes)...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	return findings, nil
+}
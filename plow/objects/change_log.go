@@ -3,6 +3,7 @@ package objects
 import (
 	"Plow/plow/utility"
 	"errors"
+	"fmt"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/utils/merkletrie"
 	"gopkg.in/yaml.v2"
@@ -27,6 +28,12 @@ var (
 	ErrDoesNotExist                  = errors.New("key or value does nto exist")
 )
 
+// TargetRouting resolves the name of the target a spec is applied to from its header
+type TargetRouting func(header *CodeBlockHeaderSpec) (string, error)
+
+// SpecValidator the differences between the spec file and the schema of the target it is applied to
+type SpecValidator func(bytes []byte) []ValidationDifference
+
 type ChangeAction int
 type ValidationErrorSeverity int
 
@@ -89,6 +96,33 @@ type ValidationStepInfo struct {
 	Success       bool
 	Error         error
 	Severity      ValidationErrorSeverity
+	Differences   []ValidationDifference
+}
+
+// ValidationDifference an attribute of the object which differs from the expected definition
+type ValidationDifference struct {
+	Subject   string
+	Attribute string
+	Expected  string
+	Actual    string
+	Severity  ValidationErrorSeverity
+}
+
+func (d ValidationDifference) String() string {
+	return fmt.Sprintf("[%s] %s %s expected [%s] actual [%s]", d.Severity, d.Subject, d.Attribute, d.Expected, d.Actual)
+}
+
+func (s ValidationErrorSeverity) String() string {
+	switch s {
+	case ValidationErrorInfo:
+		return "info"
+	case ValidationErrorWarn:
+		return "warning"
+	case ValidationErrorCritical:
+		return "critical"
+	default:
+		return "none"
+	}
 }
 
 type ChangeMetadata struct {
@@ -103,6 +137,7 @@ type ValidationInfo struct {
 	Warning  int
 	Success  int
 	Steps    map[string]ValidationStepInfo
+	order    []string
 }
 
 func (vi *ValidationInfo) PassedValidation() bool {
@@ -116,6 +151,9 @@ func (vi *ValidationInfo) addValidationStepInfo(info ValidationStepInfo) {
 	if vi.Steps == nil {
 		vi.Steps = make(map[string]ValidationStepInfo)
 	}
+	if _, ok := vi.Steps[info.ValidatorName]; !ok {
+		vi.order = append(vi.order, info.ValidatorName)
+	}
 	vi.Steps[info.ValidatorName] = info
 
 	switch info.Severity {
@@ -131,6 +169,15 @@ func (vi *ValidationInfo) addValidationStepInfo(info ValidationStepInfo) {
 	}
 }
 
+// OrderedSteps the steps in the order they were first recorded
+func (vi *ValidationInfo) OrderedSteps() []ValidationStepInfo {
+	steps := make([]ValidationStepInfo, 0, len(vi.order))
+	for _, name := range vi.order {
+		steps = append(steps, vi.Steps[name])
+	}
+	return steps
+}
+
 func (vi *ValidationInfo) AddValidationStepInfo(severity ValidationErrorSeverity, success bool, err error, name string) {
 	vi.addValidationStepInfo(ValidationStepInfo{Severity: severity,
 		Success:       success,
@@ -138,6 +185,42 @@ func (vi *ValidationInfo) AddValidationStepInfo(severity ValidationErrorSeverity
 		ValidatorName: name})
 }
 
+// AddValidationStepDifferences records the step along with the differences identified by the validator
+func (vi *ValidationInfo) AddValidationStepDifferences(severity ValidationErrorSeverity, success bool, err error, name string, differences []ValidationDifference) {
+	vi.addValidationStepInfo(ValidationStepInfo{Severity: severity,
+		Success:       success,
+		Error:         err,
+		ValidatorName: name,
+		Differences:   differences})
+}
+
+// OverrideStepSeverity remaps the severity of the step recorded by the validator, and of its differences, according to
+// the overrides. Severities without an override are retained.
+func (vi *ValidationInfo) OverrideStepSeverity(name string, overrides map[ValidationErrorSeverity]ValidationErrorSeverity) {
+	info, ok := vi.Steps[name]
+	if !ok {
+		return
+	}
+
+	for i, difference := range info.Differences {
+		if severity, ok := overrides[difference.Severity]; ok {
+			info.Differences[i].Severity = severity
+		}
+	}
+	if severity, ok := overrides[info.Severity]; ok {
+		switch info.Severity {
+		case ValidationErrorCritical:
+			vi.Critical -= 1
+		case ValidationErrorWarn:
+			vi.Warning -= 1
+		case ValidationErrorNone:
+			vi.Success -= 1
+		}
+		info.Severity = severity
+		vi.addValidationStepInfo(info)
+	}
+}
+
 type ChangeItem struct {
 	ObjectType       string                 `yaml:"type"`
 	Item             *CodeBlockSpec         `yaml:"code"`
@@ -146,6 +229,8 @@ type ChangeItem struct {
 	Validation       ValidationInfo         `yaml:"-"`
 	ApplyInformation ApplyEffectInformation `yaml:"-"`
 	Bundle           *ChangeLogBundle       `yaml:"-"`
+	// SpecDifferences differences between the spec file and the schema of the target found when loaded
+	SpecDifferences []ValidationDifference `yaml:"-"`
 }
 
 type ChangeLogBundle struct {
@@ -210,8 +295,24 @@ func (clb *ChangeLogBundle) AddItem(bytes []byte, meta ChangeMetadata) error {
 		return nil
 	}
 
+	//items routed to another target belong to that target's change log
+	if clb.parent.routing != nil {
+		target, err := clb.parent.routing(&spec.CodeBlockHeaderSpec)
+		if err != nil {
+			return err
+		}
+		if strings.Compare(target, clb.parent.Target) != 0 {
+			return nil
+		}
+	}
+
 	item := &ChangeItem{Metadata: meta, Item: spec, ObjectType: spec.Type, Bundle: clb}
 
+	//conformance to the target schema is checked against the file, the differences are recorded during validation
+	if clb.parent.validator != nil {
+		item.SpecDifferences = clb.parent.validator(bytes)
+	}
+
 	//translate obj type string to int64 representation
 	objType := clb.parent.translator(item.ObjectType)
 
@@ -226,6 +327,14 @@ func (clb *ChangeLogBundle) AddItem(bytes []byte, meta ChangeMetadata) error {
 	return nil
 }
 
+// Target name of the target the bundle's change log is applied to
+func (clb *ChangeLogBundle) Target() string {
+	if clb.parent == nil {
+		return ""
+	}
+	return clb.parent.Target
+}
+
 func (clb *ChangeLogBundle) bytesToSpec(bytes []byte) (*CodeBlockSpec, error) {
 	var spec CodeBlockSpec
 	err := yaml.Unmarshal(bytes, &spec)
@@ -236,8 +345,25 @@ func (clb *ChangeLogBundle) bytesToSpec(bytes []byte) (*CodeBlockSpec, error) {
 }
 
 type ChangeLog struct {
+	Target     string             `yaml:"target"`
 	Bundles    []*ChangeLogBundle `yaml:"bundles"`
 	translator ObjectTypeTranslator
+	routing    TargetRouting
+	validator  SpecValidator
+}
+
+// SetSpecValidator validates specs added to the change log against the schema of the target
+func (cl *ChangeLog) SetSpecValidator(validator SpecValidator) {
+	cl.validator = validator
+}
+
+// Items the items of every bundle in change log order
+func (cl *ChangeLog) Items() []*ChangeItem {
+	items := make([]*ChangeItem, 0)
+	for _, bundle := range cl.Bundles {
+		items = append(items, bundle.Items...)
+	}
+	return items
 }
 
 func (cl *ChangeLog) AddBundle(commit *object.Commit) *ChangeLogBundle {
@@ -317,3 +443,31 @@ func NewChangeMetaFromOptions(options *Options) ChangeMetadata {
 func NewChangeLog(typeTranslator ObjectTypeTranslator) *ChangeLog {
 	return &ChangeLog{Bundles: make([]*ChangeLogBundle, 0), translator: typeTranslator}
 }
+
+// NewTargetChangeLog creates a change log for a single named target, only items the routing resolves to the
+// target are added
+func NewTargetChangeLog(target string, routing TargetRouting, typeTranslator ObjectTypeTranslator) *ChangeLog {
+	return &ChangeLog{Target: target, Bundles: make([]*ChangeLogBundle, 0), translator: typeTranslator, routing: routing}
+}
+
+// ChangeSet the change logs of all targets within an operation, in target processing order
+type ChangeSet struct {
+	Logs []*ChangeLog
+}
+
+func NewChangeSet() *ChangeSet {
+	return &ChangeSet{Logs: make([]*ChangeLog, 0)}
+}
+
+func (cs *ChangeSet) Add(log *ChangeLog) {
+	cs.Logs = append(cs.Logs, log)
+}
+
+func (cs *ChangeSet) IsEmpty() bool {
+	for _, log := range cs.Logs {
+		if len(log.Bundles) > 0 {
+			return false
+		}
+	}
+	return true
+}
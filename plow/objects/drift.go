@@ -0,0 +1,38 @@
+package objects
+
+import "fmt"
+
+// DriftKind how the live object differs from its spec
+type DriftKind string
+
+const (
+	// DriftMissing declared by the spec and absent from the target
+	DriftMissing DriftKind = "missing"
+	// DriftExtra present on the target and not declared by the spec
+	DriftExtra DriftKind = "extra"
+	// DriftChanged declared by the spec with a different value on the target
+	DriftChanged DriftKind = "changed"
+)
+
+// DriftEntry an attribute of an object on the target which differs from the spec at the tracked commit
+type DriftEntry struct {
+	Target     string    `json:"target"`
+	File       string    `json:"file"`
+	ObjectType string    `json:"type"`
+	Object     string    `json:"object"`
+	Attribute  string    `json:"attribute"`
+	Kind       DriftKind `json:"kind"`
+	Expected   string    `json:"expected,omitempty"`
+	Actual     string    `json:"actual,omitempty"`
+}
+
+func (d DriftEntry) String() string {
+	switch d.Kind {
+	case DriftMissing:
+		return fmt.Sprintf("[%s] %s %s missing: [%s]", d.ObjectType, d.Object, d.Attribute, d.Expected)
+	case DriftExtra:
+		return fmt.Sprintf("[%s] %s %s extra: [%s]", d.ObjectType, d.Object, d.Attribute, d.Actual)
+	default:
+		return fmt.Sprintf("[%s] %s %s changed: expected [%s] actual [%s]", d.ObjectType, d.Object, d.Attribute, d.Expected, d.Actual)
+	}
+}
@@ -0,0 +1,16 @@
+package objects
+
+import "fmt"
+
+// LintFinding an issue identified within a spec file without connecting to the target
+type LintFinding struct {
+	File     string
+	Line     int
+	Severity ValidationErrorSeverity
+	Rule     string
+	Message  string
+}
+
+func (f LintFinding) String() string {
+	return fmt.Sprintf("%s:%d: [%s] %s: %s", f.File, f.Line, f.Severity, f.Rule, f.Message)
+}
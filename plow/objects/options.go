@@ -31,10 +31,12 @@ type FileInfo struct {
 }
 
 type Options struct {
-	OptionFlags    Flags
-	BranchOverride *string
-	CommitId       *string
-	File           *FileInfo
+	OptionFlags       Flags
+	BranchOverride    *string
+	CommitId          *string
+	File              *FileInfo
+	Environment       string
+	ValidationWorkers int
 }
 
 func (o *Options) EvaluateTargetCommit(commits []*object.Commit) (*object.Commit, error) {
@@ -43,4 +43,5 @@ type CodeBlockSpec struct {
 type CodeBlockHeaderSpec struct {
 	DefinitionStyle string `yaml:"definitionStyle"`
 	Type            string `yaml:"type"`
+	Target          string `yaml:"target,omitempty"`
 }
@@ -26,6 +26,29 @@ type LogEntry struct {
 	FastForward       bool
 }
 
+// RestorePoint the state of a table or schema captured immediately before a commit changed it
+type RestorePoint struct {
+	TrackingId string
+	ObjectType string
+	Object     string
+	Owner      string
+	Existed    bool
+	Dropped    bool
+	Captured   time.Time
+	Retention  int
+}
+
+// RollbackItemEntry the outcome of restoring an object changed by a commit
+type RollbackItemEntry struct {
+	TrackingId string
+	ObjectType string
+	Object     string
+	Statement  string
+	Status     bool
+	ApplyDate  time.Time
+	Message    string
+}
+
 type TrackingLog struct {
 	Empty bool
 	index map[string]int
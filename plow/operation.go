@@ -7,29 +7,43 @@ import (
 	"Plow/plow/targets/common"
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 )
 
 type Operation struct {
-	config  Configuration
-	options objects.Options
-	target  common.Target
-	repo    *Repo
+	config        Configuration
+	options       objects.Options
+	targets       map[string]common.Target
+	targetOrder   []string
+	defaultTarget string
+	repo          *Repo
 }
 
 func NewOperation(config Configuration, options objects.Options) (*Operation, error) {
-	operation := &Operation{config: config, options: options}
+	operation := &Operation{config: config, options: options, targets: make(map[string]common.Target)}
 
-	//unpack config and init secret store and target
+	//unpack config and init secret store and target(s)
 	//secret store
 	secretStr, err := secrets.InitKeyVault(config.SecretStoreType, config.SecretStore)
 	if err != nil {
 		return nil, err
 	}
-	target, err := targets.NewTarget(config.TargetType, config.Target, &operation.options, secretStr)
+
+	targetConfigs, order, defaultTarget, err := operation.config.ResolveTargets()
 	if err != nil {
 		return nil, err
 	}
-	operation.target = target
+	for _, name := range order {
+		targetConfig := targetConfigs[name]
+		target, err := targets.NewTarget(targetConfig.TargetType, targetConfig.Target, &operation.options, secretStr)
+		if err != nil {
+			return nil, fmt.Errorf("target [%s]: %w", name, err)
+		}
+		operation.targets[name] = target
+	}
+	operation.targetOrder = order
+	operation.defaultTarget = defaultTarget
 
 	var repo *Repo
 	//init repo instance
@@ -55,60 +69,220 @@ func (o *Operation) Repository() *Repo {
 	return o.repo
 }
 
-func (o *Operation) GenerateChangeLog() (*objects.ChangeLog, error) {
-	if o.options.IsFileProvided() {
-		changes := objects.NewChangeLog(o.target.GetObjectTypeTranslator())
-		bundle := changes.AddManualBundle()
-		err := bundle.AddItem(o.options.File.Bytes, objects.NewChangeMetaFromOptions(&o.options))
-		if err != nil {
-			return nil, err
+// TargetNames names of the configured targets in processing order
+func (o *Operation) TargetNames() []string {
+	return o.targetOrder
+}
+
+// GenerateChangeLog builds a change log for each target, targets without changes are omitted from the result.
+// ErrNoCommitsToProcess is only returned when none of the targets have commits to process.
+func (o *Operation) GenerateChangeLog() (*objects.ChangeSet, error) {
+	set := objects.NewChangeSet()
+	pending := false
+
+	for _, name := range o.targetOrder {
+		target := o.targets[name]
+		changes := objects.NewTargetChangeLog(name, o.routeSpec, target.GetObjectTypeTranslator())
+		changes.SetSpecValidator(common.NewSpecValidator(target.GetSpecSchema()))
+
+		if o.options.IsFileProvided() {
+			bundle := changes.AddManualBundle()
+			err := bundle.AddItem(o.options.File.Bytes, objects.NewChangeMetaFromOptions(&o.options))
+			if err != nil {
+				return nil, err
+			}
+			if len(bundle.Items) == 0 {
+				continue
+			}
+			pending = true
+		} else {
+			err := o.listRepositoryChanges(target, changes)
+			if err == ErrNoCommitsToProcess {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("target [%s]: %w", name, err)
+			}
+			pending = true
 		}
-		return changes, nil
-	} else {
-		return o.listRepositoryChanges()
+
+		set.Add(changes)
 	}
+
+	if !pending && !o.options.IsFileProvided() {
+		return set, ErrNoCommitsToProcess
+	}
+	return set, nil
 }
 
-func (o *Operation) listRepositoryChanges() (*objects.ChangeLog, error) {
-	history, err := o.target.GetTrackingHistory(0)
+func (o *Operation) listRepositoryChanges(target common.Target, changes *objects.ChangeLog) error {
+	history, err := target.GetTrackingHistory(0)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return o.repo.BuildChangeLog(history, o.target.GetObjectTypeTranslator())
+	return o.repo.BuildChangeLog(history, changes)
 }
 
-func (o *Operation) ValidateChanges(changes *objects.ChangeLog) error {
+// routeSpec resolves the target a spec is applied to, specs without a target header are routed to the default target
+func (o *Operation) routeSpec(header *objects.CodeBlockHeaderSpec) (string, error) {
+	name := normalizeTargetName(header.Target)
+	if len(name) == 0 {
+		if len(o.defaultTarget) == 0 {
+			return "", ErrNoDefaultTarget
+		}
+		return o.defaultTarget, nil
+	}
+	if _, ok := o.targets[name]; !ok {
+		return "", fmt.Errorf("spec references unknown target [%s]", header.Target)
+	}
+	return name, nil
+}
+
+func (o *Operation) ValidateChanges(changes *objects.ChangeSet) error {
 	if o.options.OptionFlags.Has(objects.SkipValidationSetting) {
 		return errors.New("cannot validate changes, skip validation option was set")
 	}
 
-	err := o.target.ValidateChangeLog(changes)
-	if err != nil {
-		return err
+	for _, log := range changes.Logs {
+		err := o.targets[log.Target].ValidateChangeLog(log)
+		if err != nil {
+			return fmt.Errorf("target [%s]: %w", log.Target, err)
+		}
+	}
+	return nil
+}
+
+// RehearseChanges executes the validated change log of each target against clones of the target's databases, the
+// outcome of each item is recorded within its apply information. Targets are rehearsed independently, an error
+// rehearsing one target does not prevent the remaining targets being rehearsed.
+func (o *Operation) RehearseChanges(context context.Context, changes *objects.ChangeSet) error {
+	failed := make([]string, 0)
+	for _, log := range changes.Logs {
+		if err := o.targets[log.Target].RehearseChangeLog(context, log); err != nil {
+			failed = append(failed, fmt.Sprintf("target [%s]: %s", log.Target, err.Error()))
+		}
+	}
+	if len(failed) > 0 {
+		return errors.New(strings.Join(failed, "; "))
 	}
 	return nil
 }
 
-func (o *Operation) RenderChanges(changes *objects.ChangeLog) ([]*common.RenderedChange, error) {
+// RollbackCommit restores the objects changed by the commit on each target, targets are rolled back in reverse target
+// order. Targets without restore points for the commit, or not supporting rollback, are skipped. The outcome of each
+// restored object is returned keyed by target name.
+func (o *Operation) RollbackCommit(context context.Context, commit string) (map[string][]objects.RollbackItemEntry, error) {
+	results := make(map[string][]objects.RollbackItemEntry)
+	failed := make([]string, 0)
+	for i := len(o.targetOrder) - 1; i >= 0; i-- {
+		name := o.targetOrder[i]
+		entries, err := o.targets[name].RollbackCommit(context, commit)
+		if err == common.ErrNoRestorePoints || err == common.ErrNotImplemented {
+			continue
+		}
+		if entries != nil {
+			results[name] = entries
+		}
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("target [%s]: %s", name, err.Error()))
+		}
+	}
+
+	if len(failed) > 0 {
+		return results, errors.New(strings.Join(failed, "; "))
+	}
+	if len(results) == 0 {
+		return results, fmt.Errorf("commit [%s]: %w", commit, common.ErrNoRestorePoints)
+	}
+	return results, nil
+}
+
+// DetectDrift compares the specs of the repo at the last commit applied to each target with the live objects of the
+// target. Targets not supporting drift detection are skipped, an error comparing one target does not prevent the
+// remaining targets being compared. The differences found are returned keyed by target name.
+func (o *Operation) DetectDrift() (map[string][]objects.DriftEntry, error) {
+	results := make(map[string][]objects.DriftEntry)
+	failed := make([]string, 0)
+	for _, name := range o.targetOrder {
+		target := o.targets[name]
+		changes := objects.NewTargetChangeLog(name, o.routeSpec, target.GetObjectTypeTranslator())
+		changes.SetSpecValidator(common.NewSpecValidator(target.GetSpecSchema()))
+
+		history, err := target.GetTrackingHistory(0)
+		if err == nil && history.Empty {
+			err = common.ErrNoChangeHistory
+		}
+		if err == nil {
+			err = o.repo.BuildManifest(history, changes)
+		}
+		var entries []objects.DriftEntry
+		if err == nil {
+			entries, err = target.DetectDrift(changes)
+		}
+		if err == common.ErrNotImplemented {
+			continue
+		}
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("target [%s]: %s", name, err.Error()))
+			continue
+		}
+		results[name] = entries
+	}
+
+	if len(failed) > 0 {
+		return results, errors.New(strings.Join(failed, "; "))
+	}
+	return results, nil
+}
+
+func (o *Operation) RenderChanges(changes *objects.ChangeSet) ([]*common.RenderedChange, error) {
 	if !o.options.OptionFlags.Has(objects.SkipValidationSetting) {
 		err := o.ValidateChanges(changes)
 		if err != nil {
 			return nil, err
 		}
 	}
-	return o.target.RenderChangeLog(changes)
+
+	rendered := make([]*common.RenderedChange, 0)
+	for _, log := range changes.Logs {
+		rc, err := o.targets[log.Target].RenderChangeLog(log)
+		if err != nil {
+			return nil, fmt.Errorf("target [%s]: %w", log.Target, err)
+		}
+		rendered = append(rendered, rc...)
+	}
+	return rendered, nil
 }
 
-func (o *Operation) ApplyChanges(context context.Context, changes *objects.ChangeLog) error {
+// ApplyChanges applies the change log of each target in target order, each target records its own tracking
+// information. Application halts at the first target reporting an error, the change logs of the remaining targets
+// are left unapplied.
+func (o *Operation) ApplyChanges(context context.Context, changes *objects.ChangeSet) error {
 	if !o.options.OptionFlags.Has(objects.SkipValidationSetting) {
 		err := o.ValidateChanges(changes)
 		if err != nil {
 			return err
 		}
 	}
-	return o.target.ApplyChangeLog(context, changes)
+
+	for i, log := range changes.Logs {
+		if err := o.targets[log.Target].ApplyChangeLog(context, log); err != nil {
+			skipped := make([]string, 0)
+			for _, remaining := range changes.Logs[i+1:] {
+				skipped = append(skipped, remaining.Target)
+			}
+			if len(skipped) > 0 {
+				return fmt.Errorf("target [%s]: %w, targets not applied [%s]", log.Target, err, strings.Join(skipped, ", "))
+			}
+			return fmt.Errorf("target [%s]: %w", log.Target, err)
+		}
+	}
+	return nil
 }
 
-func (o *Operation) GetExecutionOrder() []int64 {
-	return o.target.GetObjectTypeExecutionOrder()
+func (o *Operation) GetExecutionOrder(target string) []int64 {
+	if t, ok := o.targets[target]; ok {
+		return t.GetObjectTypeExecutionOrder()
+	}
+	return []int64{}
 }
@@ -200,9 +200,7 @@ func (r *Repo) ReadBlob(file *object.File) ([]byte, error) {
 	return buffer, nil
 }
 
-func (r *Repo) BuildChangeLog(log *objects.TrackingLog, translator objects.ObjectTypeTranslator) (*objects.ChangeLog, error) {
-	rez := objects.NewChangeLog(translator)
-
+func (r *Repo) BuildChangeLog(log *objects.TrackingLog, rez *objects.ChangeLog) error {
 	var last *object.Commit
 
 	//if this is a fast forward or single file execution ignore tracking history
@@ -211,12 +209,12 @@ func (r *Repo) BuildChangeLog(log *objects.TrackingLog, translator objects.Objec
 
 		lastTracked := log.GetLastProcessed()
 		if lastTracked == nil {
-			return nil, errors.New("unable to get latest commit from objects log")
+			return errors.New("unable to get latest commit from objects log")
 		}
 
 		ltCommit, err := r.getCommit(lastTracked.TrackingId)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		last = ltCommit
@@ -224,17 +222,17 @@ func (r *Repo) BuildChangeLog(log *objects.TrackingLog, translator objects.Objec
 
 	commits, err := r.buildWorkList(last)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	if len(commits) == 0 {
-		return nil, ErrNoCommitsToProcess
+		return ErrNoCommitsToProcess
 	}
 
 	if !r.options.OptionFlags.Has(objects.FastForwardSetting) {
 		if last == nil {
 			//just in case this has not been evaluated prior
-			return nil, ErrNoLastCommitFound
+			return ErrNoLastCommitFound
 		}
 		//step through commit list and load changes,
 		//changes will consist of only modified objects between two commits, not the full digest of the repo
@@ -248,34 +246,34 @@ func (r *Repo) BuildChangeLog(log *objects.TrackingLog, translator objects.Objec
 
 			changes, err := r.GetDiffChanges(prev, commits[i])
 			if err != nil {
-				return nil, err
+				return err
 			}
 
 			commit := commits[i]
 			for _, change := range changes {
 				action, err := change.Action()
 				if err != nil {
-					return nil, errors.New("unable to determine git change action")
+					return errors.New("unable to determine git change action")
 				}
 				if action != merkletrie.Delete {
 					tree, err := commit.Tree()
 					if err != nil {
-						return nil, err
+						return err
 					}
 
 					file, err := tree.File(change.To.Name)
 					if err != nil {
-						return nil, err
+						return err
 					}
 
 					bytes, err := r.ReadBlob(file)
 					if err != nil {
-						return nil, err
+						return err
 					}
 
 					err = bundle.AddItem(bytes, objects.NewChangeMetaFromGitChange(change))
 					if err != nil {
-						return nil, err
+						return err
 					}
 				}
 			}
@@ -286,27 +284,52 @@ func (r *Repo) BuildChangeLog(log *objects.TrackingLog, translator objects.Objec
 		// based on the full digest of the repo tree at that commit point
 		// we only care about the first commit, and there will be only one change bundle
 
-		bundle := rez.AddBundle(commits[0])
-		tree, err := commits[0].Tree()
-		if err != nil {
-			return nil, err
+		if err := r.addTreeFiles(rez.AddBundle(commits[0]), commits[0]); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		fIter := tree.Files()
-		file, err := fIter.Next()
-		for err != io.EOF {
-			bytes, err_inr := r.ReadBlob(file)
-			if err != nil {
-				return nil, err_inr
-			}
-			err_inr = bundle.AddItem(bytes, objects.NewChangeMetaFromGitFileTreeItem(file))
-			if err_inr != nil {
-				return nil, err_inr
-			}
-			file, err = fIter.Next()
+// BuildManifest loads every file of the repo tree at the last commit processed by the target into a single bundle,
+// the specs describing the state the target is expected to be in
+func (r *Repo) BuildManifest(log *objects.TrackingLog, rez *objects.ChangeLog) error {
+	lastTracked := log.GetLastProcessed()
+	if lastTracked == nil {
+		return errors.New("unable to get latest commit from objects log")
+	}
+
+	commit, err := r.getCommit(lastTracked.TrackingId)
+	if err != nil {
+		return err
+	}
+	return r.addTreeFiles(rez.AddBundle(commit), commit)
+}
+
+// addTreeFiles adds every file of the repo tree at the commit to the bundle
+func (r *Repo) addTreeFiles(bundle *objects.ChangeLogBundle, commit *object.Commit) error {
+	tree, err := commit.Tree()
+	if err != nil {
+		return err
+	}
+
+	fIter := tree.Files()
+	file, err := fIter.Next()
+	for err != io.EOF {
+		if err != nil {
+			return err
+		}
+		bytes, err_inr := r.ReadBlob(file)
+		if err_inr != nil {
+			return err_inr
+		}
+		err_inr = bundle.AddItem(bytes, objects.NewChangeMetaFromGitFileTreeItem(file))
+		if err_inr != nil {
+			return err_inr
 		}
+		file, err = fIter.Next()
 	}
-	return rez, nil
+	return nil
 }
 
 func (r *Repo) buildWorkList(last *object.Commit) ([]*object.Commit, error) {
@@ -21,6 +21,10 @@ var (
 	ErrInternalError            = errors.New("something very bad happened")
 	ErrInvalidTrackingStructure = errors.New("invalid or missing objects structure found on target")
 	ErrNoChangeHistory          = errors.New("no change history found on target")
+	ErrNoRestorePoints          = errors.New("no restore points recorded for commit")
+	ErrInvalidPolicy            = errors.New("invalid policy")
+	ErrInvalidValidatorConfig   = errors.New("invalid validator configuration")
+	ErrValidationTerminated     = errors.New("validation terminated on critical failure")
 )
 
 type Command int
@@ -41,7 +45,11 @@ type Target interface {
 	ValidateChangeLog(changes *objects.ChangeLog) error
 	RenderChangeLog(changes *objects.ChangeLog) ([]*RenderedChange, error)
 	ApplyChangeLog(context context.Context, changes *objects.ChangeLog) error
+	RehearseChangeLog(context context.Context, changes *objects.ChangeLog) error
+	RollbackCommit(context context.Context, commit string) ([]objects.RollbackItemEntry, error)
+	DetectDrift(changes *objects.ChangeLog) ([]objects.DriftEntry, error)
 	Close() error
 	GetObjectTypeTranslator() objects.ObjectTypeTranslator
 	GetObjectTypeExecutionOrder() []int64
+	GetSpecSchema() *JSONSchema
 }
@@ -0,0 +1,218 @@
+package common
+
+import (
+	"Plow/plow/objects"
+	"errors"
+	"fmt"
+	"github.com/noirbizarre/gonja"
+	"gopkg.in/yaml.v3"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// rules reported by the linter
+const (
+	LintRuleSyntax          = "syntax"
+	LintRuleSchema          = "schema"
+	LintRuleDefinitionStyle = "definition-style"
+	LintRuleUnknownType     = "unknown-type"
+	LintRuleMissingInit     = "missing-init"
+	LintRuleProtectedRole   = "protected-role"
+	LintRuleUseRole         = "use-role"
+	LintRuleTemplate        = "template"
+	LintRulePlaceholder     = "placeholder"
+)
+
+var (
+	regexYamlErrorLine    = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+	regexTemplateVariable = regexp.MustCompile(`\{\{-?\s*([A-Za-z_][A-Za-z0-9_]*)`)
+)
+
+// LintDocument a spec file parsed for linting, the yaml node tree is retained so findings are reported against the
+// line of the element they concern
+type LintDocument struct {
+	File     string
+	Spec     *objects.CodeBlockSpec
+	Findings []objects.LintFinding
+	root     *yaml.Node
+}
+
+// NewLintDocument parses the spec file, the spec is nil when the file is not a spec, i.e. a yaml file declaring
+// neither a definition style nor a type, those files are not applied and are not linted. Malformed yaml is reported
+// as a finding.
+func NewLintDocument(file string, bytes []byte) *LintDocument {
+	doc := &LintDocument{File: file, Findings: make([]objects.LintFinding, 0)}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(bytes, &root); err != nil {
+		doc.addError(LintRuleSyntax, err)
+		return doc
+	}
+	if len(root.Content) == 0 {
+		return doc
+	}
+	doc.root = root.Content[0]
+
+	var spec objects.CodeBlockSpec
+	if err := doc.root.Decode(&spec); err != nil {
+		//not a mapping, or the header itself is malformed, only report files which look like a spec
+		if doc.Node("type") != nil || doc.Node("definitionStyle") != nil {
+			doc.addError(LintRuleSchema, err)
+		}
+		return doc
+	}
+	if len(strings.TrimSpace(spec.Type)) == 0 && len(strings.TrimSpace(spec.DefinitionStyle)) == 0 {
+		return doc
+	}
+	doc.Spec = &spec
+	return doc
+}
+
+// Add records a finding against the line
+func (d *LintDocument) Add(line int, severity objects.ValidationErrorSeverity, rule string, message string) {
+	d.Findings = append(d.Findings, objects.LintFinding{File: d.File, Line: line, Severity: severity, Rule: rule, Message: message})
+}
+
+// addError records a yaml error as critical, the line is taken from the error when present
+func (d *LintDocument) addError(rule string, err error) {
+	messages := []string{err.Error()}
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		messages = typeErr.Errors
+	}
+
+	for _, message := range messages {
+		line := d.Line()
+		if match := regexYamlErrorLine.FindStringSubmatch(message); match != nil {
+			line, _ = strconv.Atoi(match[1])
+			message = match[2]
+		}
+		d.Add(line, objects.ValidationErrorCritical, rule, message)
+	}
+}
+
+// Node the node of the element at the path of mapping keys and sequence indexes, nil when the element is not present
+func (d *LintDocument) Node(path ...string) *yaml.Node {
+	node := d.root
+	for _, key := range path {
+		node = mappingValue(node, key)
+		if node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// Line the line of the element at the path, or of the closest element present along the path
+func (d *LintDocument) Line(path ...string) int {
+	if d.root == nil {
+		return 1
+	}
+	line := d.root.Line
+	node := d.root
+	for _, key := range path {
+		if node = mappingValue(node, key); node == nil {
+			break
+		}
+		line = node.Line
+	}
+	return line
+}
+
+// CheckConformance validates the document against the spec schema of the target, each violation is reported as a
+// finding of the same severity
+func (d *LintDocument) CheckConformance(schema *JSONSchema) {
+	if d.root == nil {
+		return
+	}
+	for _, violation := range schema.Validate(d.root) {
+		d.Add(violation.Line, violation.Severity, LintRuleSchema, violation.String())
+	}
+}
+
+// CheckSchema decodes the element at the path into out, returns false when the element is not present or can not be
+// decoded. Values which can not be decoded and unknown elements are reported by the conformance check against the
+// spec schema of the target.
+func (d *LintDocument) CheckSchema(out interface{}, path ...string) bool {
+	node := d.Node(path...)
+	if node == nil {
+		return false
+	}
+	return node.Decode(out) == nil
+}
+
+// EachLine calls fn with every line of the scalar element at the path along with the line number within the file
+func (d *LintDocument) EachLine(fn func(line int, text string), path ...string) {
+	node := d.Node(path...)
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return
+	}
+	//the content of block scalars starts on the line following the indicator
+	first := node.Line
+	if node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+		first++
+	}
+	for i, text := range strings.Split(node.Value, "\n") {
+		fn(first+i, text)
+	}
+}
+
+// CheckTemplate verifies the scope at the path parses as a template and only references the placeholders provided
+// when the scope is rendered, undefined placeholders render as empty values
+func (d *LintDocument) CheckTemplate(allowed []string, path ...string) {
+	node := d.Node(path...)
+	if node == nil || len(strings.TrimSpace(node.Value)) == 0 {
+		return
+	}
+	name := strings.Join(path, ".")
+	if _, err := gonja.FromString(node.Value); err != nil {
+		d.Add(node.Line, objects.ValidationErrorCritical, LintRuleTemplate, fmt.Sprintf("[%s] %s", name, err.Error()))
+	}
+
+	known := make(map[string]bool)
+	for _, placeholder := range allowed {
+		known[placeholder] = true
+	}
+	d.EachLine(func(line int, text string) {
+		for _, match := range regexTemplateVariable.FindAllStringSubmatch(text, -1) {
+			if !known[match[1]] {
+				d.Add(line, objects.ValidationErrorCritical, LintRulePlaceholder,
+					fmt.Sprintf("[%s] unresolved placeholder {{%s}}, expected one of [%s]", name, match[1], strings.Join(allowed, ", ")))
+			}
+		}
+	}, path...)
+}
+
+// CheckInitPresent a change scope is only applied alongside an init scope, a change scope without one is never applied
+func (d *LintDocument) CheckInitPresent(init string, change string, path ...string) {
+	if len(strings.TrimSpace(change)) > 0 && len(strings.TrimSpace(init)) == 0 {
+		d.Add(d.Line(append(path, "change")...), objects.ValidationErrorCritical, LintRuleMissingInit,
+			"change scope is present without an init scope, the change scope is not applied")
+	}
+}
+
+// mappingValue the value of the key within the mapping node, or the item at the index when the node is a sequence
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind == yaml.SequenceNode {
+		if index, err := strconv.Atoi(key); err == nil && index >= 0 && index < len(node.Content) {
+			return node.Content[index]
+		}
+		return nil
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -8,9 +8,9 @@ import (
 )
 
 type Property struct {
-	Name  string
-	Value string
-	IsKey bool
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	IsKey bool   `json:"key,omitempty"`
 }
 
 type MetadataObject struct {
@@ -73,7 +73,7 @@ func (m *Metadata) AddObject(obj *MetadataObject) {
 	if _, ok := m.contents[obj.ObjectType]; !ok {
 		m.contents[obj.ObjectType] = MetadataMap{objects: make(map[string][]*MetadataObject)}
 	}
-	if _, ok := m.contents[obj.ObjectType].objects[obj.GetKey()]; ok {
+	if _, ok := m.contents[obj.ObjectType].objects[obj.GetKey()]; !ok {
 		m.contents[obj.ObjectType].objects[obj.GetKey()] = make([]*MetadataObject, 0)
 	}
 
@@ -0,0 +1,418 @@
+package common
+
+import (
+	"Plow/plow/objects"
+	"Plow/plow/utility"
+	"bytes"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// PolicyValidatorName designation of the validation step reporting policy violations
+const PolicyValidatorName = "PolicyValidator"
+
+// Policy guardrails evaluated for every change against the parsed spec and the commands rendered for it. Rules are
+// declared within a yaml policy file:
+//
+//	rules:
+//	  - name: no-public-grants
+//	    severity: critical
+//	    deny:
+//	      - command: '(?i)\bTO\s+ROLE\s+PUBLIC\b'
+//	  - name: view-naming
+//	    severity: warning
+//	    types: [view]
+//	    require:
+//	      - field: object.name
+//	        pattern: '^V_'
+type Policy struct {
+	Rules []*PolicyRule `yaml:"rules"`
+}
+
+// PolicyRule a rule applies to changes of the listed types within the listed environments, all when applying to every
+// change when not listed, and for which every when condition holds. A change violates the rule when a deny condition
+// holds or a require condition does not.
+type PolicyRule struct {
+	Name         string             `yaml:"name"`
+	Description  string             `yaml:"description"`
+	Severity     string             `yaml:"severity"`
+	Types        []string           `yaml:"types"`
+	Environments []string           `yaml:"environments"`
+	When         []*PolicyCondition `yaml:"when"`
+	Require      []*PolicyCondition `yaml:"require"`
+	Deny         []*PolicyCondition `yaml:"deny"`
+	severity     objects.ValidationErrorSeverity
+}
+
+// PolicyCondition tests one of a spec field, the rendered commands or the commit message of the change.
+//   - field: the dot separated path of the element within the spec file, e.g. spec.meta.owner.id. Elements of a
+//     sequence along the path are each tested. The field is tested against pattern or in, when neither is declared
+//     the field must be present.
+//   - command: a pattern matched against each rendered command.
+//   - commit: a pattern matched against the commit message.
+type PolicyCondition struct {
+	Field   string   `yaml:"field"`
+	Pattern string   `yaml:"pattern"`
+	In      []string `yaml:"in"`
+	Command string   `yaml:"command"`
+	Commit  string   `yaml:"commit"`
+	regex   *regexp.Regexp
+}
+
+// policyInput the change as seen by the policy rules
+type policyInput struct {
+	spec     interface{}
+	commands []string
+	commit   string
+}
+
+// LoadPolicy reads and compiles the policy file
+func LoadPolicy(path string) (*Policy, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, utility.WrapError(fmt.Sprintf("unable to read policy file [%s]", path), err)
+	}
+	return ParsePolicy(content)
+}
+
+// ParsePolicy parses and compiles the policy, unknown elements, severities or malformed patterns are rejected
+func ParsePolicy(content []byte) (*Policy, error) {
+	var policy Policy
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&policy); err != nil {
+		return nil, utility.WrapError(err.Error(), ErrInvalidPolicy)
+	}
+
+	names := make(map[string]bool)
+	for i, rule := range policy.Rules {
+		if len(strings.TrimSpace(rule.Name)) == 0 {
+			return nil, utility.WrapError(fmt.Sprintf("rule [%d] has no name", i), ErrInvalidPolicy)
+		}
+		if names[rule.Name] {
+			return nil, utility.WrapError(fmt.Sprintf("rule [%s] declared more than once", rule.Name), ErrInvalidPolicy)
+		}
+		names[rule.Name] = true
+		if err := rule.compile(); err != nil {
+			return nil, utility.WrapError(fmt.Sprintf("rule [%s] %s", rule.Name, err.Error()), ErrInvalidPolicy)
+		}
+	}
+	return &policy, nil
+}
+
+func (r *PolicyRule) compile() error {
+	switch strings.ToLower(strings.TrimSpace(r.Severity)) {
+	case "critical", "":
+		r.severity = objects.ValidationErrorCritical
+	case "warning", "warn":
+		r.severity = objects.ValidationErrorWarn
+	case "info":
+		r.severity = objects.ValidationErrorInfo
+	default:
+		return fmt.Errorf("severity [%s], expected one of [critical, warning, info]", r.Severity)
+	}
+
+	if len(r.Require) == 0 && len(r.Deny) == 0 {
+		return errors.New("declares neither require nor deny conditions")
+	}
+	for _, conditions := range [][]*PolicyCondition{r.When, r.Require, r.Deny} {
+		for _, condition := range conditions {
+			if err := condition.compile(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *PolicyCondition) compile() error {
+	declared := 0
+	for _, subject := range []string{c.Field, c.Command, c.Commit} {
+		if len(strings.TrimSpace(subject)) > 0 {
+			declared++
+		}
+	}
+	if declared != 1 {
+		return errors.New("each condition must declare exactly one of [field, command, commit]")
+	}
+
+	pattern := c.Pattern
+	switch {
+	case len(c.Field) == 0 && (len(c.Pattern) > 0 || len(c.In) > 0):
+		return errors.New("pattern and in apply to field conditions only")
+	case len(c.Pattern) > 0 && len(c.In) > 0:
+		return fmt.Errorf("field [%s] declares both pattern and in", c.Field)
+	case len(c.Command) > 0:
+		pattern = c.Command
+	case len(c.Commit) > 0:
+		pattern = c.Commit
+	}
+	if len(pattern) == 0 {
+		return nil
+	}
+
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("pattern [%s] %s", pattern, err.Error())
+	}
+	c.regex = regex
+	return nil
+}
+
+// appliesTo the rule applies to the type within the environment, the when conditions are evaluated separately
+func (r *PolicyRule) appliesTo(objectType string, environment string) bool {
+	return listed(r.Types, objectType) && listed(r.Environments, environment)
+}
+
+// usesCommands the rule tests the rendered commands
+func (r *PolicyRule) usesCommands() bool {
+	for _, conditions := range [][]*PolicyCondition{r.When, r.Require, r.Deny} {
+		for _, condition := range conditions {
+			if len(condition.Command) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// evaluate the differences of the change from the rule, none when the rule is satisfied or does not apply
+func (r *PolicyRule) evaluate(input *policyInput) []objects.ValidationDifference {
+	for _, condition := range r.When {
+		if _, ok := condition.holds(input); !ok {
+			return nil
+		}
+	}
+
+	differences := make([]objects.ValidationDifference, 0)
+	subject := fmt.Sprintf("rule [%s]", r.Name)
+	for _, condition := range r.Deny {
+		if actual, ok := condition.holds(input); ok {
+			differences = append(differences, objects.ValidationDifference{Subject: subject, Attribute: condition.attribute(),
+				Expected: "not " + condition.expected(), Actual: actual, Severity: r.severity})
+		}
+	}
+	for _, condition := range r.Require {
+		for _, actual := range condition.unsatisfied(input) {
+			differences = append(differences, objects.ValidationDifference{Subject: subject, Attribute: condition.attribute(),
+				Expected: condition.expected(), Actual: actual, Severity: r.severity})
+		}
+	}
+	return differences
+}
+
+// holds the condition holds when any field value, command or the commit message matches, the matching value is
+// returned
+func (c *PolicyCondition) holds(input *policyInput) (string, bool) {
+	for _, value := range c.values(input) {
+		if c.matches(value) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// unsatisfied the values which do not match, every field value must match and an absent field never does
+func (c *PolicyCondition) unsatisfied(input *policyInput) []string {
+	values := c.values(input)
+	if len(c.Field) > 0 {
+		if len(values) == 0 {
+			return []string{"absent"}
+		}
+		out := make([]string, 0)
+		for _, value := range values {
+			if !c.matches(value) {
+				out = append(out, value)
+			}
+		}
+		return out
+	}
+
+	//commands and commit messages are required to match once
+	if _, ok := c.holds(input); ok {
+		return nil
+	}
+	if len(c.Commit) > 0 {
+		return []string{firstLine(input.commit)}
+	}
+	return []string{"no matching command"}
+}
+
+func (c *PolicyCondition) values(input *policyInput) []string {
+	switch {
+	case len(c.Command) > 0:
+		return input.commands
+	case len(c.Commit) > 0:
+		return []string{input.commit}
+	default:
+		return fieldValues(input.spec, strings.Split(c.Field, "."))
+	}
+}
+
+func (c *PolicyCondition) matches(value string) bool {
+	if c.regex != nil {
+		return c.regex.MatchString(value)
+	}
+	if len(c.In) > 0 {
+		return listed(c.In, value)
+	}
+	//presence of the field
+	return true
+}
+
+func (c *PolicyCondition) attribute() string {
+	switch {
+	case len(c.Command) > 0:
+		return "command"
+	case len(c.Commit) > 0:
+		return "commit"
+	default:
+		return c.Field
+	}
+}
+
+func (c *PolicyCondition) expected() string {
+	switch {
+	case c.regex != nil:
+		return fmt.Sprintf("matching [%s]", c.regex.String())
+	case len(c.In) > 0:
+		return fmt.Sprintf("one of [%s]", strings.Join(c.In, ", "))
+	default:
+		return "present"
+	}
+}
+
+// fieldValues the scalar values at the path, each item of a sequence along the path is followed
+func fieldValues(node interface{}, path []string) []string {
+	if len(path) == 0 {
+		switch value := node.(type) {
+		case nil:
+			return nil
+		case []interface{}:
+			out := make([]string, 0)
+			for _, item := range value {
+				out = append(out, fieldValues(item, path)...)
+			}
+			return out
+		case map[string]interface{}:
+			return nil
+		default:
+			return []string{fmt.Sprint(value)}
+		}
+	}
+
+	switch value := node.(type) {
+	case map[string]interface{}:
+		return fieldValues(value[path[0]], path[1:])
+	case []interface{}:
+		out := make([]string, 0)
+		for _, item := range value {
+			out = append(out, fieldValues(item, path)...)
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// listed true when the list is empty or holds the value, ignoring case
+func listed(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), strings.TrimSpace(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+func firstLine(s string) string {
+	return strings.TrimSpace(strings.SplitN(strings.TrimSpace(s), "\n", 2)[0])
+}
+
+// PolicyValidator evaluates the policy rules applying to each change, violations are reported at the severity of the
+// rule. Changes are rendered as they would be applied when a rule tests the commands, the validator is registered
+// after the exists validator so the scope applied is selected. Renderers cache the state of the target, the validator
+// is therefore not concurrent.
+type PolicyValidator struct {
+	policy      *Policy
+	renderer    Renderer
+	environment string
+}
+
+func NewPolicyValidator(policy *Policy, renderer Renderer, environment string) *PolicyValidator {
+	return &PolicyValidator{policy: policy, renderer: renderer, environment: environment}
+}
+
+func (pv *PolicyValidator) Init() error {
+	return nil
+}
+
+func (pv *PolicyValidator) Destroy() error {
+	return nil
+}
+
+func (pv *PolicyValidator) Designation() string {
+	return PolicyValidatorName
+}
+
+func (pv *PolicyValidator) Validate(change *objects.ChangeItem) error {
+	rules := make([]*PolicyRule, 0)
+	render := false
+	for _, rule := range pv.policy.Rules {
+		if rule.appliesTo(change.Item.Type, pv.environment) {
+			rules = append(rules, rule)
+			render = render || rule.usesCommands()
+		}
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	input := &policyInput{}
+	if change.Bundle != nil {
+		input.commit = change.Bundle.Ref.Message
+	}
+	if err := utility.UnmarshalYamlSubObject(change.Item, &input.spec); err != nil {
+		change.Validation.AddValidationStepInfo(objects.ValidationErrorCritical, false, errors.New("spec definition invalid,"+err.Error()), pv.Designation())
+		return nil //  validator did not fail but has completed its task
+	}
+	if render {
+		scopes, err := pv.renderer.Render(change)
+		if err != nil {
+			change.Validation.AddValidationStepInfo(objects.ValidationErrorCritical, false, utility.WrapError("rendering failed, policy not evaluated", err), pv.Designation())
+			return nil //  validator did not fail but has completed its task
+		}
+		for _, scope := range scopes {
+			input.commands = append(input.commands, scope.Commands...)
+		}
+	}
+
+	differences := make([]objects.ValidationDifference, 0)
+	for _, rule := range rules {
+		differences = append(differences, rule.evaluate(input)...)
+	}
+	if len(differences) == 0 {
+		change.Validation.AddValidationStepInfo(objects.ValidationErrorNone, true, nil, pv.Designation())
+		return nil
+	}
+
+	severity := objects.ValidationErrorNone
+	for _, difference := range differences {
+		if difference.Severity > severity {
+			severity = difference.Severity
+		}
+	}
+	change.Validation.AddValidationStepDifferences(severity,
+		false,
+		fmt.Errorf("policy violated, %d violation(s)", len(differences)),
+		pv.Designation(),
+		differences)
+	return nil
+}
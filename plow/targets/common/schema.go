@@ -0,0 +1,409 @@
+package common
+
+import (
+	"Plow/plow/objects"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"reflect"
+	"strings"
+)
+
+const JSONSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// SpecSchemaValidatorName designation of the validation step recording the conformance of a spec to the schema of
+// its target
+const SpecSchemaValidatorName = "SpecSchemaValidator"
+
+// keywords reported by schema violations
+const (
+	SchemaKeywordType                 = "type"
+	SchemaKeywordEnum                 = "enum"
+	SchemaKeywordRequired             = "required"
+	SchemaKeywordDependencies         = "dependencies"
+	SchemaKeywordAdditionalProperties = "additionalProperties"
+)
+
+// JSONSchema the subset of JSON Schema (draft-07) used to describe spec files. Schemas are generated from the spec
+// structs so the exported schema and the parser describe the same elements.
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	ID                   string                 `json:"$id,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Dependencies         map[string][]string    `json:"dependencies,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	AllOf                []*JSONSchema          `json:"allOf,omitempty"`
+	If                   *JSONSchema            `json:"if,omitempty"`
+	Then                 *JSONSchema            `json:"then,omitempty"`
+	// Closed elements not declared by properties are not permitted, exported as additionalProperties false
+	Closed bool `json:"-"`
+}
+
+// SpecSchemaType the specification of the spec element for the listed type names, styles restricts the
+// specification to the listed definition styles, all styles when empty
+type SpecSchemaType struct {
+	Names  []string
+	Styles []string
+	Spec   interface{}
+}
+
+// SchemaViolation an element of a yaml document which does not conform to the schema
+type SchemaViolation struct {
+	Line     int
+	Path     string
+	Keyword  string
+	Expected string
+	Actual   string
+	Severity objects.ValidationErrorSeverity
+}
+
+func (v SchemaViolation) String() string {
+	if v.Keyword == SchemaKeywordAdditionalProperties {
+		return fmt.Sprintf("[%s] unknown element is ignored", v.Path)
+	}
+	return fmt.Sprintf("[%s] %s expected [%s] actual [%s]", v.Path, v.Keyword, v.Expected, v.Actual)
+}
+
+// Difference the violation as a validation difference, the line is reported with the element
+func (v SchemaViolation) Difference() objects.ValidationDifference {
+	return objects.ValidationDifference{Subject: fmt.Sprintf("line %d [%s]", v.Line, v.Path), Attribute: v.Keyword,
+		Expected: v.Expected, Actual: v.Actual, Severity: v.Severity}
+}
+
+func (s *JSONSchema) MarshalJSON() ([]byte, error) {
+	type plain JSONSchema
+	if !s.Closed {
+		return json.Marshal((*plain)(s))
+	}
+	return json.Marshal(struct {
+		*plain
+		AdditionalProperties bool `json:"additionalProperties"`
+	}{(*plain)(s), false})
+}
+
+// NewJSONSchema generates the schema of the value's type from its yaml tags, structs are closed to undeclared elements
+func NewJSONSchema(v interface{}) *JSONSchema {
+	return schemaOf(reflect.TypeOf(v))
+}
+
+func schemaOf(t reflect.Type) *JSONSchema {
+	if t == nil {
+		return &JSONSchema{}
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &JSONSchema{Type: "array", Items: schemaOf(t.Elem())}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: schemaOf(t.Elem())}
+	case reflect.Struct:
+		schema := &JSONSchema{Type: "object", Properties: make(map[string]*JSONSchema), Closed: true}
+		for name, field := range yamlFields(t) {
+			schema.Properties[name] = schemaOf(field)
+		}
+		return schema
+	default:
+		//interface values accept any element
+		return &JSONSchema{}
+	}
+}
+
+// NewSpecSchema the schema of a spec file. The header is generated from CodeBlockSpec, the spec element is described
+// by the specification of the type named within the header.
+func NewSpecSchema(id string, title string, styles []string, types []SpecSchemaType) *JSONSchema {
+	schema := NewJSONSchema(objects.CodeBlockSpec{})
+	schema.Schema = JSONSchemaDraft
+	schema.ID = id
+	schema.Title = title
+	schema.Required = []string{"definitionStyle", "type", "object", "spec"}
+	schema.Properties["definitionStyle"].Enum = styles
+	schema.Properties["object"].Required = []string{"name"}
+	schema.Properties["object"].Dependencies = map[string][]string{"schema": {"database"}}
+
+	names := make([]string, 0)
+	listed := make(map[string]bool)
+	for _, specType := range types {
+		for _, name := range specType.Names {
+			if !listed[name] {
+				names = append(names, name)
+				listed[name] = true
+			}
+		}
+		condition := &JSONSchema{Properties: map[string]*JSONSchema{"type": {Enum: specType.Names}}, Required: []string{"type"}}
+		if len(specType.Styles) > 0 {
+			condition.Properties["definitionStyle"] = &JSONSchema{Enum: specType.Styles}
+			condition.Required = append(condition.Required, "definitionStyle")
+		}
+		schema.AllOf = append(schema.AllOf, &JSONSchema{If: condition,
+			Then: &JSONSchema{Properties: map[string]*JSONSchema{"spec": NewJSONSchema(specType.Spec)}}})
+	}
+	if len(names) > 0 {
+		schema.Properties["type"].Enum = names
+	}
+	return schema
+}
+
+// ValidateDocument parses the yaml document and validates it against the schema, a document which can not be parsed
+// is reported as a single violation
+func (s *JSONSchema) ValidateDocument(bytes []byte) []SchemaViolation {
+	var root yaml.Node
+	if err := yaml.Unmarshal(bytes, &root); err != nil {
+		return []SchemaViolation{{Line: 1, Path: "$", Keyword: "syntax", Expected: "yaml", Actual: err.Error(),
+			Severity: objects.ValidationErrorCritical}}
+	}
+	return s.Validate(&root)
+}
+
+// Validate the elements of the node which do not conform to the schema. Elements not declared by a closed schema are
+// ignored when parsed and are reported as warnings, other violations are critical. Enumerations are matched
+// case-insensitively as the parser does, null values are accepted for any element.
+func (s *JSONSchema) Validate(node *yaml.Node) []SchemaViolation {
+	violations := make([]SchemaViolation, 0)
+	if node != nil && node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return violations
+		}
+		node = node.Content[0]
+	}
+	s.validate(node, "", &violations)
+	return violations
+}
+
+func (s *JSONSchema) validate(node *yaml.Node, path string, violations *[]SchemaViolation) {
+	if node == nil {
+		return
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return
+	}
+	location := path
+	if len(location) == 0 {
+		location = "$"
+	}
+	add := func(line int, path string, keyword string, expected string, actual string, severity objects.ValidationErrorSeverity) {
+		*violations = append(*violations, SchemaViolation{Line: line, Path: path, Keyword: keyword,
+			Expected: expected, Actual: actual, Severity: severity})
+	}
+
+	for _, sub := range s.AllOf {
+		sub.validate(node, path, violations)
+	}
+	if s.If != nil && s.Then != nil {
+		matched := make([]SchemaViolation, 0)
+		s.If.validate(node, path, &matched)
+		if len(matched) == 0 {
+			s.Then.validate(node, path, violations)
+		}
+	}
+
+	if len(s.Type) > 0 && !conforms(s.Type, node) {
+		add(node.Line, location, SchemaKeywordType, s.Type, nodeTypeName(node), objects.ValidationErrorCritical)
+		return
+	}
+
+	if len(s.Enum) > 0 && node.Kind == yaml.ScalarNode {
+		found := false
+		for _, value := range s.Enum {
+			found = found || strings.EqualFold(strings.TrimSpace(node.Value), value)
+		}
+		if !found {
+			add(node.Line, location, SchemaKeywordEnum, strings.Join(s.Enum, ", "), node.Value, objects.ValidationErrorCritical)
+		}
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		present := make(map[string]bool)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			present[key.Value] = !(value.Kind == yaml.ScalarNode && (value.Tag == "!!null" || len(strings.TrimSpace(value.Value)) == 0))
+			child := key.Value
+			if len(path) > 0 {
+				child = path + "." + key.Value
+			}
+			if property, ok := s.Properties[key.Value]; ok {
+				property.validate(value, child, violations)
+			} else if s.AdditionalProperties != nil {
+				s.AdditionalProperties.validate(value, child, violations)
+			} else if s.Closed {
+				add(key.Line, child, SchemaKeywordAdditionalProperties, "declared element", "unknown element", objects.ValidationErrorWarn)
+			}
+		}
+		for _, required := range s.Required {
+			if !present[required] {
+				add(node.Line, joinSchemaPath(path, required), SchemaKeywordRequired, "present", "absent", objects.ValidationErrorCritical)
+			}
+		}
+		for element, dependencies := range s.Dependencies {
+			if !present[element] {
+				continue
+			}
+			for _, dependency := range dependencies {
+				if !present[dependency] {
+					add(node.Line, joinSchemaPath(path, dependency), SchemaKeywordDependencies,
+						fmt.Sprintf("present with %s", element), "absent", objects.ValidationErrorCritical)
+				}
+			}
+		}
+	case yaml.SequenceNode:
+		if s.Items != nil {
+			for i, item := range node.Content {
+				s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i), violations)
+			}
+		}
+	}
+}
+
+func joinSchemaPath(path string, element string) string {
+	if len(path) == 0 {
+		return element
+	}
+	return path + "." + element
+}
+
+// conforms the node is of the schema type, scalars of any kind are accepted as strings as the parser converts them
+func conforms(schemaType string, node *yaml.Node) bool {
+	switch schemaType {
+	case "object":
+		return node.Kind == yaml.MappingNode
+	case "array":
+		return node.Kind == yaml.SequenceNode
+	case "string":
+		return node.Kind == yaml.ScalarNode
+	case "integer":
+		return node.Kind == yaml.ScalarNode && node.Tag == "!!int"
+	case "number":
+		return node.Kind == yaml.ScalarNode && (node.Tag == "!!int" || node.Tag == "!!float")
+	case "boolean":
+		return node.Kind == yaml.ScalarNode && node.Tag == "!!bool"
+	}
+	return true
+}
+
+func nodeTypeName(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+	switch node.Tag {
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!bool":
+		return "boolean"
+	}
+	return "string"
+}
+
+// yamlFields the element names declared by the struct mapped to their type, inline structs are flattened
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("yaml"), ",")
+		if tag[0] == "-" {
+			continue
+		}
+		inline := false
+		for _, flag := range tag[1:] {
+			inline = inline || flag == "inline"
+		}
+		if inline && field.Type.Kind() == reflect.Struct {
+			for name, ft := range yamlFields(field.Type) {
+				fields[name] = ft
+			}
+			continue
+		}
+		if len(field.PkgPath) > 0 {
+			continue //unexported
+		}
+		name := tag[0]
+		if len(name) == 0 {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// NewSpecValidator validates spec files against the schema as they are loaded into a change log
+func NewSpecValidator(schema *JSONSchema) objects.SpecValidator {
+	return func(bytes []byte) []objects.ValidationDifference {
+		differences := make([]objects.ValidationDifference, 0)
+		for _, violation := range schema.ValidateDocument(bytes) {
+			differences = append(differences, violation.Difference())
+		}
+		return differences
+	}
+}
+
+// SpecSchemaValidator records the differences between a spec and the schema of its target found as the change log
+// was loaded, registered with the validation handler so it is configured alongside the other validators
+type SpecSchemaValidator struct{}
+
+func NewSpecSchemaValidator() *SpecSchemaValidator {
+	return &SpecSchemaValidator{}
+}
+
+func (ssv *SpecSchemaValidator) Init() error {
+	return nil
+}
+
+func (ssv *SpecSchemaValidator) Destroy() error {
+	return nil
+}
+
+func (ssv *SpecSchemaValidator) Designation() string {
+	return SpecSchemaValidatorName
+}
+
+// Concurrent the differences are held by the change
+func (ssv *SpecSchemaValidator) Concurrent() bool {
+	return true
+}
+
+// Validate records the differences of the change, the step severity is the most severe difference and critical
+// differences omit the change from apply
+func (ssv *SpecSchemaValidator) Validate(change *objects.ChangeItem) error {
+	if len(change.SpecDifferences) == 0 {
+		change.Validation.AddValidationStepInfo(objects.ValidationErrorNone, true, nil, ssv.Designation())
+		return nil
+	}
+
+	severity := objects.ValidationErrorNone
+	for _, difference := range change.SpecDifferences {
+		if difference.Severity > severity {
+			severity = difference.Severity
+		}
+	}
+	change.Validation.AddValidationStepDifferences(severity,
+		severity < objects.ValidationErrorCritical,
+		fmt.Errorf("spec does not conform to the target schema, %d difference(s)", len(change.SpecDifferences)),
+		ssv.Designation(),
+		append(make([]objects.ValidationDifference, 0), change.SpecDifferences...))
+	return nil
+}
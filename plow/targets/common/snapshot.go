@@ -0,0 +1,182 @@
+package common
+
+import (
+	"Plow/plow/objects"
+	"Plow/plow/utility"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultMetadataSnapshotTTL the age after which a snapshot is no longer used when no ttl is configured
+const DefaultMetadataSnapshotTTL = time.Hour
+
+// MetadataSnapshotConfiguration the location and time to live of the metadata snapshot within the target configuration
+type MetadataSnapshotConfiguration struct {
+	Path string `mapstructure:"path"`
+	TTL  string `mapstructure:"ttl"`
+}
+
+// MetadataSnapshot a local file holding the metadata loaded from the target, reused by later runs loading the same
+// scope while the tracking head of the target is unchanged and the snapshot has not expired. The scope identifies what
+// was loaded, e.g. the databases and object types referenced by the change log.
+type MetadataSnapshot struct {
+	path string
+	ttl  time.Duration
+}
+
+// metadataSnapshotFile the content of the snapshot file
+type metadataSnapshotFile struct {
+	Head     string    `json:"head"`
+	Scope    string    `json:"scope"`
+	Created  time.Time `json:"created"`
+	Metadata *Metadata `json:"metadata"`
+}
+
+// metadataObjectFile a metadata object within the snapshot file
+type metadataObjectFile struct {
+	ObjectType int64      `json:"type"`
+	Properties []Property `json:"properties"`
+}
+
+// NewMetadataSnapshot the snapshot at the configured path, nil when no path is configured
+func NewMetadataSnapshot(config MetadataSnapshotConfiguration) (*MetadataSnapshot, error) {
+	if utility.IsStringEmpty(&config.Path) {
+		return nil, nil
+	}
+
+	ttl := DefaultMetadataSnapshotTTL
+	if !utility.IsStringEmpty(&config.TTL) {
+		var err error
+		if ttl, err = time.ParseDuration(strings.TrimSpace(config.TTL)); err != nil || ttl <= 0 {
+			return nil, fmt.Errorf("metadata snapshot ttl [%s] is not a positive duration, e.g. 30m", config.TTL)
+		}
+	}
+	return &MetadataSnapshot{path: strings.TrimSpace(config.Path), ttl: ttl}, nil
+}
+
+// Load adds the objects of the snapshot to the metadata, returns false leaving the metadata unchanged when there is no
+// snapshot, it is unreadable, has expired or was taken at another tracking head or of another scope
+func (ms *MetadataSnapshot) Load(head string, scope string, meta *Metadata) bool {
+	content, err := os.ReadFile(ms.path)
+	if err != nil {
+		return false
+	}
+
+	snapshot := metadataSnapshotFile{Metadata: NewMetadata(meta.translator)}
+	if err := json.Unmarshal(content, &snapshot); err != nil {
+		return false
+	}
+	if snapshot.Head != head || snapshot.Scope != scope || time.Since(snapshot.Created) > ms.ttl {
+		return false
+	}
+
+	for _, obj := range snapshot.Metadata.Objects() {
+		meta.AddObject(obj)
+	}
+	return true
+}
+
+// Save replaces the snapshot with the metadata, the file is written alongside and renamed so a concurrent run never
+// reads a partial snapshot
+func (ms *MetadataSnapshot) Save(head string, scope string, meta *Metadata) error {
+	content, err := json.Marshal(metadataSnapshotFile{Head: head, Scope: scope, Created: time.Now().UTC(), Metadata: meta})
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(ms.path); len(dir) > 0 {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return utility.WrapError("unable to create metadata snapshot directory", err)
+		}
+	}
+	tmp := ms.path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o600); err != nil {
+		return utility.WrapError("unable to write metadata snapshot", err)
+	}
+	return os.Rename(tmp, ms.path)
+}
+
+// Invalidate removes the snapshot, the next run loads the metadata from the target
+func (ms *MetadataSnapshot) Invalidate() error {
+	if err := os.Remove(ms.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return utility.WrapError("unable to remove metadata snapshot", err)
+	}
+	return nil
+}
+
+// SnapshotScope identifies the scope of the metadata loaded from the elements of the scope, the order of the elements
+// is not significant
+func SnapshotScope(elements ...string) string {
+	sorted := append(make([]string, 0, len(elements)), elements...)
+	sort.Strings(sorted)
+	return utility.Sha256Hash(strings.Join(sorted, "\n"))
+}
+
+// SnapshotHead identifies the tracking head of the target, the most recent completed commit and when it completed.
+// Applying a commit, or applying the same commit again, changes the head.
+func SnapshotHead(history *objects.TrackingLog) string {
+	if history == nil || history.Empty {
+		return ""
+	}
+	entry := history.GetLastProcessed()
+	if entry == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s@%s", entry.TrackingId, entry.End.UTC().Format(time.RFC3339Nano))
+}
+
+// Objects every object of the metadata, ordered by type and key
+func (m *Metadata) Objects() []*MetadataObject {
+	types := make([]int64, 0, len(m.contents))
+	for objectType := range m.contents {
+		types = append(types, objectType)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+
+	out := make([]*MetadataObject, 0)
+	for _, objectType := range types {
+		keys := make([]string, 0, len(m.contents[objectType].objects))
+		for key := range m.contents[objectType].objects {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			out = append(out, m.contents[objectType].objects[key]...)
+		}
+	}
+	return out
+}
+
+// MarshalJSON serializes the objects of the metadata, the type translator is not serialized
+func (m *Metadata) MarshalJSON() ([]byte, error) {
+	out := make([]metadataObjectFile, 0)
+	for _, obj := range m.Objects() {
+		out = append(out, metadataObjectFile{ObjectType: obj.ObjectType, Properties: obj.properties})
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON adds the serialized objects to the metadata
+func (m *Metadata) UnmarshalJSON(data []byte) error {
+	var in []metadataObjectFile
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if m.contents == nil {
+		m.contents = make(map[int64]MetadataMap)
+	}
+	for _, item := range in {
+		obj, err := NewMetadataObject(item.ObjectType, item.Properties...)
+		if err != nil {
+			return err
+		}
+		m.AddObject(obj)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package common
+
+import (
+	"Plow/plow/objects"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/noirbizarre/gonja"
+	"strconv"
+	"time"
+)
+
+// Tracker persists the outcome of applying a change log within the tracking structures of a target
+type Tracker interface {
+	PersistTrackingLogDetail(detail *objects.LogItemEntry) error
+	PersistTrackingLogEntry(entry *objects.LogEntry) error
+}
+
+// Executor executes statements over a connection pool or a single session of the pool
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// ScanTrackingHistory reads the commits returned by the tracking history query, when depth is positive at most depth
+// commits are read
+func ScanTrackingHistory(rows *sql.Rows, depth int) (*objects.TrackingLog, error) {
+	rez := objects.NewTrackingLog()
+	count := 0
+
+	for rows.Next() && (depth <= 0 || count < depth) {
+		var entry objects.LogEntry
+		err := rows.Scan(&entry.TrackingId,
+			&entry.Message,
+			&entry.Start,
+			&entry.End,
+			&entry.AppliedBy,
+			&entry.TotalChanges,
+			&entry.SuccessfulChanges,
+			&entry.FailedChanges,
+			&entry.Completed,
+			&entry.FastForward)
+
+		if err != nil {
+			return nil, err
+		}
+		count += 1
+		rez.Add(entry)
+	}
+
+	if count == 0 {
+		rez.Empty = true
+	}
+
+	return rez, nil
+}
+
+// NewTrackingDetailContext the render context of the statement recording the outcome of an item, the location of the
+// tracking structures is added by the target
+func NewTrackingDetailContext(detail *objects.LogItemEntry) *gonja.Context {
+	return &gonja.Context{"COMMIT": detail.TrackingId,
+		"FILE":    EscapeStringLiteral(detail.FileName),
+		"REF":     detail.Reference,
+		"HASH":    detail.Hash,
+		"STATUS":  strconv.FormatBool(detail.Status),
+		"TIME":    detail.ApplyDate.UTC().Format("2006-01-02 15:04:05"),
+		"MSG":     MakeStringDatabaseSafe(detail.Message),
+		"PARTIAL": strconv.FormatBool(detail.Partial)}
+}
+
+// NewTrackingEntryContext the render context of the statement recording a commit, the location of the tracking
+// structures is added by the target
+func NewTrackingEntryContext(entry *objects.LogEntry) *gonja.Context {
+	return &gonja.Context{"COMMIT": entry.TrackingId,
+		"MSG":          MakeStringDatabaseSafe(entry.Message),
+		"START":        entry.Start.UTC().Format("2006-01-02 15:04:05"),
+		"END":          entry.End.UTC().Format("2006-01-02 15:04:05"),
+		"WHO":          entry.AppliedBy,
+		"TOTAL":        strconv.Itoa(entry.TotalChanges),
+		"SUCCESS":      strconv.Itoa(entry.SuccessfulChanges),
+		"FAIL":         strconv.Itoa(entry.FailedChanges),
+		"COMPLETED":    strconv.FormatBool(entry.Completed),
+		"FAST_FORWARD": strconv.FormatBool(entry.FastForward)}
+}
+
+// RenderChangeLogInOrder renders the items of each bundle in the processing order of their type. Items with validation
+// enabled which were not validated, and items which failed validation, are not rendered and record the reason within
+// their apply information.
+func RenderChangeLogInOrder(changes *objects.ChangeLog, order []int64, validationDisabled bool, render func(item *objects.ChangeItem) *RenderedChange) ([]*RenderedChange, error) {
+	if changes == nil {
+		return nil, ErrNoChangesProvided
+	}
+	renderedChanges := make([]*RenderedChange, 0)
+
+	//for each bundle in order
+	for _, bundle := range changes.Bundles {
+
+		for _, objType := range order {
+
+			items, err := bundle.GetChangesOfType(objType)
+			if err != nil {
+				return nil, err //can't get a list of objects in the correct order type, time to error and halt
+			}
+			for _, item := range items {
+				//check the bundle header see if validation was run,
+				//if any changes are configured for validation, we need to stop and not go on for this item
+				if (!bundle.Validated || validationDisabled) && item.Item.Options.Validate {
+					item.ApplyInformation.Executed = false
+					item.ApplyInformation.Completed = false
+					item.ApplyInformation.Error = errors.New("validation not performed and object has validation enabled")
+					continue
+				}
+				//also check if this item failed validation then should be skipped
+				if !validationDisabled && !item.Validation.PassedValidation() {
+					item.ApplyInformation.Executed = false
+					item.ApplyInformation.Completed = false
+					item.ApplyInformation.Error = errors.New("object failed validation")
+					continue
+				}
+
+				if rezult := render(item); rezult != nil {
+					renderedChanges = append(renderedChanges, rezult)
+				}
+			}
+		}
+	}
+
+	return renderedChanges, nil
+}
+
+// ApplyRenderedChange executes the commands of each scope of the change in order, halting at the first command which
+// fails. The effect of each scope is recorded within the apply information of the item.
+func ApplyRenderedChange(context context.Context, connection Executor, renderedChange *RenderedChange) error {
+	// apply scopes
+	appliedScopes := 0
+	item := renderedChange.Item()
+	item.ApplyInformation.Executed = true
+	renderedChange.TimeApplied = time.Now()
+
+	for _, scope := range item.ApplyInformation.GetScopes() {
+		appliedCmds := 0
+		for _, cmd := range scope.Commands {
+			_, err := connection.ExecContext(context, cmd)
+			if err != nil {
+				scope.SetEffectInfo(true, false, appliedCmds > 0 || appliedScopes > 0, err)
+				item.ApplyInformation.Error = err
+				return err
+			}
+			appliedCmds += 1
+		}
+		scope.SetEffectInfo(true, true, false, nil)
+		appliedScopes += 1
+	}
+
+	item.ApplyInformation.Completed = len(item.ApplyInformation.GetScopes()) == appliedScopes
+	return nil
+}
+
+// TrackChangeLog records the outcome of each item and the metrics of each bundle of the applied change log. Failing
+// to record an item does not halt tracking, failing to record a bundle does as the commit history depends on it.
+func TrackChangeLog(tracker Tracker, changes *objects.ChangeLog, timeStart time.Time, appliedBy string, fastForward bool) error {
+	//for each bundle gather statistics and log
+	for _, bundle := range changes.Bundles {
+		total := 0
+		success := 0
+		failed := 0
+
+		//collect and track item level apply metrics
+		for _, item := range bundle.Items {
+			total += 1
+			successful, partial, err := item.ApplyInformation.IsSuccess()
+
+			if successful {
+				success += 1
+			} else {
+				failed += 1
+			}
+
+			var msg string
+			if err != nil {
+				msg = err.Error()
+			}
+
+			logEntry := &objects.LogItemEntry{TrackingId: bundle.Ref.Hash,
+				FileName:  item.Metadata.Name,
+				Reference: item.Metadata.GitHash,
+				Hash:      item.Metadata.IdentifierHash,
+				Status:    successful,
+				ApplyDate: time.Now(),
+				Message:   msg,
+				Partial:   partial}
+			//dont error main process for logging issue of an individual item
+			_ = tracker.PersistTrackingLogDetail(logEntry)
+		}
+		timeEnd := time.Now()
+
+		//gather bundle metrics
+		log := &objects.LogEntry{TrackingId: bundle.Ref.Hash, Message: bundle.Ref.Message,
+			Start:             timeStart,
+			End:               timeEnd,
+			AppliedBy:         appliedBy,
+			TotalChanges:      total,
+			SuccessfulChanges: success,
+			FailedChanges:     failed,
+			Completed:         true,
+			FastForward:       fastForward,
+		}
+
+		// log bundle to tracking
+		err := tracker.PersistTrackingLogEntry(log)
+		if err != nil {
+			// something occurred saving log to DB, because this is critical to the logic of this solution that these entries exist
+			// error and halt
+			return errors.New(fmt.Sprintf("failed to save to comit log :%s", err.Error()))
+		}
+	}
+	return nil
+}
@@ -17,6 +17,12 @@ func MakeStringDatabaseSafe(input string) string {
 	return output
 }
 
+// EscapeStringLiteral escapes the value for embedding within a single quoted string literal, the value is otherwise
+// retained as is
+func EscapeStringLiteral(input string) string {
+	return strings.ReplaceAll(input, "'", "''")
+}
+
 func SegmentScopeCommands(blob string) []string {
 	return utility.Filter(utility.Map(strings.FieldsFunc(blob, func(c rune) bool {
 		return c == ';'
@@ -2,6 +2,10 @@ package common
 
 import (
 	"Plow/plow/objects"
+	"Plow/plow/utility"
+	"fmt"
+	"strings"
+	"sync"
 )
 
 type Validator interface {
@@ -11,53 +15,70 @@ type Validator interface {
 	Destroy() error
 }
 
+// ConcurrentValidator a validator safe to apply to several changes at once, holding no state shared between changes
+// and executing statements depending on session state over a dedicated connection. Other validators are applied to
+// one change at a time.
+type ConcurrentValidator interface {
+	Validator
+	Concurrent() bool
+}
+
+// AttributeValidator a validator comparing attributes of an object, the severity of a difference in each attribute is
+// configurable. Differences in attributes set to none are not reported.
+type AttributeValidator interface {
+	Validator
+	ConfigureAttributes(severities map[string]objects.ValidationErrorSeverity) error
+}
+
+// ValidatorConfiguration the configuration of a validator within the target configuration, keyed by the designation
+// of the validator. Validators are enabled unless configured otherwise, severity maps the severity reported by the
+// validator to the severity recorded, e.g. critical: warning. Attributes sets the severity reported for differences
+// in each attribute compared by an AttributeValidator, the severity mapping is applied to the severity reported.
+type ValidatorConfiguration struct {
+	Enabled    *bool             `mapstructure:"enabled"`
+	Severity   map[string]string `mapstructure:"severity"`
+	Attributes map[string]string `mapstructure:"attributes"`
+}
+
 type ValidationHandler struct {
 	typeValidators   map[int64][]Validator
 	globalValidators []Validator
 	typeMapper       func(string) int64
+	required         map[string]bool
+	disabled         map[string]bool
+	overrides        map[string]map[objects.ValidationErrorSeverity]objects.ValidationErrorSeverity
+	terminate        bool
+	workers          int
+	locks            map[string]*sync.Mutex
 }
 
 func (v *ValidationHandler) Initialize() error {
-	//global validators init
-	for _, validator := range v.globalValidators {
+	//validators registered for several types are initialized once
+	for _, validator := range v.uniqueValidators() {
+		if !v.enabled(validator) {
+			continue
+		}
 		err := validator.Init()
 		if err != nil {
 			return err
 		}
 	}
 
-	//global validators init
-	for _, validators := range v.typeValidators {
-		for _, validator := range validators {
-			err := validator.Init()
-			if err != nil {
-				return err
-			}
-		}
-	}
-
 	return nil
 }
 
 func (v *ValidationHandler) Close() error {
-	//global validators destroy
-	for _, validator := range v.globalValidators {
+	//validators registered for several types are destroyed once
+	for _, validator := range v.uniqueValidators() {
+		if !v.enabled(validator) {
+			continue
+		}
 		err := validator.Destroy()
 		if err != nil {
 			return err
 		}
 	}
 
-	//global validators destroy
-	for _, validators := range v.typeValidators {
-		for _, validator := range validators {
-			err := validator.Destroy()
-			if err != nil {
-				return err
-			}
-		}
-	}
-
 	return nil
 }
 
@@ -65,6 +86,13 @@ func (v *ValidationHandler) RegisterGlobalValidator(validator Validator) {
 	v.globalValidators = append(v.globalValidators, validator)
 }
 
+// RegisterRequiredValidator registers a global validator which can not be disabled, e.g. the exists validator the
+// selection of the scope applied depends on
+func (v *ValidationHandler) RegisterRequiredValidator(validator Validator) {
+	v.required[designationKey(validator.Designation())] = true
+	v.RegisterGlobalValidator(validator)
+}
+
 func (v *ValidationHandler) RegisterTypeValidator(t int64, validator Validator) {
 	if _, ok := v.typeValidators[t]; !ok {
 		v.typeValidators[t] = make([]Validator, 0)
@@ -72,31 +100,229 @@ func (v *ValidationHandler) RegisterTypeValidator(t int64, validator Validator)
 	v.typeValidators[t] = append(v.typeValidators[t], validator)
 }
 
-func (v *ValidationHandler) Validate(change *objects.ChangeItem) error {
+// Configure applies the configuration of the registered validators, and when TerminateOnValidationFailureSetting is
+// set validation stops at the first item with a critical failure. Configurations of validators not registered,
+// disabling a required validator and unknown severities are rejected.
+func (v *ValidationHandler) Configure(configs map[string]ValidatorConfiguration, options *objects.Options) error {
+	registered := make(map[string][]Validator)
+	for _, validator := range v.validators() {
+		key := designationKey(validator.Designation())
+		registered[key] = append(registered[key], validator)
+	}
+
+	for designation, config := range configs {
+		key := designationKey(designation)
+		if _, ok := registered[key]; !ok {
+			return utility.WrapError(fmt.Sprintf("unknown validator [%s]", designation), ErrInvalidValidatorConfig)
+		}
+		if config.Enabled != nil && !*config.Enabled {
+			if v.required[key] {
+				return utility.WrapError(fmt.Sprintf("validator [%s] is required and can not be disabled", designation), ErrInvalidValidatorConfig)
+			}
+			v.disabled[key] = true
+		}
+
+		if len(config.Attributes) > 0 {
+			if err := configureAttributes(designation, registered[key], config.Attributes); err != nil {
+				return err
+			}
+		}
+
+		if len(config.Severity) == 0 {
+			continue
+		}
+		overrides := make(map[objects.ValidationErrorSeverity]objects.ValidationErrorSeverity)
+		for from, to := range config.Severity {
+			reported, ok := parseSeverity(from)
+			if !ok {
+				return utility.WrapError(fmt.Sprintf("validator [%s] severity [%s], expected one of [critical, warning, info, none]", designation, from), ErrInvalidValidatorConfig)
+			}
+			recorded, ok := parseSeverity(to)
+			if !ok {
+				return utility.WrapError(fmt.Sprintf("validator [%s] severity [%s], expected one of [critical, warning, info, none]", designation, to), ErrInvalidValidatorConfig)
+			}
+			overrides[reported] = recorded
+		}
+		v.overrides[key] = overrides
+	}
+
+	v.terminate = options != nil && options.OptionFlags.Has(objects.TerminateOnValidationFailureSetting)
+	if options != nil && options.ValidationWorkers > 1 {
+		v.workers = options.ValidationWorkers
+	}
+	return nil
+}
+
+// configureAttributes sets the severity of the attributes compared by the validator, validators which do not compare
+// attributes are rejected
+func configureAttributes(designation string, validators []Validator, attributes map[string]string) error {
+	severities := make(map[string]objects.ValidationErrorSeverity)
+	for attribute, value := range attributes {
+		severity, ok := parseSeverity(value)
+		if !ok {
+			return utility.WrapError(fmt.Sprintf("validator [%s] attribute [%s] severity [%s], expected one of [critical, warning, info, none]", designation, attribute, value), ErrInvalidValidatorConfig)
+		}
+		severities[strings.ToLower(strings.TrimSpace(attribute))] = severity
+	}
 
-	//apply global validators in order
-	for _, validator := range v.globalValidators {
-		if err := validator.Validate(change); err != nil {
+	for _, validator := range validators {
+		attributeValidator, ok := validator.(AttributeValidator)
+		if !ok {
+			return utility.WrapError(fmt.Sprintf("validator [%s] does not compare attributes", designation), ErrInvalidValidatorConfig)
+		}
+		if err := attributeValidator.ConfigureAttributes(severities); err != nil {
+			return utility.WrapError(fmt.Sprintf("validator [%s]", designation), err)
+		}
+	}
+	return nil
+}
+
+// ValidateItems validates the changes using the configured number of workers, validators which are not concurrent are
+// applied to one change at a time. Changes are dispatched in order and the outcome is recorded on each change, so the
+// results do not depend on the number of workers. When terminating on validation failure no change following the
+// first failing change is dispatched and the error of the first failing change is returned.
+func (v *ValidationHandler) ValidateItems(changes []*objects.ChangeItem) error {
+	v.locks = make(map[string]*sync.Mutex)
+	for _, validator := range v.validators() {
+		if concurrent, ok := validator.(ConcurrentValidator); !ok || !concurrent.Concurrent() {
+			v.locks[designationKey(validator.Designation())] = &sync.Mutex{}
+		}
+	}
+
+	errs := make([]error, len(changes))
+	failed := len(changes)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	indexes := make(chan int)
+	for w := 0; w < v.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				mu.Lock()
+				skip := i > failed
+				mu.Unlock()
+				if skip {
+					continue
+				}
+
+				if errs[i] = v.Validate(changes[i]); errs[i] != nil {
+					mu.Lock()
+					if i < failed {
+						failed = i
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range changes {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
 			return err
 		}
 	}
+	return nil
+}
 
-	//apply type validators in order
+// Validate applies the enabled global validators, then the type validators, to the change. A validator returning an
+// error is recorded as a critical step and the remaining validators are still applied. An error is only returned
+// when terminating on validation failure and the change has a critical failure.
+func (v *ValidationHandler) Validate(change *objects.ChangeItem) error {
+	validators := append(make([]Validator, 0), v.globalValidators...)
 	if typeValidators, ok := v.typeValidators[v.typeMapper(change.ObjectType)]; ok {
-		for _, validator := range typeValidators {
-			if err := validator.Validate(change); err != nil {
-				return err
-			}
+		validators = append(validators, typeValidators...)
+	}
+
+	//apply validators in order
+	for _, validator := range validators {
+		if !v.enabled(validator) {
+			continue
+		}
+		if err := v.apply(validator, change); err != nil {
+			change.Validation.AddValidationStepInfo(objects.ValidationErrorCritical, false, utility.WrapError("validator failed,", err), validator.Designation())
+			continue
+		}
+		if overrides, ok := v.overrides[designationKey(validator.Designation())]; ok {
+			change.Validation.OverrideStepSeverity(validator.Designation(), overrides)
 		}
 	}
 
+	if v.terminate && change.Validation.Critical > 0 {
+		return utility.WrapError(fmt.Sprintf("[%s]", change.Metadata.Name), ErrValidationTerminated)
+	}
 	return nil
 }
 
+// apply the validator to the change, holding the lock of the validator when it is not concurrent
+func (v *ValidationHandler) apply(validator Validator, change *objects.ChangeItem) error {
+	if lock, ok := v.locks[designationKey(validator.Designation())]; ok {
+		lock.Lock()
+		defer lock.Unlock()
+	}
+	return validator.Validate(change)
+}
+
+// validators the global and type validators registered
+func (v *ValidationHandler) validators() []Validator {
+	validators := append(make([]Validator, 0), v.globalValidators...)
+	for _, typeValidators := range v.typeValidators {
+		validators = append(validators, typeValidators...)
+	}
+	return validators
+}
+
+// uniqueValidators the registered validators, a validator registered for several types is listed once
+func (v *ValidationHandler) uniqueValidators() []Validator {
+	seen := make(map[Validator]bool)
+	unique := make([]Validator, 0)
+	for _, validator := range v.validators() {
+		if seen[validator] {
+			continue
+		}
+		seen[validator] = true
+		unique = append(unique, validator)
+	}
+	return unique
+}
+
+func (v *ValidationHandler) enabled(validator Validator) bool {
+	return !v.disabled[designationKey(validator.Designation())]
+}
+
+func designationKey(designation string) string {
+	return strings.ToLower(strings.TrimSpace(designation))
+}
+
+func parseSeverity(value string) (objects.ValidationErrorSeverity, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "critical":
+		return objects.ValidationErrorCritical, true
+	case "warning", "warn":
+		return objects.ValidationErrorWarn, true
+	case "info":
+		return objects.ValidationErrorInfo, true
+	case "none", "ignore":
+		return objects.ValidationErrorNone, true
+	default:
+		return objects.ValidationErrorNone, false
+	}
+}
+
 func NewValidationHandler(mapper objects.ObjectTypeTranslator) *ValidationHandler {
 	return &ValidationHandler{
 		globalValidators: make([]Validator, 0),
 		typeValidators:   make(map[int64][]Validator),
 		typeMapper:       mapper,
+		required:         make(map[string]bool),
+		disabled:         make(map[string]bool),
+		overrides:        make(map[string]map[objects.ValidationErrorSeverity]objects.ValidationErrorSeverity),
+		workers:          1,
+		locks:            make(map[string]*sync.Mutex),
 	}
 }
@@ -0,0 +1,13 @@
+package generic
+
+import "Plow/plow/targets/common"
+
+type GenericConfiguration struct {
+	Driver     string                                   `mapstructure:"driver"`
+	DSNSecret  string                                   `mapstructure:"dsnSecret"`
+	Dialect    string                                   `mapstructure:"dialect"`
+	UserId     string                                   `mapstructure:"userId"`
+	Tracking   string                                   `mapstructure:"tracking"`
+	Policy     string                                   `mapstructure:"policy"`
+	Validators map[string]common.ValidatorConfiguration `mapstructure:"validators"`
+}
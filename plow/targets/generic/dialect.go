@@ -0,0 +1,257 @@
+package generic
+
+import (
+	"Plow/plow/objects"
+	"Plow/plow/utility"
+	"gopkg.in/yaml.v3"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// regexDollarQuoteTag the opening tag of a dollar quoted block, e.g. $$ or $body$, positional parameters such as $1
+// are not tags
+var regexDollarQuoteTag = regexp.MustCompile(`^\$(?:[A-Za-z_][A-Za-z0-9_]*)?\$`)
+
+const (
+	UnknownType      int64 = 0
+	defaultSeparator       = ";"
+)
+
+// DialectTracking statements used to read and write the tracking tables, rendered with the same placeholders as
+// the snowflake target tracking statements plus {{TRACKING}} set from the target configuration
+type DialectTracking struct {
+	DDL          []string `yaml:"ddl"`
+	History      string   `yaml:"history"`
+	InsertCommit string   `yaml:"insertCommit"`
+	InsertChange string   `yaml:"insertChange"`
+}
+
+// DialectObjectType an object type supported by the dialect, exists is a catalog query rendered with the object
+// header placeholders which returns at least one row when the object is present
+type DialectObjectType struct {
+	Name    string   `yaml:"name"`
+	Aliases []string `yaml:"aliases"`
+	Exists  string   `yaml:"exists"`
+}
+
+// Dialect describes the behaviour of a database engine to the generic target. Object types are processed in the
+// order they are listed.
+type Dialect struct {
+	Name               string              `yaml:"name"`
+	IdentifierQuote    string              `yaml:"identifierQuote"`
+	StatementSeparator string              `yaml:"statementSeparator"`
+	SeparatorOnOwnLine bool                `yaml:"separatorOnOwnLine"`
+	DollarQuoting      bool                `yaml:"dollarQuoting"`
+	Tracking           DialectTracking     `yaml:"tracking"`
+	ObjectTypes        []DialectObjectType `yaml:"objectTypes"`
+	typeIndex          map[string]int64
+}
+
+func LoadDialect(path string) (*Dialect, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	dialect := &Dialect{}
+	if err = yaml.Unmarshal(bytes, dialect); err != nil {
+		return nil, utility.WrapError("unable to parse dialect descriptor:", err)
+	}
+
+	if err = dialect.initialize(); err != nil {
+		return nil, err
+	}
+	return dialect, nil
+}
+
+func (d *Dialect) initialize() error {
+	if len(d.ObjectTypes) == 0 {
+		return ErrInvalidDialect
+	}
+
+	if utility.IsStringEmpty(&d.Tracking.History) ||
+		utility.IsStringEmpty(&d.Tracking.InsertCommit) ||
+		utility.IsStringEmpty(&d.Tracking.InsertChange) {
+		return ErrMissingTrackingStatements
+	}
+
+	if utility.IsStringEmpty(&d.StatementSeparator) {
+		d.StatementSeparator = defaultSeparator
+	}
+	d.StatementSeparator = strings.TrimSpace(d.StatementSeparator)
+
+	//type designators start at 1, 0 is reserved for unknown types
+	d.typeIndex = make(map[string]int64)
+	for i, objType := range d.ObjectTypes {
+		if utility.IsStringEmpty(&objType.Name) {
+			return ErrInvalidDialect
+		}
+		for _, name := range append([]string{objType.Name}, objType.Aliases...) {
+			key := strings.TrimSpace(strings.ToLower(name))
+			if _, ok := d.typeIndex[key]; ok {
+				return ErrDuplicateObjectType
+			}
+			d.typeIndex[key] = int64(i + 1)
+		}
+	}
+	return nil
+}
+
+func (d *Dialect) TypeOf(s string) int64 {
+	if t, ok := d.typeIndex[strings.TrimSpace(strings.ToLower(s))]; ok {
+		return t
+	}
+	return UnknownType
+}
+
+func (d *Dialect) ObjectType(t int64) *DialectObjectType {
+	if t > 0 && int(t) <= len(d.ObjectTypes) {
+		return &d.ObjectTypes[t-1]
+	}
+	return nil
+}
+
+func (d *Dialect) ProcessingOrder() []int64 {
+	rv := make([]int64, len(d.ObjectTypes))
+	for i := range d.ObjectTypes {
+		rv[i] = int64(i + 1)
+	}
+	return rv
+}
+
+// QuoteIdentifier wraps an identifier in the dialect quote characters, a two character quote designates an opening
+// and closing pair, e.g. "[]"
+func (d *Dialect) QuoteIdentifier(identifier string) string {
+	openQuote, closeQuote := d.IdentifierQuote, d.IdentifierQuote
+	if len(d.IdentifierQuote) == 2 {
+		openQuote, closeQuote = d.IdentifierQuote[:1], d.IdentifierQuote[1:]
+	}
+	if len(openQuote) == 0 {
+		return identifier
+	}
+	return openQuote + strings.ReplaceAll(identifier, closeQuote, closeQuote+closeQuote) + closeQuote
+}
+
+// QualifyIdentifier joins the non-empty, quoted, parts of an object name
+func (d *Dialect) QualifyIdentifier(parts ...string) string {
+	quoted := make([]string, 0)
+	for _, part := range parts {
+		if !utility.IsStringEmpty(&part) {
+			quoted = append(quoted, d.QuoteIdentifier(strings.TrimSpace(part)))
+		}
+	}
+	return strings.Join(quoted, ".")
+}
+
+// SegmentCommands splits a scope blob into individual commands using the dialect statement separator. When the
+// separator must be on its own line (e.g. GO, /) only lines consisting of the separator terminate a command. A separator
+// within a quoted string or identifier, a comment, or when the dialect enables dollar quoting a $$ or $tag$ block, does
+// not terminate a command.
+func (d *Dialect) SegmentCommands(blob string) ([]string, error) {
+	segments := make([]string, 0)
+	var current strings.Builder
+	var quote string //closing sequence of the quoted string, identifier, comment or dollar quoted block being read
+	depth := 0       //nesting of block comments
+
+	for _, line := range strings.Split(blob, "\n") {
+		if d.SeparatorOnOwnLine && len(quote) == 0 && strings.EqualFold(strings.TrimSpace(line), d.StatementSeparator) {
+			segments = append(segments, current.String())
+			current.Reset()
+			continue
+		}
+
+		for i := 0; i < len(line); {
+			rest := line[i:]
+			switch {
+			case quote == "*/":
+				if strings.HasPrefix(rest, "/*") {
+					depth++
+					current.WriteString("/*")
+					i += 2
+					continue
+				}
+				if strings.HasPrefix(rest, "*/") {
+					if depth--; depth == 0 {
+						quote = ""
+					}
+					current.WriteString("*/")
+					i += 2
+					continue
+				}
+			case len(quote) > 0:
+				//quotes within strings and identifiers are escaped by doubling them
+				if strings.HasPrefix(rest, quote) && len(quote) == 1 && strings.HasPrefix(rest[1:], quote) {
+					current.WriteString(quote + quote)
+					i += 2
+					continue
+				}
+				if strings.HasPrefix(rest, quote) {
+					current.WriteString(quote)
+					i += len(quote)
+					quote = ""
+					continue
+				}
+			case strings.HasPrefix(rest, "--"):
+				//remainder of the line is a comment, retain it with the command it belongs to
+				current.WriteString(rest)
+				i = len(line)
+				continue
+			case strings.HasPrefix(rest, "/*"):
+				quote, depth = "*/", 1
+				current.WriteString("/*")
+				i += 2
+				continue
+			case rest[0] == '\'' || rest[0] == '"':
+				quote = rest[:1]
+			case d.DollarQuoting && rest[0] == '$':
+				if tag := regexDollarQuoteTag.FindString(rest); len(tag) > 0 {
+					quote = tag
+					current.WriteString(tag)
+					i += len(tag)
+					continue
+				}
+			case !d.SeparatorOnOwnLine && strings.HasPrefix(rest, d.StatementSeparator):
+				segments = append(segments, current.String())
+				current.Reset()
+				i += len(d.StatementSeparator)
+				continue
+			}
+			current.WriteByte(line[i])
+			i++
+		}
+		current.WriteString("\n")
+	}
+
+	if len(quote) > 0 {
+		return nil, ErrUnterminatedStatement
+	}
+	segments = append(segments, current.String())
+
+	return utility.Filter(utility.Map(segments, strings.TrimSpace), func(input string) bool {
+		return len(input) > 0
+	}), nil
+}
+
+// NewRenderContext placeholders available to scope statements
+func (d *Dialect) NewRenderContext(obj objects.ObjectSpec) *map[string]interface{} {
+	name, database, schema := strings.TrimSpace(obj.Name), strings.TrimSpace(obj.Database), strings.TrimSpace(obj.Schema)
+	return &map[string]interface{}{"NAME": name,
+		"DATABASE":         database,
+		"SCHEMA":           schema,
+		"QUOTED_NAME":      d.QualifyIdentifier(name),
+		"QUOTED_DATABASE":  d.QualifyIdentifier(database),
+		"QUOTED_SCHEMA":    d.QualifyIdentifier(schema),
+		"QUALIFIED_NAME":   d.QualifyIdentifier(database, schema, name),
+		"QUALIFIED_SCHEMA": d.QualifyIdentifier(database, schema)}
+}
+
+// newLiteralRenderContext placeholders for catalog queries where values are embedded within string literals
+func newLiteralRenderContext(obj objects.ObjectSpec) *map[string]interface{} {
+	escape := func(input string) string {
+		return strings.ReplaceAll(strings.TrimSpace(input), "'", "''")
+	}
+	return &map[string]interface{}{"NAME": escape(obj.Name),
+		"DATABASE": escape(obj.Database),
+		"SCHEMA":   escape(obj.Schema)}
+}
@@ -0,0 +1,7 @@
+package generic
+
+// drivers registered with database/sql available to the generic target in addition to those registered by the
+// dedicated targets (snowflake, mysql)
+import (
+	_ "github.com/lib/pq"
+)
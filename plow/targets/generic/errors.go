@@ -0,0 +1,12 @@
+package generic
+
+import "errors"
+
+var (
+	ErrInvalidConfiguration      = errors.New("invalid or incomplete generic target configuration")
+	ErrInvalidDialect            = errors.New("invalid or incomplete dialect descriptor")
+	ErrDuplicateObjectType       = errors.New("object type name or alias defined more than once within dialect")
+	ErrUnregisteredDriver        = errors.New("database driver not registered")
+	ErrMissingTrackingStatements = errors.New("dialect tracking statements [history, insertCommit, insertChange] not defined")
+	ErrUnterminatedStatement     = errors.New("scope ends within a quoted string, identifier, comment or dollar quoted block")
+)
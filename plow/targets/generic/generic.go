@@ -0,0 +1,233 @@
+package generic
+
+import (
+	"Plow/plow/objects"
+	"Plow/plow/secrets"
+	"Plow/plow/targets/common"
+	"Plow/plow/utility"
+	"context"
+	"database/sql"
+	"github.com/noirbizarre/gonja"
+	"time"
+)
+
+// GenericTarget a target for engines which only require the default pre/init/change/post specification behaviour,
+// engine specifics are provided by a dialect descriptor and connectivity by any registered database/sql driver
+type GenericTarget struct {
+	connection  *sql.DB
+	config      GenericConfiguration
+	dialect     *Dialect
+	secretStore secrets.SecretStore
+	validation  *common.ValidationHandler
+	options     *objects.Options
+	renderer    *GenericRenderer
+	policy      *common.Policy
+}
+
+func (g *GenericTarget) Open(config GenericConfiguration, options *objects.Options, secretStore secrets.SecretStore) error {
+	if utility.IsStringEmpty(&config.Driver) || utility.IsStringEmpty(&config.Dialect) || utility.IsStringEmpty(&config.DSNSecret) {
+		return ErrInvalidConfiguration
+	}
+
+	if !utility.Include(sql.Drivers(), config.Driver) {
+		return utility.WrapError(config.Driver, ErrUnregisteredDriver)
+	}
+
+	dialect, err := LoadDialect(config.Dialect)
+	if err != nil {
+		return err
+	}
+
+	g.config = config
+	g.dialect = dialect
+	g.renderer = newGenericRenderer(dialect)
+	g.options = options
+	g.secretStore = secretStore
+
+	if !utility.IsStringEmpty(&config.Policy) {
+		if g.policy, err = common.LoadPolicy(config.Policy); err != nil {
+			return err
+		}
+	}
+
+	dsn, err := secretStore.GetSecret(config.DSNSecret)
+	if err != nil {
+		return err
+	}
+
+	db, err := sql.Open(config.Driver, dsn)
+	if err != nil {
+		return err
+	}
+	g.connection = db
+	return nil
+}
+
+// ensureTrackingStructures executes the tracking ddl of the dialect, the ddl is expected to be idempotent e.g.
+// CREATE TABLE IF NOT EXISTS. Tracking structures are established when changes are applied, commands which only read
+// the target do not alter it.
+func (g *GenericTarget) ensureTrackingStructures() error {
+	for _, ddl := range g.dialect.Tracking.DDL {
+		stmt, err := common.RenderStatement(ddl, &gonja.Context{"TRACKING": g.config.Tracking})
+		if err != nil {
+			return err
+		}
+		if _, err = g.connection.Exec(stmt); err != nil {
+			return utility.WrapError("unable to establish tracking structures:", err)
+		}
+	}
+	return nil
+}
+
+func (g *GenericTarget) GetTrackingHistory(depth int) (*objects.TrackingLog, error) {
+	stmt, err := common.RenderStatement(g.dialect.Tracking.History, &gonja.Context{"TRACKING": g.config.Tracking})
+	if err != nil {
+		return nil, err
+	}
+	rows, err := g.connection.Query(stmt)
+	if err != nil {
+		//the tracking structures of a new target are established by the first apply, until then it has no history
+		if len(g.dialect.Tracking.DDL) > 0 && g.connection.Ping() == nil {
+			history := objects.NewTrackingLog()
+			history.Empty = true
+			return history, nil
+		}
+		return nil, err
+	}
+	defer rows.Close()
+
+	//row limiting syntax is not portable, depth is enforced while reading
+	return common.ScanTrackingHistory(rows, depth)
+}
+
+func (g *GenericTarget) GetTrackingLogDetail(entry objects.LogEntry) ([]objects.LogItemEntry, error) {
+	return nil, common.ErrNotImplemented
+}
+
+func (g *GenericTarget) RenderChangeLog(changes *objects.ChangeLog) ([]*common.RenderedChange, error) {
+	return common.RenderChangeLogInOrder(changes, g.dialect.ProcessingOrder(), g.options.OptionFlags.Has(objects.SkipValidationSetting), g.renderChange)
+}
+
+func (g *GenericTarget) renderChange(item *objects.ChangeItem) *common.RenderedChange {
+	//render scopes for the item, if error: add error info to item and return
+	scopes, err := g.renderer.Render(item)
+	if err != nil {
+		item.ApplyInformation.Executed = false
+		item.ApplyInformation.Completed = false
+		item.ApplyInformation.Error = err
+		return nil
+	}
+
+	return common.NewRenderedChange(item, scopes)
+}
+
+// RehearseChangeLog rehearsal against cloned databases is not supported by this target
+func (g *GenericTarget) RehearseChangeLog(context context.Context, changes *objects.ChangeLog) error {
+	return common.ErrNotImplemented
+}
+
+// RollbackCommit restore points are not captured by this target
+func (g *GenericTarget) RollbackCommit(context context.Context, commit string) ([]objects.RollbackItemEntry, error) {
+	return nil, common.ErrNotImplemented
+}
+
+// DetectDrift comparing specs with the live target is not supported by this target
+func (g *GenericTarget) DetectDrift(changes *objects.ChangeLog) ([]objects.DriftEntry, error) {
+	return nil, common.ErrNotImplemented
+}
+
+func (g *GenericTarget) ApplyChangeLog(context context.Context, changes *objects.ChangeLog) error {
+	if changes == nil {
+		return common.ErrNoChangesProvided
+	}
+
+	rendered, err := g.RenderChangeLog(changes)
+	if err != nil {
+		return err
+	}
+
+	if err := g.ensureTrackingStructures(); err != nil {
+		return err
+	}
+
+	//apply rendered changes in order, if error occurs in application halt
+	timeStart := time.Now()
+	for _, renderedChg := range rendered {
+		if err := common.ApplyRenderedChange(context, g.connection, renderedChg); err != nil {
+			return err
+		}
+	}
+
+	return common.TrackChangeLog(g, changes, timeStart, g.config.UserId, g.options.OptionFlags.Has(objects.FastForwardSetting))
+}
+
+func (g *GenericTarget) ValidateChangeLog(changes *objects.ChangeLog) error {
+	if changes != nil {
+		//initialize the validation handler
+		g.validation = common.NewValidationHandler(g.dialect.TypeOf)
+		g.validation.RegisterRequiredValidator(newGenericObjectExistsValidator(g))
+		g.validation.RegisterGlobalValidator(common.NewSpecSchemaValidator())
+		if g.policy != nil {
+			g.validation.RegisterGlobalValidator(common.NewPolicyValidator(g.policy, g.renderer, g.options.Environment))
+		}
+		if err := g.validation.Configure(g.config.Validators, g.options); err != nil {
+			return err
+		}
+		if err := g.validation.Initialize(); err != nil {
+			return err
+		}
+		defer g.validation.Close()
+
+		if err := g.validation.ValidateItems(changes.Items()); err != nil {
+			return err
+		}
+		for _, bundle := range changes.Bundles {
+			bundle.Validated = true
+		}
+	}
+
+	return nil
+}
+
+func (g *GenericTarget) Close() error {
+	return g.connection.Close()
+}
+
+func (g *GenericTarget) PersistTrackingLogDetail(detail *objects.LogItemEntry) error {
+	gc := common.NewTrackingDetailContext(detail)
+	(*gc)["TRACKING"] = g.config.Tracking
+
+	stmt, err := common.RenderStatement(g.dialect.Tracking.InsertChange, gc)
+	if err != nil {
+		return err
+	}
+
+	_, err = g.connection.Exec(stmt)
+	return err
+}
+
+func (g *GenericTarget) PersistTrackingLogEntry(entry *objects.LogEntry) error {
+	gc := common.NewTrackingEntryContext(entry)
+	(*gc)["TRACKING"] = g.config.Tracking
+
+	stmt, err := common.RenderStatement(g.dialect.Tracking.InsertCommit, gc)
+	if err != nil {
+		return err
+	}
+
+	_, err = g.connection.Exec(stmt)
+	return err
+}
+
+func (g *GenericTarget) GetObjectTypeTranslator() objects.ObjectTypeTranslator {
+	return g.dialect.TypeOf
+}
+
+func (g *GenericTarget) GetObjectTypeExecutionOrder() []int64 {
+	return g.dialect.ProcessingOrder()
+}
+
+// GetSpecSchema the schema spec files applied to the target are validated against when loaded
+func (g *GenericTarget) GetSpecSchema() *common.JSONSchema {
+	return SpecSchema(g.dialect)
+}
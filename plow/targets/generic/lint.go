@@ -0,0 +1,25 @@
+package generic
+
+import (
+	"Plow/plow/objects"
+	"Plow/plow/targets/common"
+	"sort"
+)
+
+// Lint checks the spec without a connection to the target, in addition to the conformance check against the spec
+// schema. Change scopes without an init scope and placeholders which are not provided when rendered are reported.
+func Lint(doc *common.LintDocument) {
+	placeholders := make([]string, 0)
+	for placeholder := range *(&Dialect{}).NewRenderContext(objects.ObjectSpec{}) {
+		placeholders = append(placeholders, placeholder)
+	}
+	sort.Strings(placeholders)
+
+	var spec genericDefaultSpecification
+	if doc.CheckSchema(&spec, "spec") {
+		doc.CheckInitPresent(spec.Init, spec.Change, "spec")
+		for _, scope := range []string{"pre", "init", "change", "post"} {
+			doc.CheckTemplate(placeholders, "spec", scope)
+		}
+	}
+}
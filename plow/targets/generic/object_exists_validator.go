@@ -0,0 +1,73 @@
+package generic
+
+import (
+	"Plow/plow/objects"
+	"Plow/plow/targets/common"
+	"Plow/plow/utility"
+	"database/sql"
+	"github.com/noirbizarre/gonja"
+)
+
+// GenericObjectExistsValidator executes the dialect catalog query for each change item, object types without a
+// catalog query are always considered to not exist
+type GenericObjectExistsValidator struct {
+	db      *sql.DB
+	dialect *Dialect
+}
+
+func newGenericObjectExistsValidator(target *GenericTarget) *GenericObjectExistsValidator {
+	return &GenericObjectExistsValidator{db: target.connection, dialect: target.dialect}
+}
+
+func (gev *GenericObjectExistsValidator) Init() error {
+	return nil
+}
+
+func (gev *GenericObjectExistsValidator) Destroy() error {
+	return nil
+}
+
+// Concurrent each change is checked by a single query over the connection pool
+func (gev *GenericObjectExistsValidator) Concurrent() bool {
+	return true
+}
+
+func (gev *GenericObjectExistsValidator) Designation() string {
+	return "ObjectExistsValidator"
+}
+
+func (gev *GenericObjectExistsValidator) Validate(change *objects.ChangeItem) error {
+	objType := gev.dialect.ObjectType(gev.dialect.TypeOf(change.Item.Type))
+	if objType == nil || utility.IsStringEmpty(&objType.Exists) {
+		change.Validation.AddValidationStepInfo(objects.ValidationErrorNone, true, nil, gev.Designation())
+		return nil
+	}
+
+	exists, err := gev.exists(objType, change.Item.Object)
+	if err != nil {
+		change.Validation.AddValidationStepInfo(objects.ValidationErrorCritical, false, err, gev.Designation())
+		return err
+	}
+
+	change.Validation.AddValidationStepInfo(objects.ValidationErrorNone, true, nil, gev.Designation())
+	if exists {
+		change.ExistsFlag = true //set the exists flag so downstream validators can consume
+	}
+	return nil
+}
+
+func (gev *GenericObjectExistsValidator) exists(objType *DialectObjectType, obj objects.ObjectSpec) (bool, error) {
+	stmt, err := common.RenderStatement(objType.Exists, (*gonja.Context)(newLiteralRenderContext(obj)))
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := gev.db.Query(stmt)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	found := rows.Next()
+	return found, rows.Err()
+}
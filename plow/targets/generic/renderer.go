@@ -0,0 +1,92 @@
+package generic
+
+import (
+	"Plow/plow/objects"
+	"Plow/plow/targets/common"
+	"Plow/plow/utility"
+	"fmt"
+	"github.com/noirbizarre/gonja"
+)
+
+type GenericRenderer struct {
+	dialect *Dialect
+}
+
+func newGenericRenderer(dialect *Dialect) *GenericRenderer {
+	return &GenericRenderer{dialect: dialect}
+}
+
+func (gr *GenericRenderer) RenderWithContext(change *objects.ChangeItem, params *map[string]interface{}) ([]*objects.ApplyScope, error) {
+	spec := &genericDefaultSpecification{}
+	err := utility.UnmarshalYamlSubObject(change.Item.Spec, spec)
+	if err != nil {
+		return nil, err
+	}
+	return gr.renderDefaultSpec(spec, change, params)
+}
+
+func (gr *GenericRenderer) Render(change *objects.ChangeItem) ([]*objects.ApplyScope, error) {
+	return gr.RenderWithContext(change, gr.dialect.NewRenderContext(change.Item.Object))
+}
+
+func (gr *GenericRenderer) renderDefaultSpec(spec *genericDefaultSpecification, item *objects.ChangeItem, params *map[string]interface{}) ([]*objects.ApplyScope, error) {
+	out := make([]*objects.ApplyScope, 0)
+	var err error
+	var scope *objects.ApplyScope
+
+	//pre scope statements are always applied if present in the spec
+	if !utility.IsStringEmpty(&spec.Pre) {
+		if scope, err = gr.renderSpecStatement(spec.Pre, "pre", (*gonja.Context)(params)); err == nil {
+			out = append(out, scope)
+		} else {
+			return nil, err
+		}
+	}
+
+	//init and change scope statements execution depend on if the object exists, which was determined during validation
+	//if change scope is present a corresponding init scope must also present, change scope can be omitted
+	//if the objects exists change scope is applied, otherwise the init scope is applied
+	initPresent := !utility.IsStringEmpty(&spec.Init)
+	changePresent := !utility.IsStringEmpty(&spec.Change)
+
+	if initPresent {
+		if item.ExistsFlag {
+			if changePresent {
+				if scope, err = gr.renderSpecStatement(spec.Change, "change", (*gonja.Context)(params)); err == nil {
+					out = append(out, scope)
+				} else {
+					return nil, err
+				}
+			}
+		} else {
+			if scope, err = gr.renderSpecStatement(spec.Init, "init", (*gonja.Context)(params)); err == nil {
+				out = append(out, scope)
+			} else {
+				return nil, err
+			}
+		}
+	}
+
+	//post scope statements are always applied if present in the spec
+	if !utility.IsStringEmpty(&spec.Post) {
+		if scope, err = gr.renderSpecStatement(spec.Post, "post", (*gonja.Context)(params)); err == nil {
+			out = append(out, scope)
+		} else {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+func (gr *GenericRenderer) renderSpecStatement(input string, name string, params *gonja.Context) (*objects.ApplyScope, error) {
+	stmt, err := common.RenderStatement(input, params)
+	if err != nil {
+		return nil, err
+	}
+	commands, err := gr.dialect.SegmentCommands(stmt)
+	if err != nil {
+		return nil, utility.WrapError(fmt.Sprintf("scope [%s]", name), err)
+	}
+	return common.NewScope(name, commands), nil
+}
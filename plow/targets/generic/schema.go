@@ -0,0 +1,26 @@
+package generic
+
+import (
+	"Plow/plow/targets/common"
+	"strings"
+)
+
+// SpecSchemaID identifier of the exported generic spec schema
+const SpecSchemaID = "generic.schema.json"
+
+// SpecSchema the JSON Schema of generic spec files. Object types are declared by the dialect, when no dialect is
+// provided any type name is accepted.
+func SpecSchema(dialect *Dialect) *common.JSONSchema {
+	var names []string
+	if dialect != nil {
+		names = make([]string, 0)
+		for _, objType := range dialect.ObjectTypes {
+			names = append(names, strings.ToLower(strings.TrimSpace(objType.Name)))
+			for _, alias := range objType.Aliases {
+				names = append(names, strings.ToLower(strings.TrimSpace(alias)))
+			}
+		}
+	}
+	return common.NewSpecSchema(SpecSchemaID, "Plow generic spec", []string{"generic"},
+		[]common.SpecSchemaType{{Names: names, Spec: genericDefaultSpecification{}}})
+}
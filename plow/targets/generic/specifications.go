@@ -0,0 +1,8 @@
+package generic
+
+type genericDefaultSpecification struct {
+	Pre    string `yaml:"pre"`
+	Init   string `yaml:"init"`
+	Change string `yaml:"change"`
+	Post   string `yaml:"post"`
+}
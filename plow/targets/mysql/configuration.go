@@ -0,0 +1,14 @@
+package mysql
+
+import "Plow/plow/targets/common"
+
+type MySQLConfiguration struct {
+	Host           string                                   `mapstructure:"host"`
+	Port           int                                      `mapstructure:"port"`
+	UserId         string                                   `mapstructure:"userId"`
+	PasswordSecret string                                   `mapstructure:"passwordSecret"`
+	Database       string                                   `mapstructure:"database"`
+	TLS            string                                   `mapstructure:"tls"`
+	Policy         string                                   `mapstructure:"policy"`
+	Validators     map[string]common.ValidatorConfiguration `mapstructure:"validators"`
+}
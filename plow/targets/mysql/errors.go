@@ -0,0 +1,9 @@
+package mysql
+
+import "errors"
+
+var (
+	ErrInvalidConfiguration     = errors.New("invalid or incomplete mysql target configuration")
+	ErrInvalidUnapprovedCommand = errors.New("invalid or unapproved command")
+	ErrUnterminatedStatement    = errors.New("unterminated quoted string within scope statement")
+)
@@ -0,0 +1,30 @@
+package mysql
+
+import (
+	"Plow/plow/targets/common"
+)
+
+// lintPlaceholders the placeholders provided when spec scopes are rendered
+var lintPlaceholders = []string{"NAME", "DATABASE", "SCHEMA"}
+
+// Lint checks the spec without a connection to the target, in addition to the conformance check against the spec
+// schema. Change scopes without an init scope and placeholders which are not provided when rendered are reported.
+func Lint(doc *common.LintDocument) {
+	objType := StringToMySQLObjectType(doc.Spec.Type)
+	processed := false
+	for _, t := range MySQLProcessingOrder {
+		processed = processed || t == objType
+	}
+	//unknown types are reported by the conformance check against the spec schema
+	if !processed {
+		return
+	}
+
+	var spec mysqlDefaultSpecification
+	if doc.CheckSchema(&spec, "spec") {
+		doc.CheckInitPresent(spec.Init, spec.Change, "spec")
+		for _, scope := range []string{"pre", "init", "change", "post"} {
+			doc.CheckTemplate(lintPlaceholders, "spec", scope)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package mysql
+
+import (
+	"Plow/plow/objects"
+	"Plow/plow/secrets"
+	"Plow/plow/targets/common"
+	"Plow/plow/utility"
+	"context"
+	"database/sql"
+	"fmt"
+	driver "github.com/go-sql-driver/mysql"
+	"github.com/noirbizarre/gonja"
+	"time"
+)
+
+const defaultPort = 3306
+
+type MySQLTarget struct {
+	connection  *sql.DB
+	config      *driver.Config
+	database    string
+	secretStore secrets.SecretStore
+	validation  *common.ValidationHandler
+	options     *objects.Options
+	renderer    *MySQLRenderer
+	policy      *common.Policy
+	validators  map[string]common.ValidatorConfiguration
+}
+
+func (m *MySQLTarget) Open(config MySQLConfiguration, options *objects.Options, secretStore secrets.SecretStore) error {
+	if utility.IsStringEmpty(&config.Host) || utility.IsStringEmpty(&config.UserId) || utility.IsStringEmpty(&config.Database) {
+		return ErrInvalidConfiguration
+	}
+
+	m.renderer = newMySQLRenderer()
+	m.options = options
+	m.secretStore = secretStore
+	m.database = config.Database
+	m.validators = config.Validators
+
+	if !utility.IsStringEmpty(&config.Policy) {
+		policy, err := common.LoadPolicy(config.Policy)
+		if err != nil {
+			return err
+		}
+		m.policy = policy
+	}
+
+	pwd, err := secretStore.GetSecret(config.PasswordSecret)
+	if err != nil {
+		return err
+	}
+
+	port := config.Port
+	if port == 0 {
+		port = defaultPort
+	}
+
+	m.config = driver.NewConfig()
+	m.config.User = config.UserId
+	m.config.Passwd = pwd
+	m.config.Net = "tcp"
+	m.config.Addr = fmt.Sprintf("%s:%d", config.Host, port)
+	m.config.DBName = config.Database
+	m.config.ParseTime = true
+	m.config.Loc = time.UTC
+	if !utility.IsStringEmpty(&config.TLS) {
+		m.config.TLSConfig = config.TLS
+	}
+
+	db, err := sql.Open("mysql", m.config.FormatDSN())
+	if err != nil {
+		return err
+	}
+
+	m.connection = db
+	return nil
+}
+
+func (m *MySQLTarget) GetTrackingHistory(depth int) (*objects.TrackingLog, error) {
+	stmt, err := common.RenderStatement(TrackingHistorySQL, &gonja.Context{"DATABASE": m.database})
+	if err != nil {
+		return nil, err
+	}
+	if depth > 0 {
+		stmt = fmt.Sprintf("%s LIMIT %d", stmt, depth)
+	}
+
+	rows, err := m.connection.Query(stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return common.ScanTrackingHistory(rows, depth)
+}
+
+func (m *MySQLTarget) GetTrackingLogDetail(entry objects.LogEntry) ([]objects.LogItemEntry, error) {
+	return nil, common.ErrNotImplemented
+}
+
+func (m *MySQLTarget) RenderChangeLog(changes *objects.ChangeLog) ([]*common.RenderedChange, error) {
+	return common.RenderChangeLogInOrder(changes, m.GetObjectTypeExecutionOrder(), m.options.OptionFlags.Has(objects.SkipValidationSetting), m.renderChange)
+}
+
+func (m *MySQLTarget) renderChange(item *objects.ChangeItem) *common.RenderedChange {
+	//render scopes for the item, if error: add error info to item and return
+	scopes, err := m.renderer.Render(item)
+	if err != nil {
+		item.ApplyInformation.Executed = false
+		item.ApplyInformation.Completed = false
+		item.ApplyInformation.Error = err
+		return nil
+	}
+
+	return common.NewRenderedChange(item, scopes)
+}
+
+// RehearseChangeLog rehearsal against cloned databases is not supported by this target
+func (m *MySQLTarget) RehearseChangeLog(context context.Context, changes *objects.ChangeLog) error {
+	return common.ErrNotImplemented
+}
+
+// RollbackCommit restore points are not captured by this target
+func (m *MySQLTarget) RollbackCommit(context context.Context, commit string) ([]objects.RollbackItemEntry, error) {
+	return nil, common.ErrNotImplemented
+}
+
+// DetectDrift comparing specs with the live target is not supported by this target
+func (m *MySQLTarget) DetectDrift(changes *objects.ChangeLog) ([]objects.DriftEntry, error) {
+	return nil, common.ErrNotImplemented
+}
+
+func (m *MySQLTarget) ApplyChangeLog(context context.Context, changes *objects.ChangeLog) error {
+	if changes == nil {
+		return common.ErrNoChangesProvided
+	}
+
+	rendered, err := m.RenderChangeLog(changes)
+	if err != nil {
+		return err
+	}
+
+	//apply rendered changes in order, if error occurs in application halt
+	timeStart := time.Now()
+	for _, renderedChg := range rendered {
+		if err := common.ApplyRenderedChange(context, m.connection, renderedChg); err != nil {
+			return err
+		}
+	}
+
+	return common.TrackChangeLog(m, changes, timeStart, m.config.User, m.options.OptionFlags.Has(objects.FastForwardSetting))
+}
+
+func (m *MySQLTarget) ValidateChangeLog(changes *objects.ChangeLog) error {
+	if changes != nil {
+		//initialize the validation handler
+		m.validation = common.NewValidationHandler(StringToMySQLObjectTypeInt64)
+		m.validation.RegisterRequiredValidator(newMySQLObjectExistsValidator(m, changes))
+		m.validation.RegisterGlobalValidator(common.NewSpecSchemaValidator())
+		if m.policy != nil {
+			m.validation.RegisterGlobalValidator(common.NewPolicyValidator(m.policy, m.renderer, m.options.Environment))
+		}
+		if err := m.validation.Configure(m.validators, m.options); err != nil {
+			return err
+		}
+		if err := m.validation.Initialize(); err != nil {
+			return err
+		}
+		defer m.validation.Close()
+
+		if err := m.validation.ValidateItems(changes.Items()); err != nil {
+			return err
+		}
+		for _, bundle := range changes.Bundles {
+			bundle.Validated = true
+		}
+	}
+
+	return nil
+}
+
+func (m *MySQLTarget) Close() error {
+	return m.connection.Close()
+}
+
+func (m *MySQLTarget) PersistTrackingLogDetail(detail *objects.LogItemEntry) error {
+	gc := common.NewTrackingDetailContext(detail)
+	(*gc)["DATABASE"] = m.database
+
+	stmt, err := common.RenderStatement(InsertTrackingDetailSQL, gc)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.connection.Exec(stmt)
+	return err
+}
+
+func (m *MySQLTarget) PersistTrackingLogEntry(entry *objects.LogEntry) error {
+	gc := common.NewTrackingEntryContext(entry)
+	(*gc)["DATABASE"] = m.database
+
+	stmt, err := common.RenderStatement(InsertTrackingInfoSQL, gc)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.connection.Exec(stmt)
+	return err
+}
+
+func (m *MySQLTarget) GetObjectTypeTranslator() objects.ObjectTypeTranslator {
+	return StringToMySQLObjectTypeInt64
+}
+
+func (m *MySQLTarget) GetObjectTypeExecutionOrder() []int64 {
+	rv := make([]int64, len(MySQLProcessingOrder))
+	for i, v := range MySQLProcessingOrder {
+		rv[i] = v.ToInt64()
+	}
+	return rv
+}
+
+// GetSpecSchema the schema spec files applied to the target are validated against when loaded
+func (m *MySQLTarget) GetSpecSchema() *common.JSONSchema {
+	return SpecSchema()
+}
@@ -0,0 +1,242 @@
+package mysql
+
+import (
+	"Plow/plow/objects"
+	"Plow/plow/targets/common"
+	"database/sql"
+	"github.com/noirbizarre/gonja"
+	"strings"
+)
+
+type MySQLObjectExistsValidator struct {
+	meta        *common.Metadata
+	db          *sql.DB
+	changes     *objects.ChangeLog
+	initialized bool
+}
+
+func newMySQLObjectExistsValidator(target *MySQLTarget, changes *objects.ChangeLog) *MySQLObjectExistsValidator {
+	return &MySQLObjectExistsValidator{
+		db:      target.connection,
+		meta:    common.NewMetadata(StringToMySQLObjectTypeInt64),
+		changes: changes,
+	}
+}
+
+func (mev *MySQLObjectExistsValidator) Init() error {
+	if !mev.initialized {
+		databases, users := mev.identifyChangeScope(mev.changes)
+		err := mev.loadMeta(databases, mev.meta)
+		if err != nil {
+			return err
+		}
+		if users {
+			if err = mev.loadUserMeta(mev.meta); err != nil {
+				return err
+			}
+		}
+		mev.initialized = true
+	}
+	return nil
+}
+
+func (mev *MySQLObjectExistsValidator) Destroy() error {
+	return nil
+}
+
+// Concurrent the metadata is loaded by Init and only read when validating
+func (mev *MySQLObjectExistsValidator) Concurrent() bool {
+	return true
+}
+
+func (mev *MySQLObjectExistsValidator) Designation() string {
+	return "ObjectExistsValidator"
+}
+
+func (mev *MySQLObjectExistsValidator) Validate(change *objects.ChangeItem) error {
+	metaobj, err := mev.meta.FindObjectFromSpec(change.Item)
+	if err != nil {
+		change.Validation.AddValidationStepInfo(objects.ValidationErrorCritical, false, err, mev.Designation())
+		return err
+	}
+
+	change.Validation.AddValidationStepInfo(objects.ValidationErrorNone, true, nil, mev.Designation())
+
+	if metaobj != nil {
+		change.ExistsFlag = true //set the exists flag so downstream validators can consume
+	}
+
+	return nil
+}
+
+// identifyChangeScope lists the databases referenced by the change log and whether any user objects are present
+func (mev *MySQLObjectExistsValidator) identifyChangeScope(changes *objects.ChangeLog) (map[string]bool, bool) {
+	databases := make(map[string]bool)
+	users := false
+	for _, bundle := range changes.Bundles {
+		for _, change := range bundle.Items {
+			switch StringToMySQLObjectType(change.Item.Type) {
+			case UnknownType:
+				break
+			case User:
+				users = true
+				break
+			case Database:
+				databases[change.Item.Object.Name] = true
+				break
+			default:
+				if len(strings.TrimSpace(change.Item.Object.Database)) > 0 {
+					databases[change.Item.Object.Database] = true
+				}
+				break
+			}
+		}
+	}
+	return databases, users
+}
+
+func (mev *MySQLObjectExistsValidator) loadMeta(databases map[string]bool, meta *common.Metadata) error {
+	prunedDbList, err := mev.loadDatabasesMeta(meta, databases)
+	if err != nil {
+		return err
+	}
+	for _, database := range prunedDbList {
+		err = mev.loadDatabaseMeta(database, meta)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (mev *MySQLObjectExistsValidator) loadDatabasesMeta(meta *common.Metadata, databases map[string]bool) ([]string, error) {
+	output := make([]string, 0)
+
+	rows, err := mev.db.Query(GetDatabasesSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dbname string
+	for rows.Next() {
+		if err = rows.Scan(&dbname); err != nil {
+			return nil, err
+		}
+		if _, ok := databases[dbname]; ok {
+			output = append(output, dbname)
+			if err = addMetadataObject(meta, Database, common.Property{Name: "name", Value: dbname, IsKey: true}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return output, rows.Err()
+}
+
+func (mev *MySQLObjectExistsValidator) loadDatabaseMeta(database string, meta *common.Metadata) error {
+	steps := []func(string, *common.Metadata) error{mev.loadTableViewMeta, mev.loadRoutineMeta, mev.loadTriggerMeta, mev.loadEventMeta}
+	for _, step := range steps {
+		err := step(database, meta)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (mev *MySQLObjectExistsValidator) loadTableViewMeta(database string, meta *common.Metadata) error {
+	return mev.queryDatabaseObjects(GetTablesViewsSQL, database, meta, func(name string, kind string) MySQLObjectType {
+		if strings.Contains(strings.ToUpper(kind), "VIEW") {
+			return View
+		}
+		return Table
+	})
+}
+
+func (mev *MySQLObjectExistsValidator) loadRoutineMeta(database string, meta *common.Metadata) error {
+	return mev.queryDatabaseObjects(GetRoutinesSQL, database, meta, func(name string, kind string) MySQLObjectType {
+		if strings.Compare(strings.ToUpper(kind), "FUNCTION") == 0 {
+			return Function
+		}
+		return Procedure
+	})
+}
+
+func (mev *MySQLObjectExistsValidator) loadTriggerMeta(database string, meta *common.Metadata) error {
+	return mev.queryDatabaseObjects(GetTriggersSQL, database, meta, func(name string, kind string) MySQLObjectType {
+		return Trigger
+	})
+}
+
+func (mev *MySQLObjectExistsValidator) loadEventMeta(database string, meta *common.Metadata) error {
+	return mev.queryDatabaseObjects(GetEventsSQL, database, meta, func(name string, kind string) MySQLObjectType {
+		return Event
+	})
+}
+
+// queryDatabaseObjects executes a catalog statement returning the object name and optionally an object kind column,
+// classifier maps each row to the object type it is recorded as
+func (mev *MySQLObjectExistsValidator) queryDatabaseObjects(template string, database string, meta *common.Metadata, classifier func(string, string) MySQLObjectType) error {
+	stmt, err := common.RenderStatement(template, &gonja.Context{"DATABASE": common.MakeStringDatabaseSafe(database)})
+	if err != nil {
+		return err
+	}
+
+	rows, err := mev.db.Query(stmt)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	var name, kind string
+	for rows.Next() {
+		if len(columns) > 1 {
+			err = rows.Scan(&name, &kind)
+		} else {
+			err = rows.Scan(&name)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err = addMetadataObject(meta, classifier(name, kind),
+			common.Property{Name: "name", Value: name, IsKey: true},
+			common.Property{Name: "database", Value: database}); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (mev *MySQLObjectExistsValidator) loadUserMeta(meta *common.Metadata) error {
+	rows, err := mev.db.Query(GetUsersSQL)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var username string
+	for rows.Next() {
+		if err = rows.Scan(&username); err != nil {
+			return err
+		}
+		if err = addMetadataObject(meta, User, common.Property{Name: "name", Value: username, IsKey: true}); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func addMetadataObject(meta *common.Metadata, objectType MySQLObjectType, properties ...common.Property) error {
+	metaObject, err := common.NewMetadataObject(objectType.ToInt64(), properties...)
+	if err != nil {
+		return err
+	}
+	meta.AddObject(metaObject)
+	return nil
+}
@@ -0,0 +1,66 @@
+package mysql
+
+import (
+	"sort"
+	"strings"
+)
+
+type MySQLObjectType int64
+
+const (
+	UnknownType MySQLObjectType = iota
+	User
+	Database
+	Table
+	View
+	Procedure
+	Function
+	Trigger
+	Event
+)
+
+func (m MySQLObjectType) ToInt64() int64 {
+	return int64(m)
+}
+
+var MySQLProcessingOrder = [...]MySQLObjectType{User, Database, Table, View, Function, Procedure, Trigger, Event}
+
+func StringToMySQLObjectTypeInt64(s string) int64 {
+	return int64(StringToMySQLObjectType(s))
+}
+
+// mysqlTypeNames the accepted type names of each object type, names are matched case-insensitively
+var mysqlTypeNames = map[string]MySQLObjectType{
+	"user":                User,
+	"database":            Database,
+	"schema":              Database,
+	"table":               Table,
+	"view":                View,
+	"procedure":           Procedure,
+	"sproc":               Procedure,
+	"storedprocedure":     Procedure,
+	"function":            Function,
+	"udf":                 Function,
+	"userdefinedfunction": Function,
+	"trigger":             Trigger,
+	"event":               Event,
+}
+
+func StringToMySQLObjectType(s string) MySQLObjectType {
+	if objType, ok := mysqlTypeNames[strings.TrimSpace(strings.ToLower(s))]; ok {
+		return objType
+	}
+	return UnknownType
+}
+
+// TypeNames the accepted type names of the object type in alphabetical order
+func (m MySQLObjectType) TypeNames() []string {
+	names := make([]string, 0)
+	for name, objType := range mysqlTypeNames {
+		if objType == m {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
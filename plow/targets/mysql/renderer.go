@@ -0,0 +1,108 @@
+package mysql
+
+import (
+	"Plow/plow/objects"
+	"Plow/plow/targets/common"
+	"Plow/plow/utility"
+	"github.com/noirbizarre/gonja"
+	"strings"
+)
+
+type MySQLRenderer struct{}
+
+func newMySQLRenderer() *MySQLRenderer {
+	return &MySQLRenderer{}
+}
+
+func (mr *MySQLRenderer) RenderWithContext(change *objects.ChangeItem, params *map[string]interface{}) ([]*objects.ApplyScope, error) {
+	spec := &mysqlDefaultSpecification{}
+	err := utility.UnmarshalYamlSubObject(change.Item.Spec, spec)
+	if err != nil {
+		return nil, err
+	}
+	return mr.renderDefaultSpec(spec, change, params)
+}
+
+func (mr *MySQLRenderer) Render(change *objects.ChangeItem) ([]*objects.ApplyScope, error) {
+	return mr.RenderWithContext(change, newRenderContextFromObjectInfo(change.Item.Object))
+}
+
+func (mr *MySQLRenderer) renderDefaultSpec(spec *mysqlDefaultSpecification, item *objects.ChangeItem, params *map[string]interface{}) ([]*objects.ApplyScope, error) {
+	out := make([]*objects.ApplyScope, 0)
+	var err error
+	var scope *objects.ApplyScope
+
+	//pre scope statements are always applied if present in the spec
+	if !utility.IsStringEmpty(&spec.Pre) {
+		if scope, err = renderSpecStatement(spec.Pre, "pre", (*gonja.Context)(params)); err == nil {
+			out = append(out, scope)
+		} else {
+			return nil, err
+		}
+	}
+
+	//init and change scope statements execution depend on if the object exists, which was determined during validation
+	//if change scope is present a corresponding init scope must also present, change scope can be omitted
+	//if the objects exists change scope is applied, otherwise the init scope is applied
+	initPresent := !utility.IsStringEmpty(&spec.Init)
+	changePresent := !utility.IsStringEmpty(&spec.Change)
+
+	if initPresent {
+		if item.ExistsFlag {
+			if changePresent {
+				if scope, err = renderSpecStatement(spec.Change, "change", (*gonja.Context)(params)); err == nil {
+					out = append(out, scope)
+				} else {
+					return nil, err
+				}
+			}
+		} else {
+			if scope, err = renderSpecStatement(spec.Init, "init", (*gonja.Context)(params)); err == nil {
+				out = append(out, scope)
+			} else {
+				return nil, err
+			}
+		}
+	}
+
+	//post scope statements are always applied if present in the spec
+	if !utility.IsStringEmpty(&spec.Post) {
+		if scope, err = renderSpecStatement(spec.Post, "post", (*gonja.Context)(params)); err == nil {
+			out = append(out, scope)
+		} else {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+func renderSpecStatement(input string, name string, params *gonja.Context) (*objects.ApplyScope, error) {
+	stmt, err := common.RenderStatement(input, params)
+	if err != nil {
+		return nil, err
+	}
+	commands, err := segmentScopeCommands(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if utility.Any(commands, isDelimiterDirective) {
+		return nil, ErrInvalidUnapprovedCommand
+	}
+
+	return common.NewScope(name, commands), nil
+}
+
+// isDelimiterDirective guards against a DELIMITER directive which was not recognized during segmentation, ie. one
+// sharing a line with other statements, from being sent to the server
+func isDelimiterDirective(command string) bool {
+	return strings.HasPrefix(strings.ToUpper(strings.TrimSpace(command)), "DELIMITER ")
+}
+
+// newRenderContextFromObjectInfo mysql identifiers may be case-sensitive depending on the host file system,
+// values are passed through as defined in the object header
+func newRenderContextFromObjectInfo(obj objects.ObjectSpec) *map[string]interface{} {
+	return &map[string]interface{}{"NAME": strings.TrimSpace(obj.Name),
+		"DATABASE": strings.TrimSpace(obj.Database),
+		"SCHEMA":   strings.TrimSpace(obj.Schema)}
+}
@@ -0,0 +1,19 @@
+package mysql
+
+import (
+	"Plow/plow/targets/common"
+)
+
+// SpecSchemaID identifier of the exported mysql spec schema
+const SpecSchemaID = "mysql.schema.json"
+
+// SpecSchema the JSON Schema of mysql spec files, every object type processed by the target uses the default
+// specification
+func SpecSchema() *common.JSONSchema {
+	names := make([]string, 0)
+	for _, objType := range MySQLProcessingOrder {
+		names = append(names, objType.TypeNames()...)
+	}
+	return common.NewSpecSchema(SpecSchemaID, "Plow mysql spec", []string{"mysql", "mariadb"},
+		[]common.SpecSchemaType{{Names: names, Spec: mysqlDefaultSpecification{}}})
+}
@@ -0,0 +1,8 @@
+package mysql
+
+type mysqlDefaultSpecification struct {
+	Pre    string `yaml:"pre"`
+	Init   string `yaml:"init"`
+	Change string `yaml:"change"`
+	Post   string `yaml:"post"`
+}
@@ -0,0 +1,13 @@
+package mysql
+
+const (
+	TrackingHistorySQL      = "SELECT COMMIT_ID, MSG, EXEC_START, EXEC_END, EXEC_WHO, CHANGE_COUNT, CHANGE_SUCCESS, CHANGE_FAIL, COMPLETED, FAST_FORWARD FROM `{{DATABASE}}`.COMMITS WHERE COMPLETED = TRUE ORDER BY EXEC_END DESC"
+	InsertTrackingDetailSQL = "INSERT INTO `{{DATABASE}}`.CHANGE_LOG VALUES ('{{COMMIT}}', '{{FILE}}', '{{REF}}', '{{HASH}}', {{STATUS}}, '{{TIME}}', '{{MSG}}', {{PARTIAL}})"
+	InsertTrackingInfoSQL   = "INSERT INTO `{{DATABASE}}`.COMMITS VALUES ('{{COMMIT}}', '{{MSG}}', '{{START}}', '{{END}}', '{{WHO}}', {{TOTAL}}, {{SUCCESS}}, {{FAIL}}, {{COMPLETED}}, {{FAST_FORWARD}})"
+	GetDatabasesSQL         = "SELECT SCHEMA_NAME FROM information_schema.SCHEMATA"
+	GetTablesViewsSQL       = "SELECT TABLE_NAME, TABLE_TYPE FROM information_schema.TABLES WHERE TABLE_SCHEMA = '{{DATABASE}}'"
+	GetRoutinesSQL          = "SELECT ROUTINE_NAME, ROUTINE_TYPE FROM information_schema.ROUTINES WHERE ROUTINE_SCHEMA = '{{DATABASE}}'"
+	GetTriggersSQL          = "SELECT TRIGGER_NAME FROM information_schema.TRIGGERS WHERE TRIGGER_SCHEMA = '{{DATABASE}}'"
+	GetEventsSQL            = "SELECT EVENT_NAME FROM information_schema.EVENTS WHERE EVENT_SCHEMA = '{{DATABASE}}'"
+	GetUsersSQL             = "SELECT DISTINCT USER FROM mysql.user"
+)
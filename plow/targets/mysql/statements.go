@@ -0,0 +1,127 @@
+package mysql
+
+import (
+	"regexp"
+	"strings"
+)
+
+const defaultDelimiter = ";"
+
+var (
+	regexDelimiterDirective = regexp.MustCompile(`(?i)^\s*DELIMITER\s+(\S+)\s*$`)
+	regexBlockComment       = regexp.MustCompile(`(?s)/\*[^!].*?\*/|/\*\*/`)
+)
+
+// segmentScopeCommands splits a scope statement blob into individual commands.
+//
+// The mysql client DELIMITER directive is honored so routines, triggers and events whose bodies contain ";" can
+// be defined within a scope, e.g.
+//
+//	DELIMITER $$
+//	CREATE PROCEDURE {{DATABASE}}.DEMO() BEGIN SELECT 1; END $$
+//	DELIMITER ;
+//
+// the directive itself is a client side construct and is never sent to the server. Delimiters found within quoted
+// strings, quoted identifiers, line comments or block comments do not terminate a command.
+func segmentScopeCommands(blob string) ([]string, error) {
+	commands := make([]string, 0)
+	delimiter := defaultDelimiter
+	var current strings.Builder
+	var quote byte
+	comment := false //within a block comment, block comments may span lines
+
+	flush := func() {
+		if cmd := strings.TrimSpace(current.String()); !isCommentOnly(cmd) {
+			commands = append(commands, cmd)
+		}
+		current.Reset()
+	}
+
+	for _, line := range strings.Split(blob, "\n") {
+		if quote == 0 && !comment {
+			if match := regexDelimiterDirective.FindStringSubmatch(line); match != nil {
+				flush()
+				delimiter = match[1]
+				continue
+			}
+		}
+
+		for i := 0; i < len(line); {
+			c := line[i]
+			if comment {
+				if strings.HasPrefix(line[i:], "*/") {
+					comment = false
+					current.WriteString("*/")
+					i += 2
+					continue
+				}
+				current.WriteByte(c)
+				i++
+				continue
+			}
+			if quote != 0 {
+				current.WriteByte(c)
+				if c == '\\' && quote != '`' && i+1 < len(line) {
+					current.WriteByte(line[i+1])
+					i += 2
+					continue
+				}
+				if c == quote {
+					quote = 0
+				}
+				i++
+				continue
+			}
+
+			if c == '\'' || c == '"' || c == '`' {
+				quote = c
+				current.WriteByte(c)
+				i++
+				continue
+			}
+
+			//remainder of the line is a comment, retain it with the command it belongs to
+			if strings.HasPrefix(line[i:], "--") || c == '#' {
+				current.WriteString(line[i:])
+				break
+			}
+
+			if strings.HasPrefix(line[i:], "/*") {
+				comment = true
+				current.WriteString("/*")
+				i += 2
+				continue
+			}
+
+			if strings.HasPrefix(line[i:], delimiter) {
+				flush()
+				i += len(delimiter)
+				continue
+			}
+
+			current.WriteByte(c)
+			i++
+		}
+		current.WriteByte('\n')
+	}
+
+	if quote != 0 || comment {
+		return nil, ErrUnterminatedStatement
+	}
+	flush()
+
+	return commands, nil
+}
+
+// isCommentOnly identifies commands which contain nothing but whitespace, line comments and block comments, the server
+// rejects these as empty queries. Executable comments, /*! ... */, are statements.
+func isCommentOnly(cmd string) bool {
+	cmd = regexBlockComment.ReplaceAllString(cmd, "")
+	for _, line := range strings.Split(cmd, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) > 0 && !strings.HasPrefix(line, "--") && !strings.HasPrefix(line, "#") {
+			return false
+		}
+	}
+	return true
+}
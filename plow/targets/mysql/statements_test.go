@@ -0,0 +1,46 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSegmentScopeCommands(t *testing.T) {
+	tests := []struct {
+		name  string
+		blob  string
+		wants []string
+	}{
+		{"delimiter", "SELECT 1;\nSELECT 2;", []string{"SELECT 1", "SELECT 2"}},
+		{"quoted delimiter", "SELECT 'a;b';", []string{"SELECT 'a;b'"}},
+		{"line comment", "SELECT 1; -- a; b\nSELECT 2;", []string{"SELECT 1", "-- a; b\nSELECT 2"}},
+		{"line comment without space", "--a; b\nSELECT 1;", []string{"--a; b\nSELECT 1"}},
+		{"hash comment", "# a; b\nSELECT 1;", []string{"# a; b\nSELECT 1"}},
+		{"block comment", "SELECT /* a; b */ 1;", []string{"SELECT /* a; b */ 1"}},
+		{"multiline block comment", "SELECT 1 /* a;\nb; */;\nSELECT 2;", []string{"SELECT 1 /* a;\nb; */", "SELECT 2"}},
+		{"comment only", "SELECT 1;\n/* trailing; */\n-- end", []string{"SELECT 1"}},
+		{"executable comment", "/*!40101 SET NAMES utf8 */;", []string{"/*!40101 SET NAMES utf8 */"}},
+		{"delimiter directive", "DELIMITER $$\nCREATE PROCEDURE P() BEGIN SELECT 1; END $$\nDELIMITER ;\nSELECT 2;",
+			[]string{"CREATE PROCEDURE P() BEGIN SELECT 1; END", "SELECT 2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commands, err := segmentScopeCommands(tt.blob)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(commands, tt.wants) {
+				t.Errorf("segmentScopeCommands() = %q, wants %q", commands, tt.wants)
+			}
+		})
+	}
+}
+
+func TestSegmentScopeCommandsUnterminated(t *testing.T) {
+	for _, blob := range []string{"SELECT 'a;", "SELECT 1 /* a;"} {
+		if _, err := segmentScopeCommands(blob); err != ErrUnterminatedStatement {
+			t.Errorf("segmentScopeCommands(%q) error = %v, wants %v", blob, err, ErrUnterminatedStatement)
+		}
+	}
+}
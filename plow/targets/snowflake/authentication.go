@@ -0,0 +1,155 @@
+package snowflake
+
+import (
+	"Plow/plow/secrets"
+	"Plow/plow/utility"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	sf "github.com/snowflakedb/gosnowflake"
+	"github.com/youmark/pkcs8"
+	"os"
+	"strings"
+)
+
+const (
+	JwtAuthenticator             = "JWT"
+	OAuthAuthenticator           = "OAUTH"
+	PasswordAuthenticator        = "PASSWORD"
+	ExternalBrowserAuthenticator = "EXTERNALBROWSER"
+)
+
+// authenticator the configured authenticator, key pair (jwt) authentication is assumed when not set
+func (c *SnowflakeConfiguration) authenticator() string {
+	if utility.IsStringEmpty(&c.Authenticator) {
+		return JwtAuthenticator
+	}
+	auth := strings.ToUpper(strings.TrimSpace(c.Authenticator))
+	if auth == "SNOWFLAKE" {
+		return PasswordAuthenticator
+	}
+	return auth
+}
+
+// privateKeyFile path of the private key file, falls back to the deprecated publicKeyFile setting
+func (c *SnowflakeConfiguration) privateKeyFile() string {
+	if !utility.IsStringEmpty(&c.PrivateKeyFile) {
+		return c.PrivateKeyFile
+	}
+	return c.PublicKeyFile
+}
+
+// Validate verifies the settings required by the configured authenticator are present, no secrets are resolved
+func (c *SnowflakeConfiguration) Validate() error {
+	required := []struct {
+		name  string
+		value *string
+	}{{"userId", &c.UserId}, {"account", &c.Account}, {"role", &c.Role}, {"database", &c.Database}, {"warehouse", &c.Warehouse}}
+	for _, setting := range required {
+		if utility.IsStringEmpty(setting.value) {
+			return utility.WrapError(fmt.Sprintf("[%s]", setting.name), ErrMissingConnectionSetting)
+		}
+	}
+
+	keyFile := c.privateKeyFile()
+	switch c.authenticator() {
+	case JwtAuthenticator:
+		if utility.IsStringEmpty(&keyFile) && utility.IsStringEmpty(&c.PrivateKeySecret) {
+			return ErrMissingPrivateKey
+		}
+		if !utility.IsStringEmpty(&keyFile) && !utility.IsStringEmpty(&c.PrivateKeySecret) {
+			return ErrAmbiguousPrivateKey
+		}
+	case OAuthAuthenticator:
+		if utility.IsStringEmpty(&c.TokenSecret) {
+			return ErrMissingTokenSecret
+		}
+	case PasswordAuthenticator:
+		if utility.IsStringEmpty(&c.UserPasswordSecret) {
+			return ErrMissingUserPasswordSecret
+		}
+	case ExternalBrowserAuthenticator:
+	default:
+		return utility.WrapError(fmt.Sprintf("[%s]", c.Authenticator), ErrUnknownAuthenticator)
+	}
+	return nil
+}
+
+// applyAuthentication resolves the connection material of the configured authenticator onto the driver config
+func applyAuthentication(config *SnowflakeConfiguration, secretStore secrets.SecretStore, sfConfig *sf.Config) error {
+	switch config.authenticator() {
+	case JwtAuthenticator:
+		pkey, err := loadPrivateKey(config, secretStore)
+		if err != nil {
+			return err
+		}
+		sfConfig.Authenticator = sf.AuthTypeJwt
+		sfConfig.PrivateKey = pkey
+	case OAuthAuthenticator:
+		token, err := secretStore.GetSecret(config.TokenSecret)
+		if err != nil {
+			return utility.WrapError("unable to resolve [tokenSecret]:", err)
+		}
+		sfConfig.Authenticator = sf.AuthTypeOAuth
+		sfConfig.Token = token
+	case PasswordAuthenticator:
+		pwd, err := secretStore.GetSecret(config.UserPasswordSecret)
+		if err != nil {
+			return utility.WrapError("unable to resolve [userPasswordSecret]:", err)
+		}
+		sfConfig.Authenticator = sf.AuthTypeSnowflake
+		sfConfig.Password = pwd
+	case ExternalBrowserAuthenticator:
+		sfConfig.Authenticator = sf.AuthTypeExternalBrowser
+	default:
+		return ErrUnknownAuthenticator
+	}
+	return nil
+}
+
+// loadPrivateKey reads the PEM encoded private key from the secret store or local disk. Encrypted PKCS8 keys are
+// decrypted using the passphrase identified by passwordSecret, unencrypted PKCS8 and PKCS1 keys require no passphrase.
+func loadPrivateKey(config *SnowflakeConfiguration, secretStore secrets.SecretStore) (*rsa.PrivateKey, error) {
+	var bytes []byte
+	if !utility.IsStringEmpty(&config.PrivateKeySecret) {
+		material, err := secretStore.GetSecret(config.PrivateKeySecret)
+		if err != nil {
+			return nil, utility.WrapError("unable to resolve [privateKeySecret]:", err)
+		}
+		//secret stores backed by single line values commonly carry escaped line feeds
+		if !strings.Contains(material, "\n") {
+			material = strings.ReplaceAll(material, `\n`, "\n")
+		}
+		bytes = []byte(material)
+	} else {
+		material, err := os.ReadFile(config.privateKeyFile())
+		if err != nil {
+			return nil, err
+		}
+		bytes = material
+	}
+
+	block, _ := pem.Decode(bytes)
+	if block == nil {
+		return nil, ErrInvalidPrivateKey
+	}
+
+	switch block.Type {
+	case "ENCRYPTED PRIVATE KEY":
+		if utility.IsStringEmpty(&config.KeyPasswordSecret) {
+			return nil, utility.WrapError("encrypted private key requires [passwordSecret]:", ErrMissingConnectionSetting)
+		}
+		pwd, err := secretStore.GetSecret(config.KeyPasswordSecret)
+		if err != nil {
+			return nil, utility.WrapError("unable to resolve [passwordSecret]:", err)
+		}
+		return pkcs8.ParsePKCS8PrivateKeyRSA(block.Bytes, []byte(pwd))
+	case "PRIVATE KEY":
+		return pkcs8.ParsePKCS8PrivateKeyRSA(block.Bytes)
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, ErrUnsupportedPrivateKeyType
+	}
+}
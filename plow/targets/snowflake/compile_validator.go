@@ -0,0 +1,270 @@
+package snowflake
+
+import (
+	"Plow/plow/objects"
+	"Plow/plow/targets/common"
+	"Plow/plow/utility"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/noirbizarre/gonja"
+	"regexp"
+	"strings"
+)
+
+// sfCompileKind the DROP and SHOW kinds of an object type compiled by the compile validator
+type sfCompileKind struct {
+	kind  string
+	kinds string
+}
+
+// compileKinds object types whose scope is compiled within the validate schema
+var compileKinds = map[SnowflakeObjectType]sfCompileKind{
+	View:                {kind: "VIEW", kinds: "VIEWS"},
+	Procedure:           {kind: "PROCEDURE", kinds: "PROCEDURES"},
+	UserDefinedFunction: {kind: "FUNCTION", kinds: "USER FUNCTIONS"},
+}
+
+// createCommand commands creating an object, the only commands compiled
+var createCommand = regexp.MustCompile(`(?i)^\s*CREATE\s`)
+
+// SnowflakeCompileValidator compiles the scope applied for a view, procedure or udf within the VALIDATE schema of the
+// change mgmt database. The scope is rendered as it would be applied and references to the object are rewritten to
+// the validate schema, compile errors are reported as critical. The output columns of a compiled view are compared
+// with those of the current view.
+type SnowflakeCompileValidator struct {
+	target       *SnowflakeTarget
+	databaseName string
+}
+
+func newSnowflakeCompileValidator(snowflake *SnowflakeTarget) *SnowflakeCompileValidator {
+	return &SnowflakeCompileValidator{target: snowflake, databaseName: snowflake.config.Database}
+}
+
+func (cv *SnowflakeCompileValidator) Init() error {
+	return nil
+}
+
+func (cv *SnowflakeCompileValidator) Destroy() error {
+	return nil
+}
+
+// Concurrent the compiled objects are named for the change and the statements of a change are executed over one
+// connection
+func (cv *SnowflakeCompileValidator) Concurrent() bool {
+	return true
+}
+
+func (cv *SnowflakeCompileValidator) Designation() string {
+	return "CompileValidator"
+}
+
+func (cv *SnowflakeCompileValidator) Validate(change *objects.ChangeItem) error {
+	kind, ok := compileKinds[StringToSnowflakeObjectType(change.Item.Type)]
+	if !ok {
+		return nil
+	}
+
+	if !change.Item.Options.Validate {
+		change.Validation.AddValidationStepInfo(objects.ValidationErrorInfo, false, errors.New("object settings for validation are not enabled"), cv.Designation())
+		return nil
+	}
+
+	if change.Item.Options.Drop {
+		change.Validation.AddValidationStepInfo(objects.ValidationErrorNone, true, nil, cv.Designation())
+		return nil
+	}
+
+	var spec sfDefaultSpecification
+	if err := utility.UnmarshalYamlSubObject(change.Item.Spec, &spec); err != nil {
+		change.Validation.AddValidationStepInfo(objects.ValidationErrorCritical, false, errors.New("spec definition invalid,"+err.Error()), cv.Designation())
+		return nil //  validator did not fail but has completed its task
+	}
+
+	//the scope selection mirrors rendering, change when the object exists otherwise init
+	statement, name := spec.Init, "init"
+	if change.ExistsFlag {
+		statement, name = spec.Change, "change"
+	}
+	if utility.IsStringEmpty(&statement) {
+		change.Validation.AddValidationStepInfo(objects.ValidationErrorInfo, true, fmt.Errorf("no [%s] scope is applied, compile skipped", name), cv.Designation())
+		return nil
+	}
+
+	params := common.NewRenderContextFromObjectInfo(change.Item.Object)
+	scope, err := renderSpecStatement(statement, name, (*gonja.Context)(params))
+	if err != nil {
+		change.Validation.AddValidationStepInfo(objects.ValidationErrorCritical, false, utility.WrapError(fmt.Sprintf("rendering failed [%s]", name), err), cv.Designation())
+		return nil //  validator did not fail but has completed its task
+	}
+
+	//the compiled object is named for the change so changes validated at once do not collide
+	vars := *params
+	mock := validationObjectName(change)
+	object := fmt.Sprintf("%s.%s.%s", vars["DATABASE"], vars["SCHEMA"], vars["NAME"])
+	validate := fmt.Sprintf("%s.VALIDATE.%s", cv.databaseName, mock)
+	commands := compileCommands(scope.Commands, object, validate)
+	if len(commands) == 0 {
+		change.Validation.AddValidationStepInfo(objects.ValidationErrorWarn, false, fmt.Errorf("[%s] scope does not reference %s, compile skipped", name, object), cv.Designation())
+		return nil
+	}
+
+	ctx := context.Background()
+	conn, err := cv.target.connection.Conn(ctx)
+	if err != nil {
+		change.Validation.AddValidationStepInfo(objects.ValidationErrorCritical, false, utility.WrapError("failed to obtain connection:", err), cv.Designation())
+		return nil //  validator did not fail but has completed its task
+	}
+	defer conn.Close()
+
+	//run in event prior run created but never cleaned up after itself
+	if err := cv.cleanup(ctx, conn, kind, mock); err != nil {
+		change.Validation.AddValidationStepInfo(objects.ValidationErrorCritical, false, utility.WrapError("failed to execute prep command:", err), cv.Designation())
+		return nil //  validator did not fail but has completed its task
+	}
+
+	var differences []objects.ValidationDifference
+	for _, cmd := range commands {
+		if _, err = conn.ExecContext(ctx, cmd); err != nil {
+			err = utility.WrapError(fmt.Sprintf("failed compiling [%s] scope:", name), err)
+			break
+		}
+	}
+	if err == nil && kind.kind == "VIEW" && change.ExistsFlag {
+		differences, err = cv.compareViewColumns(object, validate)
+		if err != nil {
+			err = utility.WrapError("failed describing view:", err)
+		}
+	}
+
+	// run cleanup commands, if error dont fail validator, next pass will cleanup in prep stage
+	_ = cv.cleanup(ctx, conn, kind, mock)
+
+	if err != nil {
+		change.Validation.AddValidationStepInfo(objects.ValidationErrorCritical, false, err, cv.Designation())
+		return nil //  validator did not fail but has completed its task
+	}
+
+	if len(differences) > 0 {
+		severity := objects.ValidationErrorNone
+		for _, difference := range differences {
+			if difference.Severity > severity {
+				severity = difference.Severity
+			}
+		}
+		change.Validation.AddValidationStepDifferences(severity,
+			false,
+			fmt.Errorf("output columns differ from current view, %d difference(s)", len(differences)),
+			cv.Designation(),
+			differences)
+		return nil
+	}
+
+	change.Validation.AddValidationStepInfo(objects.ValidationErrorNone, true, nil, cv.Designation())
+	return nil
+}
+
+// compileCommands the CREATE commands of the scope referencing the object, rewritten to reference the validate object.
+// Other commands, e.g. USE ROLE or grants, are not compiled.
+func compileCommands(commands []string, object string, validate string) []string {
+	parts := strings.Split(object, ".")
+	for i, part := range parts {
+		parts[i] = `"?` + regexp.QuoteMeta(part) + `"?`
+	}
+	reference := regexp.MustCompile(`(?i)(^|[^\w$."])` + strings.Join(parts, `\s*\.\s*`) + `([^\w$.]|$)`)
+
+	out := make([]string, 0)
+	for _, cmd := range commands {
+		if !createCommand.MatchString(cmd) || !reference.MatchString(cmd) {
+			continue
+		}
+		out = append(out, reference.ReplaceAllString(cmd, "${1}"+validate+"${2}"))
+	}
+	return out
+}
+
+// cleanup drops every object of the kind with the name within the validate schema, functions and procedures are
+// dropped for each overload
+func (cv *SnowflakeCompileValidator) cleanup(ctx context.Context, conn *sql.Conn, kind sfCompileKind, name string) error {
+	stmt, err := common.RenderStatement(ShowValidateObjectsSQL, &gonja.Context{"KINDS": kind.kinds, "NAME": name, "CHG_MGMT_DB": cv.databaseName})
+	if err != nil {
+		return err
+	}
+	rows, err := cv.target.renderer.inspector.show(stmt)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		object := fmt.Sprintf("%s.VALIDATE.%s%s", cv.databaseName, name, signatureFromArguments(row["arguments"]))
+		stmt, err := common.RenderStatement(DropObjectIfExistsSQL, &gonja.Context{"KIND": kind.kind, "OBJECT": object})
+		if err != nil {
+			return err
+		}
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compareViewColumns the differences between the output columns of the current view and the compiled view, the
+// current view is expected. Removed columns and changed types are warnings, added columns are informational.
+func (cv *SnowflakeCompileValidator) compareViewColumns(object string, validate string) ([]objects.ValidationDifference, error) {
+	current, err := cv.describeViewColumns(object)
+	if err != nil {
+		return nil, err
+	}
+	compiled, err := cv.describeViewColumns(validate)
+	if err != nil {
+		return nil, err
+	}
+
+	differences := make([]objects.ValidationDifference, 0)
+	for _, column := range current.names {
+		subject := fmt.Sprintf("column [%s]", column)
+		if _, ok := compiled.types[column]; !ok {
+			differences = append(differences, objects.ValidationDifference{Subject: subject, Attribute: StructureColumn,
+				Expected: "present", Actual: "absent", Severity: objects.ValidationErrorWarn})
+			continue
+		}
+		if !strings.EqualFold(current.types[column], compiled.types[column]) {
+			differences = append(differences, objects.ValidationDifference{Subject: subject, Attribute: StructureDataType,
+				Expected: current.types[column], Actual: compiled.types[column], Severity: objects.ValidationErrorWarn})
+		}
+	}
+	for _, column := range compiled.names {
+		if _, ok := current.types[column]; !ok {
+			differences = append(differences, objects.ValidationDifference{Subject: fmt.Sprintf("column [%s]", column),
+				Attribute: StructureColumn, Expected: "absent", Actual: "present", Severity: objects.ValidationErrorInfo})
+		}
+	}
+	return differences, nil
+}
+
+// sfViewColumns the output columns of a view in order along with their type
+type sfViewColumns struct {
+	names []string
+	types map[string]string
+}
+
+func (cv *SnowflakeCompileValidator) describeViewColumns(object string) (*sfViewColumns, error) {
+	parts := strings.SplitN(object, ".", 3)
+	stmt, err := common.RenderStatement(DescribeSchemaObjectSQL, &gonja.Context{"KIND": "VIEW", "DATABASE": parts[0], "SCHEMA": parts[1], "NAME": parts[2]})
+	if err != nil {
+		return nil, err
+	}
+	rows, err := cv.target.renderer.inspector.show(stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := &sfViewColumns{names: make([]string, 0), types: make(map[string]string)}
+	for _, row := range rows {
+		name := strings.ToUpper(row["name"])
+		columns.names = append(columns.names, name)
+		columns.types[name] = row["type"]
+	}
+	return columns, nil
+}
@@ -1,12 +1,34 @@
 package snowflake
 
+import (
+	"Plow/plow/targets/common"
+	"strings"
+)
+
 type SnowflakeConfiguration struct {
-	PublicKeyFile     string `mapstructure:"publicKeyFile"`
-	UserId            string `mapstructure:"userId"`
-	Account           string `mapstructure:"account"`
-	Region            string `mapstructure:"region"`
-	Database          string `mapstructure:"database"`
-	Warehouse         string `mapstructure:"warehouse"`
-	Role              string `mapstructure:"role"`
-	KeyPasswordSecret string `mapstructure:"passwordSecret"`
+	Authenticator      string                                   `mapstructure:"authenticator"`
+	PrivateKeyFile     string                                   `mapstructure:"privateKeyFile"`
+	PrivateKeySecret   string                                   `mapstructure:"privateKeySecret"`
+	PublicKeyFile      string                                   `mapstructure:"publicKeyFile"` // deprecated: misnamed, use privateKeyFile
+	KeyPasswordSecret  string                                   `mapstructure:"passwordSecret"`
+	UserPasswordSecret string                                   `mapstructure:"userPasswordSecret"`
+	TokenSecret        string                                   `mapstructure:"tokenSecret"`
+	UserId             string                                   `mapstructure:"userId"`
+	Account            string                                   `mapstructure:"account"`
+	Region             string                                   `mapstructure:"region"`
+	Database           string                                   `mapstructure:"database"`
+	Warehouse          string                                   `mapstructure:"warehouse"`
+	Role               string                                   `mapstructure:"role"`
+	MonitorRole        string                                   `mapstructure:"monitorRole"`
+	Policy             string                                   `mapstructure:"policy"`
+	Validators         map[string]common.ValidatorConfiguration `mapstructure:"validators"`
+	MetadataSnapshot   common.MetadataSnapshotConfiguration     `mapstructure:"metadataSnapshot"`
+}
+
+// monitorRole the role resource monitors are managed under, the base role when not configured
+func (c *SnowflakeConfiguration) monitorRole() string {
+	if len(strings.TrimSpace(c.MonitorRole)) == 0 {
+		return c.Role
+	}
+	return c.MonitorRole
 }
@@ -0,0 +1,352 @@
+package snowflake
+
+import (
+	"Plow/plow/objects"
+	"Plow/plow/targets/common"
+	"Plow/plow/utility"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sfDrift collects the differences found for a single change item
+type sfDrift struct {
+	base    objects.DriftEntry
+	entries []objects.DriftEntry
+}
+
+func newSnowflakeDrift(target string, item *objects.ChangeItem, object string) *sfDrift {
+	return &sfDrift{base: objects.DriftEntry{Target: target, File: item.Metadata.Name, ObjectType: item.Item.Type, Object: object},
+		entries: make([]objects.DriftEntry, 0)}
+}
+
+func (d *sfDrift) add(attribute string, kind objects.DriftKind, expected string, actual string) {
+	entry := d.base
+	entry.Attribute, entry.Kind, entry.Expected, entry.Actual = attribute, kind, expected, actual
+	d.entries = append(d.entries, entry)
+}
+
+// grants reports the desired grants which are not present, and the current grants which are not desired
+func (d *sfDrift) grants(attribute string, desired []sfGrant, current []sfGrant) {
+	present := make(map[sfGrant]bool)
+	for _, grant := range current {
+		present[grant] = true
+	}
+	for _, grant := range desired {
+		if !present[grant] {
+			d.add(attribute, objects.DriftMissing, grant.String(), "")
+		}
+	}
+	for _, grant := range revokedGrants(desired, current) {
+		d.add(attribute, objects.DriftExtra, "", grant.String())
+	}
+}
+
+// DetectDrift compares the specs of the change log with the live objects of the target. Warehouse properties,
+// declarative table definitions, owners, usage, object and future grants are compared, only the attributes declared
+// by a spec are compared and specs declaring none of them are not compared. Existence is read from the catalog, the
+// metadata snapshot is not used as objects created or dropped by hand are to be reported.
+func (s *SnowflakeTarget) DetectDrift(changes *objects.ChangeLog) ([]objects.DriftEntry, error) {
+	if changes == nil {
+		return nil, common.ErrNoChangesProvided
+	}
+
+	catalog := newSnowflakeObjectExistsValidator(s, changes)
+	catalog.snapshot = nil
+	if err := catalog.Init(); err != nil {
+		return nil, err
+	}
+
+	out := make([]objects.DriftEntry, 0)
+	for _, item := range changes.Items() {
+		found, err := catalog.findObject(item)
+		if err != nil {
+			return nil, utility.WrapError(fmt.Sprintf("[%s]", item.Metadata.Name), err)
+		}
+		entries, err := s.detectItemDrift(changes.Target, item, found != nil)
+		if err != nil {
+			return nil, utility.WrapError(fmt.Sprintf("[%s]", item.Metadata.Name), err)
+		}
+		out = append(out, entries...)
+	}
+	return out, nil
+}
+
+func (s *SnowflakeTarget) detectItemDrift(target string, item *objects.ChangeItem, exists bool) ([]objects.DriftEntry, error) {
+	params := common.NewRenderContextFromObjectInfo(item.Item.Object)
+	database, _ := (*params)["DATABASE"].(string)
+	name, _ := (*params)["NAME"].(string)
+
+	switch sfType := StringToSnowflakeObjectType(item.Item.Type); sfType {
+	case Warehouse:
+		spec := &sfWarehouseSpecification{}
+		if err := utility.UnmarshalYamlSubObject(item.Item.Spec, spec); err != nil {
+			return nil, err
+		}
+		drift := newSnowflakeDrift(target, item, name)
+		if !s.driftExistence(drift, item, exists) {
+			return drift.entries, nil
+		}
+		if err := s.warehouseDrift(drift, spec, name); err != nil {
+			return nil, err
+		}
+		owner := strings.ToUpper(strings.TrimSpace(ownerRole(spec.Owner)))
+		return drift.entries, s.usageDrift(drift, "WAREHOUSE", name, owner, spec.Usage)
+	case Database:
+		spec := &sfDatabaseSpecification{}
+		if err := utility.UnmarshalYamlSubObject(item.Item.Spec, spec); err != nil {
+			return nil, err
+		}
+		drift := newSnowflakeDrift(target, item, name)
+		if !s.driftExistence(drift, item, exists) {
+			return drift.entries, nil
+		}
+		owner := strings.ToUpper(strings.TrimSpace(ownerRole(spec.Owner)))
+		if err := s.usageDrift(drift, "DATABASE", name, owner, spec.Usage); err != nil {
+			return nil, err
+		}
+		return drift.entries, s.futureGrantsDrift(drift, spec.FutureGrants, "DATABASE", name, name)
+	case Schema:
+		spec := &sfSchemaSpecification{}
+		if err := utility.UnmarshalYamlSubObject(item.Item.Spec, spec); err != nil {
+			return nil, err
+		}
+		object := fmt.Sprintf("%s.%s", database, name)
+		drift := newSnowflakeDrift(target, item, object)
+		if !s.driftExistence(drift, item, exists) {
+			return drift.entries, nil
+		}
+		owner := strings.ToUpper(strings.TrimSpace(ownerRole(spec.Owner)))
+		if err := s.usageDrift(drift, "SCHEMA", object, owner, spec.Usage); err != nil {
+			return nil, err
+		}
+		return drift.entries, s.futureGrantsDrift(drift, spec.FutureGrants, "SCHEMA", object, database)
+	case Table:
+		if isDeclarative(item) {
+			spec := &sfTableSpecification{}
+			if err := utility.UnmarshalYamlSubObject(item.Item.Spec, spec); err != nil {
+				return nil, err
+			}
+			drift := newSnowflakeDrift(target, item, qualifiedObjectName(params, ""))
+			if !s.driftExistence(drift, item, exists) {
+				return drift.entries, nil
+			}
+			if err := s.tableDrift(drift, spec, params); err != nil {
+				return nil, err
+			}
+			return drift.entries, s.objectGrantsDrift(drift, "TABLE", drift.base.Object, database, spec.Metadata.Owner, spec.Grants)
+		}
+		fallthrough
+	default:
+		kind, ok := grantableObjectKinds[sfType]
+		if !ok {
+			return nil, nil
+		}
+		spec := &sfDefaultSpecification{}
+		if err := utility.UnmarshalYamlSubObject(item.Item.Spec, spec); err != nil {
+			return nil, err
+		}
+		if spec.Grants == nil && spec.Metadata.Owner == nil {
+			return nil, nil
+		}
+		signature := ""
+		if kind == "FUNCTION" || kind == "PROCEDURE" {
+			signature = spec.Metadata.Signature
+		}
+		drift := newSnowflakeDrift(target, item, qualifiedObjectName(params, signature))
+		if !s.driftExistence(drift, item, exists) {
+			return drift.entries, nil
+		}
+		return drift.entries, s.objectGrantsDrift(drift, kind, drift.base.Object, database, spec.Metadata.Owner, spec.Grants)
+	}
+}
+
+// driftExistence reports an object declared to be dropped which exists, or an object which does not exist, returns
+// true when the attributes of the object are to be compared
+func (s *SnowflakeTarget) driftExistence(drift *sfDrift, item *objects.ChangeItem, exists bool) bool {
+	switch {
+	case item.Item.Options.Drop && exists:
+		drift.add("object", objects.DriftExtra, "", drift.base.Object)
+	case !item.Item.Options.Drop && !exists:
+		drift.add("object", objects.DriftMissing, drift.base.Object, "")
+	}
+	return exists && !item.Item.Options.Drop
+}
+
+// warehouseDrift compares the declared warehouse properties with those reported by SHOW WAREHOUSES
+func (s *SnowflakeTarget) warehouseDrift(drift *sfDrift, spec *sfWarehouseSpecification, name string) error {
+	props, err := spec.properties()
+	if err != nil {
+		return err
+	}
+	current, _, err := s.renderer.inspector.Warehouse(name)
+	if err != nil {
+		return err
+	}
+	for _, prop := range props {
+		if prop.changed(current) {
+			drift.add(strings.ToLower(prop.parameter), objects.DriftChanged, prop.desired, prop.normalize(current[prop.column]))
+		}
+	}
+	return nil
+}
+
+// tableDrift compares the declared columns, clustering key, constraints and comment with the table definition
+// reported by INFORMATION_SCHEMA, columns not declared are reported as extra
+func (s *SnowflakeTarget) tableDrift(drift *sfDrift, spec *sfTableSpecification, params *map[string]interface{}) error {
+	if err := spec.validate(); err != nil {
+		return err
+	}
+	state, exists, err := s.renderer.inspector.Table(params)
+	if err != nil || !exists {
+		return err
+	}
+	if !strings.EqualFold(state.TableType, "BASE TABLE") {
+		drift.add("tableType", objects.DriftChanged, "BASE TABLE", state.TableType)
+		return nil
+	}
+
+	current := make(map[string]sfColumnState)
+	for _, col := range state.Columns {
+		current[strings.ToUpper(col.Name)] = col
+	}
+
+	declared := make(map[string]bool)
+	for i := range spec.Columns {
+		col := &spec.Columns[i]
+		name := col.identifier()
+		declared[name] = true
+
+		existing, ok := current[name]
+		if !ok {
+			drift.add("column", objects.DriftMissing, col.definition(), "")
+			continue
+		}
+
+		desired, _ := parseColumnType(col.Type)
+		if have := columnTypeFromState(existing); compareColumnTypes(have, desired) != columnTypeSame {
+			drift.add(fmt.Sprintf("column [%s] type", name), objects.DriftChanged, desired.String(), have.String())
+		}
+		if col.nullable() != existing.Nullable {
+			drift.add(fmt.Sprintf("column [%s] nullable", name), objects.DriftChanged,
+				strconv.FormatBool(col.nullable()), strconv.FormatBool(existing.Nullable))
+		}
+		if col.Default != nil && !defaultsEqual(strings.TrimSpace(*col.Default), strings.TrimSpace(existing.Default.String)) {
+			drift.add(fmt.Sprintf("column [%s] default", name), objects.DriftChanged,
+				strings.TrimSpace(*col.Default), strings.TrimSpace(existing.Default.String))
+		}
+		if col.Comment != nil && *col.Comment != existing.Comment.String {
+			drift.add(fmt.Sprintf("column [%s] comment", name), objects.DriftChanged, *col.Comment, existing.Comment.String)
+		}
+	}
+
+	for _, col := range state.Columns {
+		if !declared[strings.ToUpper(col.Name)] {
+			drift.add("column", objects.DriftExtra, "", fmt.Sprintf("%s %s", strings.ToUpper(col.Name), columnTypeFromState(col)))
+		}
+	}
+
+	if spec.ClusterBy != nil && normalizeClusteringKey(strings.Join(spec.ClusterBy, ",")) != normalizeClusteringKey(state.ClusteringKey.String) {
+		drift.add("clusterBy", objects.DriftChanged, strings.Join(spec.ClusterBy, ", "), state.ClusteringKey.String)
+	}
+
+	for i := range spec.Constraints {
+		if _, ok := state.Constraints[spec.Constraints[i].identifier()]; !ok {
+			drift.add("constraint", objects.DriftMissing, spec.Constraints[i].definition(), "")
+		}
+	}
+
+	if spec.Comment != nil && *spec.Comment != state.Comment.String {
+		drift.add("comment", objects.DriftChanged, *spec.Comment, state.Comment.String)
+	}
+	return nil
+}
+
+// ownerDrift compares the declared owner with the role holding OWNERSHIP, no comparison is made when no owning role
+// is declared
+func (s *SnowflakeTarget) ownerDrift(drift *sfDrift, rows []map[string]string, owner string) {
+	if len(owner) == 0 {
+		return
+	}
+	current := ""
+	for _, row := range rows {
+		if strings.EqualFold(row["privilege"], "OWNERSHIP") {
+			current = strings.ToUpper(strings.ReplaceAll(row["grantee_name"], `"`, ""))
+			break
+		}
+	}
+	if current != owner {
+		drift.add("owner", objects.DriftChanged, owner, current)
+	}
+}
+
+// usageDrift compares the owner and declared usage of the database, schema or warehouse with its grants. Usage held
+// by the owner and the default change mgmt role is exempt, usage is only compared when the spec declares grants or
+// revokes of usage.
+func (s *SnowflakeTarget) usageDrift(drift *sfDrift, kind string, object string, owner string, usage sfUsageSpecification) error {
+	if len(owner) == 0 && len(usage.Grants) == 0 && len(usage.Revokes) == 0 {
+		return nil
+	}
+	rows, err := s.renderer.inspector.Grants(kind, object)
+	if err != nil {
+		return err
+	}
+	s.ownerDrift(drift, rows, owner)
+	if len(usage.Grants) == 0 && len(usage.Revokes) == 0 {
+		return nil
+	}
+
+	exempt := []string{strings.ToUpper(s.renderer.defaultRole), owner}
+	current := make([]sfGrant, 0)
+	for _, grant := range grantsFromShow(rows, true) {
+		if grant.Privilege != "USAGE" || (grant.GranteeKind == RoleGrantee && utility.Include(exempt, grant.Grantee)) {
+			continue
+		}
+		current = append(current, grant)
+	}
+	drift.grants("usage", declaredUsage(usage), current)
+	return nil
+}
+
+// objectGrantsDrift compares the owner and declared grants of a schema level object with its grants
+func (s *SnowflakeTarget) objectGrantsDrift(drift *sfDrift, kind string, object string, database string, owner *objects.ObjectDesignation, specs []sfGrantSpecification) error {
+	role := ""
+	if owner != nil {
+		role = strings.ToUpper(strings.TrimSpace(ownerRole(*owner)))
+	}
+	if len(role) == 0 && specs == nil {
+		return nil
+	}
+	rows, err := s.renderer.inspector.Grants(kind, object)
+	if err != nil {
+		return err
+	}
+	s.ownerDrift(drift, rows, role)
+	if specs == nil {
+		return nil
+	}
+
+	desired, err := expandGrants(specs, database)
+	if err != nil {
+		return err
+	}
+	drift.grants("grants", desired, grantsFromShow(rows, false))
+	return nil
+}
+
+// futureGrantsDrift compares the declared future grants with those defined within the database or schema
+func (s *SnowflakeTarget) futureGrantsDrift(drift *sfDrift, specs []sfFutureGrantSpecification, containerKind string, container string, database string) error {
+	if specs == nil {
+		return nil
+	}
+	desired, err := expandFutureGrants(specs, containerKind, database)
+	if err != nil {
+		return err
+	}
+	rows, err := s.renderer.inspector.FutureGrants(containerKind, container)
+	if err != nil {
+		return err
+	}
+	drift.grants("futureGrants", desired, futureGrantsFromShow(rows))
+	return nil
+}
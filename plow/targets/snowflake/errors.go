@@ -3,8 +3,36 @@ package snowflake
 import "errors"
 
 var (
-	ErrAllHellHasBrokenLoose    = errors.New("snowflake target critical error")
-	ErrDisallowedPrivilegedRole = errors.New("execution not approved using privileged role")
-	ErrInvalidUnapprovedCommand = errors.New("invalid or unapproved command")
-	ErrUnableSetRoleContext     = errors.New("unable to establish execution role context")
+	ErrAllHellHasBrokenLoose      = errors.New("snowflake target critical error")
+	ErrDisallowedPrivilegedRole   = errors.New("execution not approved using privileged role")
+	ErrInvalidUnapprovedCommand   = errors.New("invalid or unapproved command")
+	ErrUnableSetRoleContext       = errors.New("unable to establish execution role context")
+	ErrUnknownAuthenticator       = errors.New("unknown authenticator, expected one of [jwt, oauth, password, externalbrowser]")
+	ErrMissingConnectionSetting   = errors.New("required connection setting not configured")
+	ErrMissingPrivateKey          = errors.New("jwt authenticator requires one of [privateKeyFile, privateKeySecret]")
+	ErrAmbiguousPrivateKey        = errors.New("only one of [privateKeyFile, privateKeySecret] can be configured")
+	ErrInvalidPrivateKey          = errors.New("private key is not a PEM encoded block")
+	ErrUnsupportedPrivateKeyType  = errors.New("private key PEM type not supported, expected [PRIVATE KEY, ENCRYPTED PRIVATE KEY, RSA PRIVATE KEY]")
+	ErrMissingTokenSecret         = errors.New("oauth authenticator requires tokenSecret")
+	ErrMissingUserPasswordSecret  = errors.New("password authenticator requires userPasswordSecret")
+	ErrInvalidWarehouseProperty   = errors.New("invalid warehouse property value")
+	ErrInvalidTableSpecification  = errors.New("invalid declarative table specification")
+	ErrColumnTypeNarrowing        = errors.New("column type change narrows the existing type, set allowNarrowing to permit")
+	ErrIncompatibleColumnChange   = errors.New("column type change can not be applied in place")
+	ErrUnsupportedDefaultChange   = errors.New("column default can only be dropped or set to a sequence in place")
+	ErrInvalidGrantSpecification  = errors.New("invalid grant specification")
+	ErrInvalidRoleSpecification   = errors.New("invalid role specification")
+	ErrProtectedRoleGrant         = errors.New("roles can not be granted to a protected system role")
+	ErrRoleHierarchyCycle         = errors.New("role grants form a cycle within the role hierarchy")
+	ErrInvalidUserProperty        = errors.New("invalid user property value")
+	ErrInvalidPublicKey           = errors.New("public key is not a PEM or base64 encoded RSA public key")
+	ErrInvalidResourceMonitor     = errors.New("invalid resource monitor specification")
+	ErrInvalidPolicySpecification = errors.New("invalid policy or tag specification")
+	ErrPolicySignatureChange      = errors.New("policy signature and return type can not be altered, the policy must be dropped and recreated")
+	ErrInvalidPolicyAttachment    = errors.New("invalid policy or tag attachment")
+	ErrNotRehearsed               = errors.New("account level objects are not rehearsed")
+	ErrRehearsalCleanup           = errors.New("unable to drop rehearsal clone(s)")
+	ErrOutsideRetention           = errors.New("restore point is outside the time travel retention window")
+	ErrRollbackFailed             = errors.New("one or more objects could not be restored")
+	ErrInvalidStructurePolicy     = errors.New("invalid structure validation policy")
 )
@@ -0,0 +1,538 @@
+package snowflake
+
+import (
+	"Plow/plow/objects"
+	"Plow/plow/targets/common"
+	"Plow/plow/utility"
+	"encoding/json"
+	"fmt"
+	"github.com/noirbizarre/gonja"
+	"regexp"
+	"strings"
+)
+
+const (
+	MaskingPolicyKind   = "MASKING POLICY"
+	RowAccessPolicyKind = "ROW ACCESS POLICY"
+	TagKind             = "TAG"
+)
+
+// policyKinds the keyword of the policy type along with the plural used by SHOW
+var policyKinds = map[SnowflakeObjectType][2]string{
+	MaskingPolicy:   {MaskingPolicyKind, "MASKING POLICIES"},
+	RowAccessPolicy: {RowAccessPolicyKind, "ROW ACCESS POLICIES"},
+}
+
+// regexCreateOrReplace a statement recreating a table or view, capturing the kind and name of the object
+var regexCreateOrReplace = regexp.MustCompile(`(?is)^\s*CREATE\s+OR\s+REPLACE\s+(?:[A-Z]+\s+)*?(TABLE|VIEW)\s+(?:IF\s+NOT\s+EXISTS\s+)?([^\s(]+)`)
+
+// attachableObjectKinds the object types accepting policy and tag attachments
+var attachableObjectKinds = map[SnowflakeObjectType]string{
+	Table: "TABLE",
+	View:  "VIEW",
+}
+
+// signature the argument list of the policy, e.g. VAL STRING, REGION STRING
+func (spec *sfPolicySpecification) signature() string {
+	args := make([]string, 0)
+	for _, arg := range spec.Signature {
+		args = append(args, fmt.Sprintf("%s %s", strings.ToUpper(strings.TrimSpace(arg.Name)), strings.ToUpper(strings.TrimSpace(arg.Type))))
+	}
+	return strings.Join(args, ", ")
+}
+
+func (spec *sfPolicySpecification) validate(objType SnowflakeObjectType) error {
+	if len(spec.Signature) == 0 {
+		return utility.WrapError("policy requires a signature", ErrInvalidPolicySpecification)
+	}
+	for _, arg := range spec.Signature {
+		if len(strings.TrimSpace(arg.Name)) == 0 || len(strings.TrimSpace(arg.Type)) == 0 {
+			return utility.WrapError("policy arguments require a name and type", ErrInvalidPolicySpecification)
+		}
+	}
+	if len(strings.TrimSpace(spec.Body)) == 0 {
+		return utility.WrapError("policy requires a body", ErrInvalidPolicySpecification)
+	}
+	if len(strings.TrimSpace(spec.Returns)) == 0 {
+		if objType == RowAccessPolicy {
+			spec.Returns = "BOOLEAN"
+		} else {
+			return utility.WrapError("masking policy requires a return type", ErrInvalidPolicySpecification)
+		}
+	}
+	if objType == RowAccessPolicy && !strings.EqualFold(strings.TrimSpace(spec.Returns), "BOOLEAN") {
+		return utility.WrapError("row access policy must return BOOLEAN", ErrInvalidPolicySpecification)
+	}
+	return nil
+}
+
+// sameSignature compares the declared signature and return type with those reported by DESC, e.g. (VAL VARCHAR) and
+// VARCHAR(16777216). Types are compared in their canonical form so aliases such as STRING and VARCHAR are equal.
+func (spec *sfPolicySpecification) sameSignature(current map[string]string) bool {
+	args := splitTypeList(strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(current["signature"]), "("), ")"))
+	if len(args) != len(spec.Signature) {
+		return false
+	}
+	for i, arg := range args {
+		fields := strings.Fields(arg)
+		if len(fields) < 2 || !strings.EqualFold(fields[0], strings.TrimSpace(spec.Signature[i].Name)) {
+			return false
+		}
+		if !sameType(strings.Join(fields[1:], " "), spec.Signature[i].Type) {
+			return false
+		}
+	}
+	return sameType(current["return_type"], spec.Returns)
+}
+
+func sameType(current string, declared string) bool {
+	currentType, err := parseColumnType(current)
+	if err != nil {
+		return strings.EqualFold(strings.TrimSpace(current), strings.TrimSpace(declared))
+	}
+	declaredType, err := parseColumnType(declared)
+	if err != nil {
+		return false
+	}
+	return currentType == declaredType
+}
+
+// splitTypeList splits a comma separated list ignoring commas within parentheses, e.g. A NUMBER(38,0), B STRING
+func splitTypeList(value string) []string {
+	out := make([]string, 0)
+	depth, start := 0, 0
+	for i, c := range value {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				out = append(out, strings.TrimSpace(value[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(value[start:]); len(last) > 0 {
+		out = append(out, last)
+	}
+	return out
+}
+
+// sameBody compares policy bodies ignoring differences in whitespace
+func sameBody(current string, declared string) bool {
+	return strings.Join(strings.Fields(current), " ") == strings.Join(strings.Fields(declared), " ")
+}
+
+// commentActions the SET or UNSET COMMENT action when the declared comment differs from current
+func commentActions(comment *string, current map[string]string) []string {
+	if comment == nil || current["comment"] == *comment {
+		return nil
+	}
+	if len(*comment) == 0 {
+		return []string{"UNSET COMMENT"}
+	}
+	return []string{"SET COMMENT = " + quoteLiteral(*comment)}
+}
+
+// renderSchemaObjectSecurity renders the security scope applying the owner role of a policy or tag
+func renderSchemaObjectSecurity(metadata sfSpecMetadata) (*objects.ApplyScope, error) {
+	if metadata.Owner == nil || StringToSnowflakeObjectType(metadata.Owner.ObjectType) != Role || utility.IsStringEmpty(&metadata.Owner.Identifier) {
+		return nil, nil
+	}
+	if IsProtectedSystemRole(metadata.Owner.Identifier) {
+		return nil, ErrDisallowedPrivilegedRole
+	}
+	return common.NewScope("security", []string{generateUseRoleStmt(metadata.Owner.Identifier)}), nil
+}
+
+// renderAlterSchemaObject renders one ALTER statement per action against the policy or tag
+func renderAlterSchemaObject(kind string, actions []string, params *map[string]interface{}) ([]string, error) {
+	vars := gonja.Context(utility.DeepMapCopy(*params))
+	vars["KIND"] = kind
+	stmts := make([]string, 0)
+	for _, action := range actions {
+		vars["ACTION"] = action
+		stmt, err := common.RenderStatement(AlterSchemaObjectSQL, &vars)
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, stmt)
+	}
+	return stmts, nil
+}
+
+// renderPolicySpec creates the masking or row access policy, or updates the body and comment when they differ from
+// the current definition. The signature and return type of an existing policy can not be altered.
+func (sfr *SnowflakeRenderer) renderPolicySpec(spec *sfPolicySpecification, item *objects.ChangeItem, params *map[string]interface{}) ([]*objects.ApplyScope, error) {
+	out := make([]*objects.ApplyScope, 0)
+	objType := StringToSnowflakeObjectType(item.Item.Type)
+	kinds := policyKinds[objType]
+	vars := *params
+	vars["KIND"] = kinds[0]
+
+	if spec.Metadata.Owner != nil {
+		sfr.addOwnerToWarehouseCoordinator(*spec.Metadata.Owner)
+	}
+	security, err := renderSchemaObjectSecurity(spec.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	if security != nil {
+		out = append(out, security)
+	}
+
+	if item.Item.Options.Drop {
+		stmt, err := common.RenderStatement(DropSchemaObjectSQL, (*gonja.Context)(&vars))
+		if err != nil {
+			return nil, err
+		}
+		return append(out, common.NewScope("drop", []string{stmt})), nil
+	}
+
+	if err := spec.validate(objType); err != nil {
+		return nil, err
+	}
+	//the body may reference the render context, e.g. {{DATABASE}}.GOVERNANCE.ENTITLEMENTS
+	body, err := common.RenderStatement(strings.TrimSpace(spec.Body), (*gonja.Context)(params))
+	if err != nil {
+		return nil, err
+	}
+
+	current, exists, err := sfr.inspector.SchemaObject(kinds[0], kinds[1], true, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		vars["SIGNATURE"] = spec.signature()
+		vars["RETURNS"] = strings.ToUpper(strings.TrimSpace(spec.Returns))
+		vars["BODY"] = body
+		vars["COMMENT"] = ""
+		if spec.Comment != nil && len(*spec.Comment) > 0 {
+			vars["COMMENT"] = quoteLiteral(*spec.Comment)
+		}
+		stmt, err := common.RenderStatement(CreatePolicySQL, (*gonja.Context)(&vars))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, common.NewScope("init", []string{stmt}))
+	} else {
+		if !spec.sameSignature(current) {
+			return nil, utility.WrapError(fmt.Sprintf("[%s] %s", kinds[0], qualifiedObjectName(params, "")), ErrPolicySignatureChange)
+		}
+		actions := make([]string, 0)
+		if !sameBody(current["body"], body) {
+			actions = append(actions, "SET BODY -> "+body)
+		}
+		actions = append(actions, commentActions(spec.Comment, current)...)
+		stmts, err := renderAlterSchemaObject(kinds[0], actions, params)
+		if err != nil {
+			return nil, err
+		}
+		if len(stmts) > 0 {
+			out = append(out, common.NewScope("change", stmts))
+		}
+	}
+
+	grants, err := sfr.renderObjectGrants(spec.Grants, "", exists, item, params)
+	if err != nil {
+		return nil, err
+	}
+	if grants != nil {
+		out = append(out, grants)
+	}
+	return out, nil
+}
+
+// allowedValues the allowed values reported by SHOW TAGS, e.g. ["HIGH","LOW"], null when unrestricted
+func allowedValues(current map[string]string) []string {
+	values := make([]string, 0)
+	if raw := strings.TrimSpace(current["allowed_values"]); len(raw) > 0 && !strings.EqualFold(raw, "null") {
+		_ = json.Unmarshal([]byte(raw), &values)
+	}
+	return values
+}
+
+func quoteLiterals(values []string) string {
+	quoted := make([]string, 0)
+	for _, v := range values {
+		quoted = append(quoted, quoteLiteral(v))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// renderTagSpec creates the tag, or aligns the allowed values and comment with the current definition
+func (sfr *SnowflakeRenderer) renderTagSpec(spec *sfTagSpecification, item *objects.ChangeItem, params *map[string]interface{}) ([]*objects.ApplyScope, error) {
+	out := make([]*objects.ApplyScope, 0)
+	vars := *params
+	vars["KIND"] = TagKind
+
+	if spec.Metadata.Owner != nil {
+		sfr.addOwnerToWarehouseCoordinator(*spec.Metadata.Owner)
+	}
+	security, err := renderSchemaObjectSecurity(spec.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	if security != nil {
+		out = append(out, security)
+	}
+
+	if item.Item.Options.Drop {
+		stmt, err := common.RenderStatement(DropSchemaObjectSQL, (*gonja.Context)(&vars))
+		if err != nil {
+			return nil, err
+		}
+		return append(out, common.NewScope("drop", []string{stmt})), nil
+	}
+
+	current, exists, err := sfr.inspector.SchemaObject(TagKind, "TAGS", false, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		vars["ALLOWED_VALUES"] = quoteLiterals(spec.AllowedValues)
+		vars["COMMENT"] = ""
+		if spec.Comment != nil && len(*spec.Comment) > 0 {
+			vars["COMMENT"] = quoteLiteral(*spec.Comment)
+		}
+		stmt, err := common.RenderStatement(CreateTagSQL, (*gonja.Context)(&vars))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, common.NewScope("init", []string{stmt}))
+	} else {
+		actions := make([]string, 0)
+		if spec.AllowedValues != nil {
+			existing := allowedValues(current)
+			added := make([]string, 0)
+			for _, v := range spec.AllowedValues {
+				if !utility.Include(existing, v) {
+					added = append(added, v)
+				}
+			}
+			dropped := make([]string, 0)
+			for _, v := range existing {
+				if !utility.Include(spec.AllowedValues, v) {
+					dropped = append(dropped, v)
+				}
+			}
+			switch {
+			case len(spec.AllowedValues) == 0 && len(existing) > 0:
+				actions = append(actions, "UNSET ALLOWED_VALUES")
+			default:
+				if len(added) > 0 {
+					actions = append(actions, "ADD ALLOWED_VALUES "+quoteLiterals(added))
+				}
+				if len(dropped) > 0 {
+					actions = append(actions, "DROP ALLOWED_VALUES "+quoteLiterals(dropped))
+				}
+			}
+		}
+		actions = append(actions, commentActions(spec.Comment, current)...)
+		stmts, err := renderAlterSchemaObject(TagKind, actions, params)
+		if err != nil {
+			return nil, err
+		}
+		if len(stmts) > 0 {
+			out = append(out, common.NewScope("change", stmts))
+		}
+	}
+
+	grants, err := sfr.renderObjectGrants(spec.Grants, "", exists, item, params)
+	if err != nil {
+		return nil, err
+	}
+	if grants != nil {
+		out = append(out, grants)
+	}
+	return out, nil
+}
+
+// empty reports if the spec declares no attachments
+func (a *sfAttachmentSpecification) empty() bool {
+	return len(a.MaskingPolicies) == 0 && a.RowAccessPolicy == nil && len(a.Tags) == 0
+}
+
+// qualifyReference completes a policy or tag reference with the database and schema of the object, e.g. PII becomes
+// DEMO.GOVERNANCE.PII when declared on a table within DEMO.GOVERNANCE
+func qualifyReference(reference string, params *map[string]interface{}) string {
+	parts := strings.Split(strings.ToUpper(strings.TrimSpace(reference)), ".")
+	database, _ := (*params)["DATABASE"].(string)
+	schema, _ := (*params)["SCHEMA"].(string)
+	switch len(parts) {
+	case 1:
+		parts = append([]string{database, schema}, parts...)
+	case 2:
+		parts = append([]string{database}, parts...)
+	}
+	return strings.Join(parts, ".")
+}
+
+// referenceName the qualified policy or tag name of an INFORMATION_SCHEMA reference row
+func referenceName(row map[string]string, prefix string) string {
+	return strings.ToUpper(strings.Join([]string{row[prefix+"_db"] + row[prefix+"_database"], row[prefix+"_schema"], row[prefix+"_name"]}, "."))
+}
+
+// referenceColumns the argument columns of a row access policy reference, e.g. [ "REGION" ]
+func referenceColumns(value string) []string {
+	out := make([]string, 0)
+	for _, col := range strings.Split(strings.Trim(strings.TrimSpace(value), "[]"), ",") {
+		if col = strings.ToUpper(strings.Trim(strings.TrimSpace(col), `"`)); len(col) > 0 {
+			out = append(out, col)
+		}
+	}
+	return out
+}
+
+// replacesObject reports if the scope recreates the table or view with CREATE OR REPLACE
+func replacesObject(scope *objects.ApplyScope, kind string, params *map[string]interface{}) bool {
+	if scope == nil {
+		return false
+	}
+	name, _ := (*params)["NAME"].(string)
+	for _, command := range scope.Commands {
+		match := regexCreateOrReplace.FindStringSubmatch(command)
+		if match == nil || !strings.EqualFold(match[1], kind) {
+			continue
+		}
+		parts := strings.Split(match[2], ".")
+		if strings.EqualFold(strings.Trim(parts[len(parts)-1], `"`), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderAttachments attaches the declared masking policies, row access policy and tags to the table or view.
+// Attachments already present are detected so redeploying the spec renders no statements, attachments present on
+// the object which are not declared are left in place. When replaced is set the object is recreated by the change scope,
+// dropping its attachments, and all attachments are rendered. Returns nil when no attachments are declared.
+func (sfr *SnowflakeRenderer) renderAttachments(spec *sfAttachmentSpecification, exists bool, replaced bool, item *objects.ChangeItem, params *map[string]interface{}) (*objects.ApplyScope, error) {
+	if spec.empty() {
+		return nil, nil
+	}
+	kind, ok := attachableObjectKinds[StringToSnowflakeObjectType(item.Item.Type)]
+	if !ok {
+		return nil, utility.WrapError(fmt.Sprintf("object type [%s] does not accept policies or tags", item.Item.Type), ErrInvalidPolicyAttachment)
+	}
+	object := qualifiedObjectName(params, "")
+	database, _ := (*params)["DATABASE"].(string)
+
+	//current attachments, column level masking policies keyed by column, tags keyed by column and tag
+	masking := make(map[string]string)
+	tags := make(map[string]string)
+	var rowAccess string
+	var rowAccessOn []string
+	if exists && !replaced {
+		rows, err := sfr.inspector.References(GetPolicyReferencesSQL, database, object, kind)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			switch strings.ToUpper(row["policy_kind"]) {
+			case "MASKING_POLICY":
+				masking[strings.ToUpper(row["ref_column_name"])] = referenceName(row, "policy")
+			case "ROW_ACCESS_POLICY":
+				rowAccess = referenceName(row, "policy")
+				rowAccessOn = referenceColumns(row["ref_arg_column_names"])
+			}
+		}
+		for _, template := range []string{GetTagReferencesSQL, GetColumnTagReferencesSQL} {
+			if rows, err = sfr.inspector.References(template, database, object, kind); err != nil {
+				return nil, err
+			}
+			for _, row := range rows {
+				//inherited tags are reported at the level they are set
+				level := strings.ToUpper(row["level"])
+				column := ""
+				if level == "COLUMN" {
+					column = strings.ToUpper(row["column_name"])
+				} else if level != "TABLE" && level != "VIEW" {
+					continue
+				}
+				tags[column+" "+referenceName(row, "tag")] = row["tag_value"]
+			}
+		}
+	}
+
+	actions := make([]string, 0)
+	columns := make(map[string]bool)
+	for _, attachment := range spec.MaskingPolicies {
+		column := strings.ToUpper(strings.TrimSpace(attachment.Column))
+		if len(column) == 0 || len(strings.TrimSpace(attachment.Policy)) == 0 {
+			return nil, utility.WrapError("masking policy attachment requires a column and policy", ErrInvalidPolicyAttachment)
+		}
+		if columns[column] {
+			return nil, utility.WrapError(fmt.Sprintf("column [%s] declares more than one masking policy", column), ErrInvalidPolicyAttachment)
+		}
+		columns[column] = true
+
+		policy := qualifyReference(attachment.Policy, params)
+		switch masking[column] {
+		case policy:
+			continue
+		case "":
+			actions = append(actions, fmt.Sprintf("MODIFY COLUMN %s SET MASKING POLICY %s", column, policy))
+		default:
+			//FORCE replaces the current policy without leaving the column unprotected
+			actions = append(actions, fmt.Sprintf("MODIFY COLUMN %s SET MASKING POLICY %s FORCE", column, policy))
+		}
+	}
+
+	if spec.RowAccessPolicy != nil {
+		if len(strings.TrimSpace(spec.RowAccessPolicy.Policy)) == 0 || len(spec.RowAccessPolicy.On) == 0 {
+			return nil, utility.WrapError("row access policy attachment requires a policy and columns", ErrInvalidPolicyAttachment)
+		}
+		policy := qualifyReference(spec.RowAccessPolicy.Policy, params)
+		on := make([]string, 0)
+		for _, col := range spec.RowAccessPolicy.On {
+			on = append(on, strings.ToUpper(strings.TrimSpace(col)))
+		}
+		add := fmt.Sprintf("ADD ROW ACCESS POLICY %s ON (%s)", policy, strings.Join(on, ", "))
+		switch {
+		case len(rowAccess) == 0:
+			actions = append(actions, add)
+		case rowAccess != policy || strings.Join(rowAccessOn, ",") != strings.Join(on, ","):
+			//an object holds a single row access policy, the swap is applied within one statement
+			actions = append(actions, fmt.Sprintf("DROP ROW ACCESS POLICY %s, %s", rowAccess, add))
+		}
+	}
+
+	assigned := make(map[string]bool)
+	for _, assignment := range spec.Tags {
+		if len(strings.TrimSpace(assignment.Tag)) == 0 {
+			return nil, utility.WrapError("tag assignment requires a tag", ErrInvalidPolicyAttachment)
+		}
+		column := strings.ToUpper(strings.TrimSpace(assignment.Column))
+		tag := qualifyReference(assignment.Tag, params)
+		key := column + " " + tag
+		if assigned[key] {
+			return nil, utility.WrapError(fmt.Sprintf("tag [%s] assigned more than once", tag), ErrInvalidPolicyAttachment)
+		}
+		assigned[key] = true
+		if value, ok := tags[key]; ok && value == assignment.Value {
+			continue
+		}
+		if len(column) > 0 {
+			actions = append(actions, fmt.Sprintf("MODIFY COLUMN %s SET TAG %s = %s", column, tag, quoteLiteral(assignment.Value)))
+		} else {
+			actions = append(actions, fmt.Sprintf("SET TAG %s = %s", tag, quoteLiteral(assignment.Value)))
+		}
+	}
+
+	vars := gonja.Context{"KIND": kind, "OBJECT": object}
+	stmts := make([]string, 0)
+	for _, action := range actions {
+		vars["ACTION"] = action
+		stmt, err := common.RenderStatement(AlterObjectSQL, &vars)
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, stmt)
+	}
+	return common.NewScope("attachments", stmts), nil
+}
@@ -0,0 +1,399 @@
+package snowflake
+
+import (
+	"Plow/plow/objects"
+	"Plow/plow/targets/common"
+	"Plow/plow/utility"
+	"fmt"
+	"github.com/noirbizarre/gonja"
+	"sort"
+	"strings"
+)
+
+const (
+	RoleGrantee         = "ROLE"
+	DatabaseRoleGrantee = "DATABASE ROLE"
+	UserGrantee         = "USER"
+)
+
+// grantableObjectKinds the object types accepting a grants block and the keyword identifying the type in GRANT,
+// REVOKE and SHOW GRANTS statements
+var grantableObjectKinds = map[SnowflakeObjectType]string{
+	Table:               "TABLE",
+	View:                "VIEW",
+	Procedure:           "PROCEDURE",
+	UserDefinedFunction: "FUNCTION",
+	Stage:               "STAGE",
+	Pipe:                "PIPE",
+	Stream:              "STREAM",
+	Task:                "TASK",
+	Sequence:            "SEQUENCE",
+	Format:              "FILE FORMAT",
+	MaskingPolicy:       "MASKING POLICY",
+	RowAccessPolicy:     "ROW ACCESS POLICY",
+	Tag:                 "TAG",
+}
+
+// futureGrantObjectKinds the object kinds accepted by future grants, keyed by the normalized kind, along with the
+// value reported by SHOW FUTURE GRANTS
+var futureGrantObjectKinds = map[string]string{
+	"TABLES":             "TABLE",
+	"EXTERNAL TABLES":    "EXTERNAL_TABLE",
+	"DYNAMIC TABLES":     "DYNAMIC_TABLE",
+	"VIEWS":              "VIEW",
+	"MATERIALIZED VIEWS": "MATERIALIZED_VIEW",
+	"FUNCTIONS":          "FUNCTION",
+	"PROCEDURES":         "PROCEDURE",
+	"STAGES":             "STAGE",
+	"PIPES":              "PIPE",
+	"STREAMS":            "STREAM",
+	"TASKS":              "TASK",
+	"SEQUENCES":          "SEQUENCE",
+	"FILE FORMATS":       "FILE_FORMAT",
+	"SCHEMAS":            "SCHEMA",
+}
+
+// sfGrant a single privilege held by a grantee, ObjectKind is only set for future grants
+type sfGrant struct {
+	ObjectKind  string
+	GranteeKind string
+	Grantee     string
+	Privilege   string
+}
+
+func (g sfGrant) granteeKey() string {
+	return fmt.Sprintf("%s %s %s", g.ObjectKind, g.GranteeKind, g.Grantee)
+}
+
+func (g sfGrant) String() string {
+	if len(g.ObjectKind) > 0 {
+		return fmt.Sprintf("%s ON FUTURE %s TO %s %s", g.Privilege, g.ObjectKind, g.GranteeKind, g.Grantee)
+	}
+	return fmt.Sprintf("%s TO %s %s", g.Privilege, g.GranteeKind, g.Grantee)
+}
+
+// grantee the grantee kind and name, database roles without a database qualifier are qualified with the database
+// of the object
+func (g *sfGrantSpecification) grantee(database string) (string, string, error) {
+	role := strings.ToUpper(strings.TrimSpace(g.Role))
+	dbRole := strings.ToUpper(strings.TrimSpace(g.DatabaseRole))
+
+	switch {
+	case len(role) > 0 && len(dbRole) > 0, len(role) == 0 && len(dbRole) == 0:
+		return "", "", utility.WrapError("one of [role, databaseRole] is required", ErrInvalidGrantSpecification)
+	case len(role) > 0:
+		if IsProtectedSystemRole(role) {
+			return "", "", ErrDisallowedPrivilegedRole
+		}
+		return RoleGrantee, role, nil
+	default:
+		if !strings.Contains(dbRole, ".") {
+			if len(strings.TrimSpace(database)) == 0 {
+				return "", "", utility.WrapError(fmt.Sprintf("database role [%s] requires a database", g.DatabaseRole), ErrInvalidGrantSpecification)
+			}
+			dbRole = fmt.Sprintf("%s.%s", strings.ToUpper(strings.TrimSpace(database)), dbRole)
+		}
+		return DatabaseRoleGrantee, dbRole, nil
+	}
+}
+
+// expandGrants flattens the grants block into individual privileges, in declared order
+func expandGrants(specs []sfGrantSpecification, database string) ([]sfGrant, error) {
+	out := make([]sfGrant, 0)
+	seen := make(map[sfGrant]bool)
+	for i := range specs {
+		grants, err := specs[i].expand("", database)
+		if err != nil {
+			return nil, err
+		}
+		for _, grant := range grants {
+			if !seen[grant] {
+				seen[grant] = true
+				out = append(out, grant)
+			}
+		}
+	}
+	return out, nil
+}
+
+// expandFutureGrants flattens the future grants block into individual privileges, in declared order
+func expandFutureGrants(specs []sfFutureGrantSpecification, containerKind string, database string) ([]sfGrant, error) {
+	out := make([]sfGrant, 0)
+	seen := make(map[sfGrant]bool)
+	for i := range specs {
+		objectKind, err := specs[i].objectKind()
+		if err != nil {
+			return nil, err
+		}
+		if objectKind == "SCHEMAS" && containerKind != "DATABASE" {
+			return nil, utility.WrapError("future grants on schemas can only be declared on a database", ErrInvalidGrantSpecification)
+		}
+		grants, err := specs[i].expand(objectKind, database)
+		if err != nil {
+			return nil, err
+		}
+		for _, grant := range grants {
+			if !seen[grant] {
+				seen[grant] = true
+				out = append(out, grant)
+			}
+		}
+	}
+	return out, nil
+}
+
+// objectKind the normalized plural object kind, singular and plural forms are accepted, e.g. table, tables, file_format
+func (f *sfFutureGrantSpecification) objectKind() (string, error) {
+	kind := strings.Join(strings.Fields(strings.ToUpper(strings.ReplaceAll(f.ObjectKind, "_", " "))), " ")
+	if !strings.HasSuffix(kind, "S") {
+		kind = kind + "S"
+	}
+	if _, ok := futureGrantObjectKinds[kind]; !ok {
+		return "", utility.WrapError(fmt.Sprintf("future grant object kind [%s]", f.ObjectKind), ErrInvalidGrantSpecification)
+	}
+	return kind, nil
+}
+
+// expand the individual privileges of the grant
+func (g *sfGrantSpecification) expand(objectKind string, database string) ([]sfGrant, error) {
+	out := make([]sfGrant, 0)
+	kind, grantee, err := g.grantee(database)
+	if err != nil {
+		return nil, err
+	}
+	if len(g.Privileges) == 0 {
+		return nil, utility.WrapError(fmt.Sprintf("grant to [%s] lists no privileges", grantee), ErrInvalidGrantSpecification)
+	}
+	for _, privilege := range g.Privileges {
+		privilege = strings.Join(strings.Fields(strings.ToUpper(privilege)), " ")
+		//privileges are reconciled individually against SHOW GRANTS, which never reports ALL
+		if privilege == "ALL" || privilege == "ALL PRIVILEGES" || privilege == "OWNERSHIP" {
+			return nil, utility.WrapError(fmt.Sprintf("privilege [%s] can not be declared", privilege), ErrInvalidGrantSpecification)
+		}
+		out = append(out, sfGrant{ObjectKind: objectKind, GranteeKind: kind, Grantee: grantee, Privilege: privilege})
+	}
+	return out, nil
+}
+
+// grantsFromShow the role and database role grants reported by SHOW GRANTS, ownership and grants held by
+// protected system roles are excluded as they are never managed. Grants held by users are included when requested,
+// user names are case-sensitive and returned as reported.
+func grantsFromShow(rows []map[string]string, users bool) []sfGrant {
+	out := make([]sfGrant, 0)
+	for _, row := range rows {
+		privilege := strings.ToUpper(row["privilege"])
+		if privilege == "OWNERSHIP" {
+			continue
+		}
+		grantee := strings.ToUpper(strings.ReplaceAll(row["grantee_name"], `"`, ""))
+		var kind string
+		switch strings.ToUpper(row["granted_to"]) {
+		case "ROLE":
+			kind = RoleGrantee
+		case "DATABASE_ROLE":
+			kind = DatabaseRoleGrantee
+		case "USER":
+			if !users {
+				continue
+			}
+			kind = UserGrantee
+			grantee = row["grantee_name"]
+		default:
+			continue
+		}
+		if kind == RoleGrantee && IsProtectedSystemRole(grantee) {
+			continue
+		}
+		out = append(out, sfGrant{GranteeKind: kind, Grantee: grantee, Privilege: privilege})
+	}
+	return out
+}
+
+// qualifiedObjectName fully qualified name of the object, functions and procedures include their signature
+func qualifiedObjectName(params *map[string]interface{}, signature string) string {
+	parts := make([]string, 0)
+	for _, key := range []string{"DATABASE", "SCHEMA", "NAME"} {
+		if val, ok := (*params)[key].(string); ok && len(val) > 0 {
+			parts = append(parts, val)
+		}
+	}
+	return strings.Join(parts, ".") + strings.ToUpper(strings.Join(strings.Fields(signature), ""))
+}
+
+// renderObjectGrants reconciles the declared grants with the grants present on the object. Declared grants are
+// always issued, GRANT is idempotent and replacing an object in the change scope drops its grants, grants present on
+// the object which are not declared are revoked. Returns nil when the spec does not declare a grants block.
+func (sfr *SnowflakeRenderer) renderObjectGrants(specs []sfGrantSpecification, signature string, exists bool, item *objects.ChangeItem, params *map[string]interface{}) (*objects.ApplyScope, error) {
+	if specs == nil {
+		return nil, nil
+	}
+
+	kind, ok := grantableObjectKinds[StringToSnowflakeObjectType(item.Item.Type)]
+	if !ok {
+		return nil, utility.WrapError(fmt.Sprintf("object type [%s] does not accept grants", item.Item.Type), ErrInvalidGrantSpecification)
+	}
+	if kind != "FUNCTION" && kind != "PROCEDURE" {
+		signature = ""
+	}
+	object := qualifiedObjectName(params, signature)
+	database, _ := (*params)["DATABASE"].(string)
+
+	desired, err := expandGrants(specs, database)
+	if err != nil {
+		return nil, err
+	}
+
+	//current grants can only be read when the object exists prior to the change
+	current := make([]sfGrant, 0)
+	if exists {
+		rows, err := sfr.inspector.Grants(kind, object)
+		if err != nil {
+			return nil, err
+		}
+		current = grantsFromShow(rows, false)
+	}
+
+	context := gonja.Context{"KIND": kind, "OBJECT": object}
+	stmts := make([]string, 0)
+	grantStmts, err := renderGrantStatements(GrantPrivilegesSQL, context, desired)
+	if err != nil {
+		return nil, err
+	}
+	stmts = append(stmts, grantStmts...)
+
+	revokeStmts, err := renderGrantStatements(RevokePrivilegesSQL, context, revokedGrants(desired, current))
+	if err != nil {
+		return nil, err
+	}
+	stmts = append(stmts, revokeStmts...)
+
+	return common.NewScope("grants", stmts), nil
+}
+
+// renderGrantStatements renders one statement per grantee listing the grantee privileges, the context provides the
+// object or container placeholders of the template. Future grants are also grouped by object kind.
+func renderGrantStatements(template string, context gonja.Context, grants []sfGrant) ([]string, error) {
+	order := make([]string, 0)
+	grouped := make(map[string][]sfGrant)
+	for _, grant := range grants {
+		key := grant.granteeKey()
+		if _, ok := grouped[key]; !ok {
+			order = append(order, key)
+		}
+		grouped[key] = append(grouped[key], grant)
+	}
+
+	stmts := make([]string, 0)
+	for _, key := range order {
+		privileges := make([]string, 0)
+		for _, grant := range grouped[key] {
+			privileges = append(privileges, grant.Privilege)
+		}
+		vars := gonja.Context(utility.DeepMapCopy(context))
+		vars["PRIVILEGES"] = strings.Join(privileges, ", ")
+		vars["GRANTEE_KIND"] = grouped[key][0].GranteeKind
+		vars["GRANTEE"] = grouped[key][0].Grantee
+		if grouped[key][0].GranteeKind == UserGrantee {
+			vars["GRANTEE"] = fmt.Sprintf("\"%s\"", grouped[key][0].Grantee)
+		}
+		if len(grouped[key][0].ObjectKind) > 0 {
+			vars["KIND"] = grouped[key][0].ObjectKind
+		}
+		stmt, err := common.RenderStatement(template, &vars)
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, stmt)
+	}
+	return stmts, nil
+}
+
+// revokedGrants the current grants which are not declared, ordered by grantee
+func revokedGrants(desired []sfGrant, current []sfGrant) []sfGrant {
+	declared := make(map[sfGrant]bool)
+	for _, grant := range desired {
+		declared[grant] = true
+	}
+	revokes := make([]sfGrant, 0)
+	for _, grant := range current {
+		if !declared[grant] {
+			revokes = append(revokes, grant)
+		}
+	}
+	sort.SliceStable(revokes, func(i, j int) bool { return revokes[i].granteeKey() < revokes[j].granteeKey() })
+	return revokes
+}
+
+// futureGrantsFromShow the role and database role future grants reported by SHOW FUTURE GRANTS, grants held by
+// protected system roles and ownership are excluded as they are never managed
+func futureGrantsFromShow(rows []map[string]string) []sfGrant {
+	kinds := make(map[string]string)
+	for kind, shown := range futureGrantObjectKinds {
+		kinds[shown] = kind
+	}
+
+	out := make([]sfGrant, 0)
+	for _, row := range rows {
+		privilege := strings.ToUpper(row["privilege"])
+		objectKind, ok := kinds[strings.ToUpper(row["grant_on"])]
+		if privilege == "OWNERSHIP" || !ok {
+			continue
+		}
+		var kind string
+		switch strings.ToUpper(row["grant_to"]) {
+		case "ROLE":
+			kind = RoleGrantee
+		case "DATABASE_ROLE":
+			kind = DatabaseRoleGrantee
+		default:
+			continue
+		}
+		grantee := strings.ToUpper(strings.ReplaceAll(row["grantee_name"], `"`, ""))
+		if kind == RoleGrantee && IsProtectedSystemRole(grantee) {
+			continue
+		}
+		out = append(out, sfGrant{ObjectKind: objectKind, GranteeKind: kind, Grantee: grantee, Privilege: privilege})
+	}
+	return out
+}
+
+// renderFutureGrants reconciles the declared future grants of a database or schema with the future grants defined
+// within it, following the same rules as object grants. Future grants require the MANAGE GRANTS privilege and are
+// rendered under SECURITYADMIN. Returns nil when the spec does not declare a future grants block.
+func (sfr *SnowflakeRenderer) renderFutureGrants(specs []sfFutureGrantSpecification, containerKind string, container string, database string, exists bool) (*objects.ApplyScope, error) {
+	if specs == nil {
+		return nil, nil
+	}
+
+	desired, err := expandFutureGrants(specs, containerKind, database)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make([]sfGrant, 0)
+	if exists {
+		rows, err := sfr.inspector.FutureGrants(containerKind, container)
+		if err != nil {
+			return nil, err
+		}
+		current = futureGrantsFromShow(rows)
+	}
+
+	context := gonja.Context{"CONTAINER_KIND": containerKind, "CONTAINER": container}
+	stmts := []string{generateUseRoleStmt(string(SECURITYADMIN))}
+
+	grantStmts, err := renderGrantStatements(GrantFutureSQL, context, desired)
+	if err != nil {
+		return nil, err
+	}
+	stmts = append(stmts, grantStmts...)
+
+	revokeStmts, err := renderGrantStatements(RevokeFutureSQL, context, revokedGrants(desired, current))
+	if err != nil {
+		return nil, err
+	}
+	stmts = append(stmts, revokeStmts...)
+	stmts = append(stmts, generateUseRoleStmt(sfr.defaultRole))
+
+	return common.NewScope("future grants", stmts), nil
+}
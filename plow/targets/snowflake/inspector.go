@@ -0,0 +1,472 @@
+package snowflake
+
+import (
+	"Plow/plow/targets/common"
+	"Plow/plow/utility"
+	"database/sql"
+	"fmt"
+	"github.com/noirbizarre/gonja"
+	"strings"
+)
+
+// SnowflakeInspector reads the current state of account objects, used by the renderer to reconcile declarative
+// specifications with the target. Results are cached for the life of the inspector.
+type SnowflakeInspector struct {
+	db         *sql.DB
+	warehouses map[string]map[string]string
+	tables     map[string]*sfTableState
+	grants     map[string][]map[string]string
+	future     map[string][]map[string]string
+	roles      map[string][]map[string]string
+	users      map[string]map[string]string
+	monitors   map[string]map[string]string
+	schemaObjs map[string]map[string]string
+	references map[string][]map[string]string
+	databases  map[string]map[string]string
+}
+
+type sfColumnState struct {
+	Name      string
+	DataType  string
+	Nullable  bool
+	Default   sql.NullString
+	Comment   sql.NullString
+	Length    sql.NullInt64
+	Precision sql.NullInt64
+	Scale     sql.NullInt64
+}
+
+type sfTableState struct {
+	TableType     string
+	ClusteringKey sql.NullString
+	Comment       sql.NullString
+	Columns       []sfColumnState
+	Constraints   map[string]string
+}
+
+func newSnowflakeInspector(db *sql.DB) *SnowflakeInspector {
+	return &SnowflakeInspector{db: db,
+		warehouses: make(map[string]map[string]string),
+		tables:     make(map[string]*sfTableState),
+		grants:     make(map[string][]map[string]string),
+		future:     make(map[string][]map[string]string),
+		roles:      make(map[string][]map[string]string),
+		users:      make(map[string]map[string]string),
+		monitors:   make(map[string]map[string]string),
+		schemaObjs: make(map[string]map[string]string),
+		references: make(map[string][]map[string]string),
+		databases:  make(map[string]map[string]string)}
+}
+
+// Warehouse properties of the named warehouse as reported by SHOW WAREHOUSES keyed by lower case column name,
+// the second value is false when the warehouse does not exist or is not visible to the active role
+func (si *SnowflakeInspector) Warehouse(name string) (map[string]string, bool, error) {
+	key := strings.ToUpper(strings.TrimSpace(name))
+	if props, ok := si.warehouses[key]; ok {
+		return props, props != nil, nil
+	}
+
+	stmt, err := common.RenderStatement(ShowWarehousesSQL, &gonja.Context{"NAME": key})
+	if err != nil {
+		return nil, false, err
+	}
+	rows, err := si.show(stmt)
+	if err != nil {
+		return nil, false, err
+	}
+
+	//LIKE is a case-insensitive pattern match, underscores are wildcards so confirm the exact name
+	var found map[string]string
+	for _, row := range rows {
+		if strings.EqualFold(row["name"], key) {
+			found = row
+			break
+		}
+	}
+	si.warehouses[key] = found
+	return found, found != nil, nil
+}
+
+// Database properties of the named database as reported by SHOW DATABASES keyed by lower case column name, the
+// second value is false when the database does not exist or is not visible to the active role
+func (si *SnowflakeInspector) Database(name string) (map[string]string, bool, error) {
+	key := strings.ToUpper(strings.TrimSpace(name))
+	if props, ok := si.databases[key]; ok {
+		return props, props != nil, nil
+	}
+
+	stmt, err := common.RenderStatement(ShowDatabasesSQL, &gonja.Context{"NAME": key})
+	if err != nil {
+		return nil, false, err
+	}
+	rows, err := si.show(stmt)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var found map[string]string
+	for _, row := range rows {
+		if strings.EqualFold(row["name"], key) {
+			found = row
+			break
+		}
+	}
+	si.databases[key] = found
+	return found, found != nil, nil
+}
+
+// ResourceMonitor properties of the named monitor as reported by SHOW RESOURCE MONITORS keyed by lower case column
+// name, the second value is false when the monitor does not exist or is not visible to the active role
+func (si *SnowflakeInspector) ResourceMonitor(name string) (map[string]string, bool, error) {
+	key := strings.ToUpper(strings.TrimSpace(name))
+	if props, ok := si.monitors[key]; ok {
+		return props, props != nil, nil
+	}
+
+	stmt, err := common.RenderStatement(ShowResourceMonitorsSQL, &gonja.Context{"NAME": key})
+	if err != nil {
+		return nil, false, err
+	}
+	rows, err := si.show(stmt)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var found map[string]string
+	for _, row := range rows {
+		if strings.EqualFold(row["name"], key) {
+			found = row
+			break
+		}
+	}
+	si.monitors[key] = found
+	return found, found != nil, nil
+}
+
+// SchemaObject properties of the schema level object, e.g. a tag or policy, as reported by SHOW <kinds> keyed by lower
+// case column name. When describe is set the properties reported by DESC <kind> are overlaid, e.g. signature and body
+// of a policy. The second value is false when the object does not exist or is not visible to the active role.
+func (si *SnowflakeInspector) SchemaObject(kind string, kinds string, describe bool, params *map[string]interface{}) (map[string]string, bool, error) {
+	ctx := gonja.Context(utility.DeepMapCopy(*params))
+	ctx["KIND"] = kind
+	ctx["KINDS"] = kinds
+	key := fmt.Sprintf("%s %v.%v.%v", kind, ctx["DATABASE"], ctx["SCHEMA"], ctx["NAME"])
+	if props, ok := si.schemaObjs[key]; ok {
+		return props, props != nil, nil
+	}
+
+	stmt, err := common.RenderStatement(ShowSchemaObjectsSQL, &ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	rows, err := si.show(stmt)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var found map[string]string
+	for _, row := range rows {
+		if strings.EqualFold(row["name"], fmt.Sprintf("%v", ctx["NAME"])) {
+			found = row
+			break
+		}
+	}
+	if found != nil && describe {
+		if stmt, err = common.RenderStatement(DescribeSchemaObjectSQL, &ctx); err != nil {
+			return nil, false, err
+		}
+		if rows, err = si.show(stmt); err != nil {
+			return nil, false, err
+		}
+		for _, row := range rows {
+			for col, val := range row {
+				found[col] = val
+			}
+		}
+	}
+	si.schemaObjs[key] = found
+	return found, found != nil, nil
+}
+
+// References the policy or tag references of the table or view as reported by the INFORMATION_SCHEMA table function
+// rendered by template, keyed by lower case column name. The domain is the kind of the object, e.g. TABLE or VIEW.
+func (si *SnowflakeInspector) References(template string, database string, object string, domain string) ([]map[string]string, error) {
+	stmt, err := common.RenderStatement(template, &gonja.Context{"DATABASE": database, "OBJECT": object, "DOMAIN": domain})
+	if err != nil {
+		return nil, err
+	}
+	if rows, ok := si.references[stmt]; ok {
+		return rows, nil
+	}
+	rows, err := si.show(stmt)
+	if err != nil {
+		return nil, err
+	}
+	si.references[stmt] = rows
+	return rows, nil
+}
+
+// User properties of the named user as reported by SHOW USERS, overlaid with the properties reported by DESC USER
+// keyed by lower case property name, e.g. rsa_public_key_fp. The second value is false when the user does not exist or
+// is not visible to the active role
+func (si *SnowflakeInspector) User(name string) (map[string]string, bool, error) {
+	key := strings.ToUpper(strings.TrimSpace(name))
+	if props, ok := si.users[key]; ok {
+		return props, props != nil, nil
+	}
+
+	stmt, err := common.RenderStatement(ShowUsersSQL, &gonja.Context{"NAME": key})
+	if err != nil {
+		return nil, false, err
+	}
+	rows, err := si.show(stmt)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var found map[string]string
+	for _, row := range rows {
+		if strings.EqualFold(row["name"], key) {
+			found = row
+			break
+		}
+	}
+	if found == nil {
+		si.users[key] = nil
+		return nil, false, nil
+	}
+
+	//key fingerprints are only reported by DESC USER
+	if stmt, err = common.RenderStatement(DescribeUserSQL, &gonja.Context{"NAME": key}); err != nil {
+		return nil, false, err
+	}
+	if rows, err = si.show(stmt); err != nil {
+		return nil, false, err
+	}
+	for _, row := range rows {
+		found[strings.ToLower(row["property"])] = row["value"]
+	}
+	si.users[key] = found
+	return found, true, nil
+}
+
+// Table the current definition of the table as reported by the database INFORMATION_SCHEMA, the second value is
+// false when the table does not exist or is not visible to the active role
+func (si *SnowflakeInspector) Table(params *map[string]interface{}) (*sfTableState, bool, error) {
+	ctx := gonja.Context(*params)
+	key := fmt.Sprintf("%v.%v.%v", ctx["DATABASE"], ctx["SCHEMA"], ctx["NAME"])
+	if state, ok := si.tables[key]; ok {
+		return state, state != nil, nil
+	}
+
+	stmt, err := common.RenderStatement(GetTableStateSQL, &ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	rows, err := si.db.Query(stmt)
+	if err != nil {
+		return nil, false, err
+	}
+	var state *sfTableState
+	if rows.Next() {
+		state = &sfTableState{Constraints: make(map[string]string)}
+		err = rows.Scan(&state.TableType, &state.ClusteringKey, &state.Comment)
+	}
+	rows.Close()
+	if err != nil {
+		return nil, false, err
+	}
+	if state == nil {
+		si.tables[key] = nil
+		return nil, false, nil
+	}
+
+	if stmt, err = common.RenderStatement(GetTableColumnsSQL, &ctx); err != nil {
+		return nil, false, err
+	}
+	if rows, err = si.db.Query(stmt); err != nil {
+		return nil, false, err
+	}
+	for rows.Next() {
+		var col sfColumnState
+		var nullable string
+		if err = rows.Scan(&col.Name, &col.DataType, &nullable, &col.Default, &col.Comment,
+			&col.Length, &col.Precision, &col.Scale); err != nil {
+			rows.Close()
+			return nil, false, err
+		}
+		col.Nullable = strings.EqualFold(nullable, "YES")
+		state.Columns = append(state.Columns, col)
+	}
+	rows.Close()
+
+	if stmt, err = common.RenderStatement(GetTableConstraintsSQL, &ctx); err != nil {
+		return nil, false, err
+	}
+	if rows, err = si.db.Query(stmt); err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name, kind string
+		if err = rows.Scan(&name, &kind); err != nil {
+			return nil, false, err
+		}
+		state.Constraints[strings.ToUpper(name)] = strings.ToUpper(kind)
+	}
+
+	si.tables[key] = state
+	return state, true, rows.Err()
+}
+
+// Grants the grants on the object as reported by SHOW GRANTS ON
+func (si *SnowflakeInspector) Grants(kind string, object string) ([]map[string]string, error) {
+	key := fmt.Sprintf("%s %s", kind, object)
+	if rows, ok := si.grants[key]; ok {
+		return rows, nil
+	}
+
+	stmt, err := common.RenderStatement(ShowGrantsOnSQL, &gonja.Context{"KIND": kind, "OBJECT": object})
+	if err != nil {
+		return nil, err
+	}
+	rows, err := si.show(stmt)
+	if err != nil {
+		return nil, err
+	}
+	si.grants[key] = rows
+	return rows, nil
+}
+
+// FutureGrants the future grants defined within the database or schema as reported by SHOW FUTURE GRANTS IN
+func (si *SnowflakeInspector) FutureGrants(containerKind string, container string) ([]map[string]string, error) {
+	key := fmt.Sprintf("%s %s", containerKind, container)
+	if rows, ok := si.future[key]; ok {
+		return rows, nil
+	}
+
+	stmt, err := common.RenderStatement(ShowFutureGrantsSQL, &gonja.Context{"CONTAINER_KIND": containerKind, "CONTAINER": container})
+	if err != nil {
+		return nil, err
+	}
+	rows, err := si.show(stmt)
+	if err != nil {
+		return nil, err
+	}
+	si.future[key] = rows
+	return rows, nil
+}
+
+// RoleHolders the roles and users the role is granted to as reported by SHOW GRANTS OF ROLE, the second value is
+// false when the role does not exist or is not visible to the active role
+func (si *SnowflakeInspector) RoleHolders(role string) ([]map[string]string, bool, error) {
+	key := strings.ToUpper(strings.TrimSpace(role))
+	if rows, ok := si.roles[key]; ok {
+		return rows, rows != nil, nil
+	}
+
+	stmt, err := common.RenderStatement(ShowRolesSQL, &gonja.Context{"ROLE": key})
+	if err != nil {
+		return nil, false, err
+	}
+	rows, err := si.show(stmt)
+	if err != nil {
+		return nil, false, err
+	}
+	exists := false
+	for _, row := range rows {
+		if strings.EqualFold(row["name"], key) {
+			exists = true
+			break
+		}
+	}
+	if !exists {
+		si.roles[key] = nil
+		return nil, false, nil
+	}
+
+	if stmt, err = common.RenderStatement(ShowGrantsOfRoleSQL, &gonja.Context{"ROLE": key}); err != nil {
+		return nil, false, err
+	}
+	if rows, err = si.show(stmt); err != nil {
+		return nil, false, err
+	}
+	si.roles[key] = rows
+	return rows, true, nil
+}
+
+// show executes a SHOW command returning each row as a map of lower case column name to string value, null
+// values are returned as empty strings
+func (si *SnowflakeInspector) show(stmt string) ([]map[string]string, error) {
+	rows, err := si.db.Query(stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]map[string]string, 0)
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]string)
+		for i, col := range columns {
+			row[strings.ToLower(col)] = values[i].String
+		}
+		out = append(out, row)
+	}
+	return out, rows.Err()
+}
+
+// DatabaseRoleHolders the roles and database roles the database role is granted to as reported by SHOW GRANTS OF
+// DATABASE ROLE, the second value is false when the database role does not exist or is not visible to the active role
+func (si *SnowflakeInspector) DatabaseRoleHolders(role string) ([]map[string]string, bool, error) {
+	key := strings.ToUpper(strings.TrimSpace(role))
+	if rows, ok := si.roles[key]; ok {
+		return rows, rows != nil, nil
+	}
+
+	parts := strings.SplitN(key, ".", 2)
+	if len(parts) != 2 {
+		return nil, false, utility.WrapError(key, ErrInvalidRoleSpecification)
+	}
+	stmt, err := common.RenderStatement(ShowDatabaseRolesSQL, &gonja.Context{"DATABASE": parts[0]})
+	if err != nil {
+		return nil, false, err
+	}
+	rows, err := si.show(stmt)
+	if err != nil {
+		return nil, false, err
+	}
+	exists := false
+	for _, row := range rows {
+		if strings.EqualFold(row["name"], parts[1]) {
+			exists = true
+			break
+		}
+	}
+	if !exists {
+		si.roles[key] = nil
+		return nil, false, nil
+	}
+
+	if stmt, err = common.RenderStatement(ShowGrantsOfDatabaseRoleSQL, &gonja.Context{"ROLE": key}); err != nil {
+		return nil, false, err
+	}
+	if rows, err = si.show(stmt); err != nil {
+		return nil, false, err
+	}
+	si.roles[key] = rows
+	return rows, true, nil
+}
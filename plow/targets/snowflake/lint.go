@@ -0,0 +1,132 @@
+package snowflake
+
+import (
+	"Plow/plow/objects"
+	"Plow/plow/targets/common"
+	"fmt"
+	"strings"
+)
+
+// lintPlaceholders the placeholders provided when spec scopes are rendered
+var lintPlaceholders = []string{"NAME", "DATABASE", "SCHEMA"}
+
+// Lint checks the spec without a connection to the target, in addition to the conformance check against the spec
+// schema. Object types not processed by the target, change scopes without an init scope, protected owner roles, USE
+// ROLE commands and placeholders which are not provided when rendered are reported.
+func Lint(doc *common.LintDocument) {
+	spec := doc.Spec
+	sfType := StringToSnowflakeObjectType(spec.Type)
+	//unknown types are reported by the conformance check against the spec schema
+	if !isProcessedType(sfType) {
+		if sfType != UnknownType {
+			doc.Add(doc.Line("type"), objects.ValidationErrorWarn, common.LintRuleUnknownType,
+				fmt.Sprintf("object type [%s] is not processed by the snowflake target, the spec is ignored", spec.Type))
+		}
+		return
+	}
+
+	declarative := strings.EqualFold(strings.TrimSpace(spec.DefinitionStyle), DeclarativeDefinitionStyle)
+	if declarative && sfType != Table {
+		doc.Add(doc.Line("definitionStyle"), objects.ValidationErrorWarn, common.LintRuleDefinitionStyle,
+			fmt.Sprintf("declarative definitions apply to tables only, [%s] is rendered as a snowflake definition", spec.Type))
+	}
+
+	switch sfType {
+	case Role:
+		var role sfRoleSpecification
+		if doc.CheckSchema(&role, "spec") {
+			for i, dbRole := range role.DatabaseRoles {
+				if IsProtectedSystemRole(dbRole.Owner) {
+					lintProtectedRole(doc, dbRole.Owner, "spec", "databaseRoles", fmt.Sprint(i), "owner")
+				}
+			}
+		}
+	case Database:
+		var database sfDatabaseSpecification
+		if doc.CheckSchema(&database, "spec") {
+			lintOwner(doc, &database.Owner, "spec", "owner")
+		}
+	case Schema:
+		var schema sfSchemaSpecification
+		if doc.CheckSchema(&schema, "spec") {
+			lintOwner(doc, &schema.Owner, "spec", "owner")
+		}
+	case Warehouse:
+		var warehouse sfWarehouseSpecification
+		if doc.CheckSchema(&warehouse, "spec") {
+			lintOwner(doc, &warehouse.Owner, "spec", "owner")
+		}
+	case User:
+		var user sfUserSpecification
+		if doc.CheckSchema(&user, "spec") {
+			lintOwner(doc, &user.Owner, "spec", "owner")
+		}
+	case ResourceMonitor:
+		//resource monitors are checked by the conformance check alone
+	case Tag:
+		var tag sfTagSpecification
+		if doc.CheckSchema(&tag, "spec") {
+			lintOwner(doc, tag.Metadata.Owner, "spec", "meta", "owner")
+		}
+	case MaskingPolicy, RowAccessPolicy:
+		var policy sfPolicySpecification
+		if doc.CheckSchema(&policy, "spec") {
+			lintOwner(doc, policy.Metadata.Owner, "spec", "meta", "owner")
+			doc.CheckTemplate(lintPlaceholders, "spec", "body")
+		}
+	default:
+		if sfType == Table && declarative {
+			var table sfTableSpecification
+			if doc.CheckSchema(&table, "spec") {
+				lintOwner(doc, table.Metadata.Owner, "spec", "meta", "owner")
+				lintScopes(doc, "pre", "post")
+			}
+			return
+		}
+
+		var def sfDefaultSpecification
+		if doc.CheckSchema(&def, "spec") {
+			lintOwner(doc, def.Metadata.Owner, "spec", "meta", "owner")
+			doc.CheckInitPresent(def.Init, def.Change, "spec")
+			lintScopes(doc, "pre", "init", "change", "post")
+		}
+	}
+}
+
+// isProcessedType object types applied by the target, items of other types are never applied
+func isProcessedType(sfType SnowflakeObjectType) bool {
+	for _, processed := range SnowflakeProcessingOrder {
+		if processed == sfType {
+			return true
+		}
+	}
+	return false
+}
+
+// lintOwner reports an owner which is a protected system role, such owners are rejected when rendered
+func lintOwner(doc *common.LintDocument, owner *objects.ObjectDesignation, path ...string) {
+	if owner == nil || StringToSnowflakeObjectType(owner.ObjectType) != Role {
+		return
+	}
+	if IsProtectedSystemRole(owner.Identifier) {
+		lintProtectedRole(doc, owner.Identifier, append(path, "id")...)
+	}
+}
+
+func lintProtectedRole(doc *common.LintDocument, role string, path ...string) {
+	doc.Add(doc.Line(path...), objects.ValidationErrorCritical, common.LintRuleProtectedRole,
+		fmt.Sprintf("[%s] is a protected system role and can not own objects managed by plow", strings.ToUpper(strings.TrimSpace(role))))
+}
+
+// lintScopes checks the templates of the scopes and reports USE ROLE commands, which are rejected when rendered
+func lintScopes(doc *common.LintDocument, scopes ...string) {
+	for _, scope := range scopes {
+		doc.CheckTemplate(lintPlaceholders, "spec", scope)
+		doc.EachLine(func(line int, text string) {
+			if regexUseRoleCommand.MatchString(text) {
+				doc.Add(line, objects.ValidationErrorCritical, common.LintRuleUseRole,
+					fmt.Sprintf("[%s] USE ROLE is not permitted within scopes, the role is set from meta.owner", scope))
+			}
+		}, "spec", scope)
+	}
+}
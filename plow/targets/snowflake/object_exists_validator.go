@@ -3,49 +3,128 @@ package snowflake
 import (
 	"Plow/plow/objects"
 	"Plow/plow/targets/common"
+	"Plow/plow/utility"
 	"database/sql"
+	"fmt"
 	"github.com/noirbizarre/gonja"
 	"strings"
 )
 
+// schemaObjectKinds the SHOW command kinds listing objects of the type within a database
+var schemaObjectKinds = map[SnowflakeObjectType]string{
+	Procedure:           "PROCEDURES",
+	UserDefinedFunction: "USER FUNCTIONS",
+	Stage:               "STAGES",
+	Pipe:                "PIPES",
+	Stream:              "STREAMS",
+	Task:                "TASKS",
+	Sequence:            "SEQUENCES",
+	Format:              "FILE FORMATS",
+	MaskingPolicy:       "MASKING POLICIES",
+	RowAccessPolicy:     "ROW ACCESS POLICIES",
+	Tag:                 "TAGS",
+}
+
+// accountObjectKinds the SHOW command kinds listing account level objects of the type, these are not contained by a
+// database
+var accountObjectKinds = map[SnowflakeObjectType]string{
+	Warehouse:       "WAREHOUSES",
+	Role:            "ROLES",
+	User:            "USERS",
+	ResourceMonitor: "RESOURCE MONITORS",
+}
+
 type SnowflakeObjectExistsValidator struct {
 	meta        *common.Metadata
 	db          *sql.DB
 	target      *SnowflakeTarget
 	changes     *objects.ChangeLog
+	types       map[SnowflakeObjectType]bool
+	snapshot    *common.MetadataSnapshot
 	initialized bool
 }
 
 func newSnowflakeObjectExistsValidator(snowflake *SnowflakeTarget, changes *objects.ChangeLog) *SnowflakeObjectExistsValidator {
 	return &SnowflakeObjectExistsValidator{
-		db:      snowflake.connection,
-		target:  snowflake,
-		meta:    common.NewMetadata(StringToSnowflakeObjectTypeInt64),
-		changes: changes,
+		db:       snowflake.connection,
+		target:   snowflake,
+		meta:     common.NewMetadata(StringToSnowflakeObjectTypeInt64),
+		changes:  changes,
+		snapshot: snowflake.snapshot,
 	}
 }
 
 func (sfev *SnowflakeObjectExistsValidator) Init() error {
 	if !sfev.initialized {
-		err := sfev.loadMeta(sfev.identifyChangeDatabases(sfev.changes), sfev.meta)
+		sfev.types = sfev.identifyChangeTypes(sfev.changes)
+		databases := sfev.identifyChangeDatabases(sfev.changes)
+
+		//a snapshot taken at the current tracking head for the same scope replaces the catalog scan
+		var head, scope string
+		snapshot := sfev.snapshot
+		if snapshot != nil {
+			history, err := sfev.target.GetTrackingHistory(1)
+			if err != nil {
+				return err
+			}
+			head, scope = common.SnapshotHead(history), sfev.snapshotScope(databases)
+			if snapshot.Load(head, scope, sfev.meta) {
+				sfev.initialized = true
+				return nil
+			}
+		}
+
+		err := sfev.loadMeta(databases, sfev.meta)
+		if err != nil {
+			return err
+		}
+		err = sfev.loadAccountMeta(sfev.changes, sfev.meta)
 		if err != nil {
 			return err
 		}
+		if snapshot != nil {
+			if err := snapshot.Save(head, scope, sfev.meta); err != nil {
+				return err
+			}
+		}
 		sfev.initialized = true
 	}
 	return nil
 }
 
+// snapshotScope identifies the metadata loaded for the change log, the databases and types referenced and the account
+// level objects named, along with the account and the tool's database
+func (sfev *SnowflakeObjectExistsValidator) snapshotScope(databases map[string]bool) string {
+	elements := []string{"account:" + strings.ToUpper(sfev.target.config.Account), "tracking:" + strings.ToUpper(sfev.target.config.Database)}
+	for database := range databases {
+		elements = append(elements, "database:"+database)
+	}
+	for sfType := range sfev.types {
+		elements = append(elements, fmt.Sprintf("type:%d", sfType))
+	}
+	for _, change := range sfev.changes.Items() {
+		if kinds, ok := accountObjectKinds[StringToSnowflakeObjectType(change.Item.Type)]; ok {
+			elements = append(elements, kinds+":"+strings.ToUpper(strings.TrimSpace(change.Item.Object.Name)))
+		}
+	}
+	return common.SnapshotScope(elements...)
+}
+
 func (sfev *SnowflakeObjectExistsValidator) Destroy() error {
 	return nil
 }
 
+// Concurrent the metadata is loaded by Init and only read when validating
+func (sfev *SnowflakeObjectExistsValidator) Concurrent() bool {
+	return true
+}
+
 func (sfev *SnowflakeObjectExistsValidator) Designation() string {
 	return "ObjectExistsValidator"
 }
 
 func (sfev *SnowflakeObjectExistsValidator) Validate(change *objects.ChangeItem) error {
-	metaobj, err := sfev.meta.FindObjectFromSpec(change.Item)
+	metaobj, err := sfev.findObject(change)
 	if err != nil {
 		change.Validation.AddValidationStepInfo(objects.ValidationErrorCritical, false, err, sfev.Designation())
 		return err
@@ -60,6 +139,59 @@ func (sfev *SnowflakeObjectExistsValidator) Validate(change *objects.ChangeItem)
 	return nil
 }
 
+// findObject locates the object of the change, functions and procedures declaring a signature are matched to the
+// overload with the same argument types, otherwise any overload of the name is matched
+func (sfev *SnowflakeObjectExistsValidator) findObject(change *objects.ChangeItem) (*common.MetadataObject, error) {
+	sfType := StringToSnowflakeObjectType(change.Item.Type)
+	if sfType != Procedure && sfType != UserDefinedFunction {
+		return sfev.meta.FindObjectFromSpec(change.Item)
+	}
+
+	spec := &sfDefaultSpecification{}
+	if err := utility.UnmarshalYamlSubObject(change.Item.Spec, spec); err != nil {
+		return nil, err
+	}
+	properties := []common.Property{{Name: "name", Value: change.Item.Object.Name, IsKey: true},
+		{Name: "database", Value: change.Item.Object.Database},
+		{Name: "schema", Value: change.Item.Object.Schema}}
+	if signature := normalizeSignature(spec.Metadata.Signature); len(signature) > 0 {
+		properties = append(properties, common.Property{Name: "signature", Value: signature})
+	}
+	return sfev.meta.Find(int64(sfType), properties...)
+}
+
+// normalizeSignature argument types of a function or procedure in the form (TYPE,TYPE), optional arguments reported by
+// SHOW within brackets are treated as declared
+func normalizeSignature(signature string) string {
+	signature = strings.NewReplacer("[", "", "]", "").Replace(signature)
+	return strings.ToUpper(strings.Join(strings.Fields(signature), ""))
+}
+
+// signatureFromArguments the argument types of the arguments column reported by SHOW FUNCTIONS and SHOW PROCEDURES,
+// e.g. MY_FUNC(NUMBER, VARCHAR) RETURN NUMBER
+func signatureFromArguments(arguments string) string {
+	start := strings.Index(arguments, "(")
+	end := strings.LastIndex(arguments, ")")
+	if ret := strings.Index(strings.ToUpper(arguments), " RETURN "); ret > 0 {
+		end = strings.LastIndex(arguments[:ret], ")")
+	}
+	if start < 0 || end < start {
+		return ""
+	}
+	return normalizeSignature(arguments[start : end+1])
+}
+
+// identifyChangeTypes the object types present within the change log, SHOW commands are only issued for these types
+func (sfev *SnowflakeObjectExistsValidator) identifyChangeTypes(changes *objects.ChangeLog) map[SnowflakeObjectType]bool {
+	types := make(map[SnowflakeObjectType]bool)
+	for _, bundle := range changes.Bundles {
+		for _, change := range bundle.Items {
+			types[StringToSnowflakeObjectType(change.Item.Type)] = true
+		}
+	}
+	return types
+}
+
 func (sfev *SnowflakeObjectExistsValidator) identifyChangeDatabases(changes *objects.ChangeLog) map[string]bool {
 	databases := make(map[string]bool)
 	//loop changes, eval object spec pull database names to extract meta information for
@@ -129,7 +261,7 @@ func (sfev *SnowflakeObjectExistsValidator) loadDatabasesMeta(meta *common.Metad
 }
 
 func (sfev *SnowflakeObjectExistsValidator) loadDatabaseMeta(database string, meta *common.Metadata) error {
-	steps := []func(string, *common.Metadata) error{sfev.loadSchemaMeta, sfev.loadTableViewMeta}
+	steps := []func(string, *common.Metadata) error{sfev.loadSchemaMeta, sfev.loadTableViewMeta, sfev.loadSchemaObjectMeta}
 	for _, step := range steps {
 		err := step(database, meta)
 		if err != nil {
@@ -202,3 +334,95 @@ func (sfev *SnowflakeObjectExistsValidator) loadTableViewMeta(database string, m
 	}
 	return nil
 }
+
+// loadSchemaObjectMeta loads the schema level objects of the types present within the change log using SHOW <kinds>
+// IN DATABASE. Functions and procedures are added once by name and once for each overload's signature.
+func (sfev *SnowflakeObjectExistsValidator) loadSchemaObjectMeta(database string, meta *common.Metadata) error {
+	for _, sfType := range SnowflakeProcessingOrder {
+		kinds, ok := schemaObjectKinds[sfType]
+		if !ok || !sfev.types[sfType] {
+			continue
+		}
+
+		stmt, err := common.RenderStatement(ShowObjectsInDatabaseSQL, &gonja.Context{"KINDS": kinds, "DATABASE": database})
+		if err != nil {
+			return err
+		}
+		rows, err := sfev.target.renderer.inspector.show(stmt)
+		if err != nil {
+			return err
+		}
+
+		named := make(map[string]bool)
+		for _, row := range rows {
+			if strings.EqualFold(row["is_builtin"], "Y") {
+				continue
+			}
+			properties := []common.Property{{Name: "name", Value: row["name"], IsKey: true},
+				{Name: "database", Value: database},
+				{Name: "schema", Value: row["schema_name"]}}
+
+			if sfType == Procedure || sfType == UserDefinedFunction {
+				overload := append(append(make([]common.Property, 0), properties...),
+					common.Property{Name: "signature", Value: signatureFromArguments(row["arguments"])})
+				if err := sfev.addMetadataObject(meta, sfType, overload...); err != nil {
+					return err
+				}
+				key := row["schema_name"] + "." + row["name"]
+				if named[key] {
+					continue
+				}
+				named[key] = true
+			}
+			if err := sfev.addMetadataObject(meta, sfType, properties...); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// loadAccountMeta loads the account level objects named within the change log using SHOW <kinds> LIKE, these objects
+// are identified by name alone
+func (sfev *SnowflakeObjectExistsValidator) loadAccountMeta(changes *objects.ChangeLog, meta *common.Metadata) error {
+	loaded := make(map[string]bool)
+	for _, bundle := range changes.Bundles {
+		for _, change := range bundle.Items {
+			sfType := StringToSnowflakeObjectType(change.Item.Type)
+			kinds, ok := accountObjectKinds[sfType]
+			name := strings.TrimSpace(change.Item.Object.Name)
+			if !ok || len(name) == 0 || loaded[kinds+" "+strings.ToUpper(name)] {
+				continue
+			}
+			loaded[kinds+" "+strings.ToUpper(name)] = true
+
+			stmt, err := common.RenderStatement(ShowAccountObjectsSQL, &gonja.Context{"KINDS": kinds, "NAME": name})
+			if err != nil {
+				return err
+			}
+			rows, err := sfev.target.renderer.inspector.show(stmt)
+			if err != nil {
+				return err
+			}
+			//LIKE is a case-insensitive pattern match, underscores are wildcards so confirm the exact name
+			for _, row := range rows {
+				if strings.EqualFold(row["name"], name) {
+					if err := sfev.addMetadataObject(meta, sfType, common.Property{Name: "name", Value: name, IsKey: true}); err != nil {
+						return err
+					}
+					break
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (sfev *SnowflakeObjectExistsValidator) addMetadataObject(meta *common.Metadata, sfType SnowflakeObjectType, properties ...common.Property) error {
+	metaObject, err := common.NewMetadataObject(int64(sfType), properties...)
+	if err != nil {
+		return err
+	}
+	meta.AddObject(metaObject)
+	return nil
+}
@@ -1,6 +1,9 @@
 package snowflake
 
-import "strings"
+import (
+	"sort"
+	"strings"
+)
 
 type SnowflakeObjectType int64
 
@@ -23,57 +26,64 @@ const (
 	Sequence
 	User
 	Format
+	MaskingPolicy
+	RowAccessPolicy
+	Tag
 )
 
 func (s SnowflakeObjectType) ToInt64() int64 {
 	return int64(s)
 }
 
-var SnowflakeProcessingOrder = [...]SnowflakeObjectType{Role, Warehouse, Database, Schema, Table, View, Procedure, UserDefinedFunction, ResourceMonitor, Stage, Pipe, Stream, Task, Sequence, Format}
+var SnowflakeProcessingOrder = [...]SnowflakeObjectType{Role, User, ResourceMonitor, Warehouse, Database, Schema, Tag, MaskingPolicy, RowAccessPolicy, Table, View, Procedure, UserDefinedFunction, Stage, Pipe, Stream, Task, Sequence, Format}
 
 func StringToSnowflakeObjectTypeInt64(s string) int64 {
 	return int64(StringToSnowflakeObjectType(s))
 }
 
+// snowflakeTypeNames the accepted type names of each object type, names are matched case-insensitively
+var snowflakeTypeNames = map[string]SnowflakeObjectType{
+	"warehouse":           Warehouse,
+	"database":            Database,
+	"schema":              Schema,
+	"table":               Table,
+	"view":                View,
+	"procedure":           Procedure,
+	"sproc":               Procedure,
+	"storedprocedure":     Procedure,
+	"udf":                 UserDefinedFunction,
+	"userdefinedfunction": UserDefinedFunction,
+	"role":                Role,
+	"security":            Security,
+	"resourcemonitor":     ResourceMonitor,
+	"stage":               Stage,
+	"pipe":                Pipe,
+	"stream":              Stream,
+	"task":                Task,
+	"sequence":            Sequence,
+	"seq":                 Sequence,
+	"user":                User,
+	"format":              Format,
+	"maskingpolicy":       MaskingPolicy,
+	"rowaccesspolicy":     RowAccessPolicy,
+	"tag":                 Tag,
+}
+
 func StringToSnowflakeObjectType(s string) SnowflakeObjectType {
-	switch strings.TrimSpace(strings.ToLower(s)) {
-	case "warehouse":
-		return Warehouse
-	case "database":
-		return Database
-	case "schema":
-		return Schema
-	case "table":
-		return Table
-	case "view":
-		return View
-	case "procedure":
-		return Procedure
-	case "sproc", "storedprocedure":
-		return Procedure
-	case "udf", "userdefinedfunction":
-		return UserDefinedFunction
-	case "role":
-		return Role
-	case "security":
-		return Security
-	case "resourcemonitor":
-		return ResourceMonitor
-	case "stage":
-		return Stage
-	case "pipe":
-		return Pipe
-	case "stream":
-		return Stream
-	case "task":
-		return Task
-	case "sequence", "seq":
-		return Sequence
-	case "user":
-		return User
-	case "format":
-		return Format
-	default:
-		return UnknownType
+	if sfType, ok := snowflakeTypeNames[strings.TrimSpace(strings.ToLower(s))]; ok {
+		return sfType
+	}
+	return UnknownType
+}
+
+// TypeNames the accepted type names of the object type in alphabetical order
+func (s SnowflakeObjectType) TypeNames() []string {
+	names := make([]string, 0)
+	for name, sfType := range snowflakeTypeNames {
+		if sfType == s {
+			names = append(names, name)
+		}
 	}
+	sort.Strings(names)
+	return names
 }
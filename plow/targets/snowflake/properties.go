@@ -0,0 +1,71 @@
+package snowflake
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sfProperty an object parameter declared by a spec, desired holds the normalized value compared against the
+// column reported by SHOW or DESC, an empty value indicates the parameter is to be unset
+type sfProperty struct {
+	parameter string
+	column    string
+	value     string
+	desired   string
+	normalize func(string) string
+}
+
+// changed reports if the current properties differ from the declared value
+func (p sfProperty) changed(current map[string]string) bool {
+	if len(p.column) == 0 {
+		return false //create only parameter
+	}
+	return p.normalize(current[p.column]) != p.desired
+}
+
+func (p sfProperty) assignment() string {
+	return fmt.Sprintf("%s = %s", p.parameter, p.value)
+}
+
+// createProperties the assignments applied when the object is created, unset values are omitted
+func createProperties(props []sfProperty) []string {
+	assignments := make([]string, 0)
+	for _, prop := range props {
+		if len(prop.value) > 0 {
+			assignments = append(assignments, prop.assignment())
+		}
+	}
+	return assignments
+}
+
+// diffProperties the assignments to SET and the parameters to UNSET for the properties which differ from current
+func diffProperties(props []sfProperty, current map[string]string) ([]string, []string) {
+	set := make([]string, 0)
+	unset := make([]string, 0)
+	for _, prop := range props {
+		if !prop.changed(current) {
+			continue
+		}
+		if len(prop.value) > 0 {
+			set = append(set, prop.assignment())
+		} else {
+			unset = append(unset, prop.parameter)
+		}
+	}
+	return set, unset
+}
+
+func newIntProperty(parameter string, column string, value int) sfProperty {
+	val := strconv.Itoa(value)
+	return sfProperty{parameter: parameter, column: column, value: val, desired: val, normalize: normalizeToken}
+}
+
+// normalizeToken upper cases the value, SHOW and DESC report unset values as null
+func normalizeToken(value string) string {
+	value = strings.ToUpper(strings.Trim(strings.TrimSpace(value), "'"))
+	if value == "NULL" {
+		return ""
+	}
+	return value
+}
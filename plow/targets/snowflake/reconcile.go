@@ -0,0 +1,107 @@
+package snowflake
+
+import (
+	"Plow/plow/objects"
+	"Plow/plow/targets/common"
+	"github.com/noirbizarre/gonja"
+	"sort"
+	"strings"
+)
+
+// ReconcileScope name of the scope holding the revokes planned by reconciliation, listed separately within the
+// rendered output so they can be reviewed prior to apply
+const ReconcileScope = "reconcile"
+
+// declaredUsage the usage grants declared by a usage specification
+func declaredUsage(usage sfUsageSpecification) []sfGrant {
+	out := make([]sfGrant, 0)
+	for _, grant := range usage.Grants {
+		switch StringToSnowflakeObjectType(grant.ObjectType) {
+		case Role:
+			out = append(out, sfGrant{GranteeKind: RoleGrantee, Grantee: strings.ToUpper(strings.TrimSpace(grant.Identifier)), Privilege: "USAGE"})
+		case User:
+			out = append(out, sfGrant{GranteeKind: UserGrantee, Grantee: strings.TrimSpace(grant.Identifier), Privilege: "USAGE"})
+		}
+	}
+	return out
+}
+
+// renderUsageReconcile revokes usage present on the database, schema or warehouse which is not declared by the usage
+// specification, other privileges are not managed by the specification and are left in place. The owner and the
+// default change mgmt role are exempt, usage they hold is never revoked. Returns nil when the object does not exist or
+// nothing is to be revoked.
+func (sfr *SnowflakeRenderer) renderUsageReconcile(kind string, object string, usage sfUsageSpecification, owner string, exists bool) (*objects.ApplyScope, error) {
+	if !exists {
+		return nil, nil
+	}
+	rows, err := sfr.inspector.Grants(kind, object)
+	if err != nil {
+		return nil, err
+	}
+
+	exempt := []string{strings.ToUpper(sfr.defaultRole), strings.ToUpper(strings.TrimSpace(owner))}
+	current := make([]sfGrant, 0)
+	for _, grant := range grantsFromShow(rows, true) {
+		if grant.Privilege != "USAGE" || (grant.GranteeKind == RoleGrantee && (grant.Grantee == exempt[0] || grant.Grantee == exempt[1])) {
+			continue
+		}
+		current = append(current, grant)
+	}
+
+	stmts, err := renderGrantStatements(RevokePrivilegesSQL, gonja.Context{"KIND": kind, "OBJECT": object}, revokedGrants(declaredUsage(usage), current))
+	if err != nil {
+		return nil, err
+	}
+	return sfr.newReconcileScope(stmts), nil
+}
+
+// renderRoleReconcile revokes the account and database roles from the roles and users holding them which are not
+// declared by the role specification. The default change mgmt role and protected system roles are exempt. Returns nil
+// when nothing is to be revoked.
+func (sfr *SnowflakeRenderer) renderRoleReconcile(spec *sfRoleSpecification) (*objects.ApplyScope, error) {
+	stmts := make([]string, 0)
+	for _, role := range spec.managedRoles() {
+		rows, exists, err := sfr.roleHolderRows(role)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+
+		declared := spec.declaredHolders(role)
+		declared[roleNode{kind: RoleGrantee, name: strings.ToUpper(sfr.defaultRole)}] = true
+
+		holders := make([]string, 0)
+		revokes := make(map[string]string)
+		for _, row := range rows {
+			holder, ok := holderFromShow(row)
+			if !ok || declared[holder] || (holder.kind == RoleGrantee && IsProtectedSystemRole(holder.name)) {
+				continue
+			}
+			stmt, err := revokeHolder(role, holder)
+			if err != nil {
+				return nil, err
+			}
+			holders = append(holders, holder.String())
+			revokes[holder.String()] = stmt
+		}
+		sort.Strings(holders)
+		for _, key := range holders {
+			stmts = append(stmts, revokes[key])
+		}
+	}
+	return sfr.newReconcileScope(stmts), nil
+}
+
+// newReconcileScope wraps the revokes with the role switches required to issue them, revokes require the MANAGE
+// GRANTS privilege and are issued under SECURITYADMIN. Returns nil when there are no revokes.
+func (sfr *SnowflakeRenderer) newReconcileScope(revokes []string) *objects.ApplyScope {
+	if len(revokes) == 0 {
+		return nil
+	}
+	stmts := []string{generateUseRoleStmt(string(SECURITYADMIN))}
+	stmts = append(stmts, revokes...)
+	stmts = append(stmts, generateUseRoleStmt(sfr.defaultRole))
+	return common.NewScope(ReconcileScope, stmts)
+}
@@ -0,0 +1,295 @@
+package snowflake
+
+import (
+	"Plow/plow/objects"
+	"Plow/plow/targets/common"
+	"Plow/plow/utility"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/noirbizarre/gonja"
+	sf "github.com/snowflakedb/gosnowflake"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// rehearsalSkippedTypes account level objects, these can not be redirected to a clone and are never executed during a
+// rehearsal
+var rehearsalSkippedTypes = map[SnowflakeObjectType]bool{Role: true, User: true, ResourceMonitor: true, Warehouse: true}
+
+// sfRehearsalClone a database referenced by the change log and the clone standing in for it during a rehearsal
+type sfRehearsalClone struct {
+	source     string
+	name       string
+	qualified  *regexp.Regexp
+	designated *regexp.Regexp
+}
+
+func newRehearsalClone(source string, suffix string) *sfRehearsalClone {
+	name := regexp.QuoteMeta(source)
+	return &sfRehearsalClone{
+		source: source,
+		name:   fmt.Sprintf("%s_REHEARSAL_%s", source, suffix),
+		//database qualified identifiers, e.g. DEMO.PUBLIC.TABLE or "DEMO".PUBLIC.TABLE
+		qualified: regexp.MustCompile(`(?i)(^|[^\w$."])"?` + name + `"?(\s*\.)`),
+		//unqualified references to the database, e.g. CREATE DATABASE DEMO or USE DATABASE DEMO
+		designated: regexp.MustCompile(`(?i)(\b(?:DATABASE|USE)\s+(?:IF\s+(?:NOT\s+)?EXISTS\s+)?)"?` + name + `"?([^\w$.]|$)`),
+	}
+}
+
+// rewrite redirects the references to the source database within the command to the clone
+func (c *sfRehearsalClone) rewrite(cmd string) string {
+	cmd = c.qualified.ReplaceAllString(cmd, "${1}"+c.name+"${2}")
+	return c.designated.ReplaceAllString(cmd, "${1}"+c.name+"${2}")
+}
+
+// rehearsalDatabase the database the change belongs to, empty for account level and unknown object types
+func rehearsalDatabase(change *objects.ChangeItem) string {
+	switch StringToSnowflakeObjectType(change.Item.Type) {
+	case UnknownType:
+		return ""
+	case Database:
+		return strings.ToUpper(strings.TrimSpace(change.Item.Object.Name))
+	default:
+		return strings.ToUpper(strings.TrimSpace(change.Item.Object.Database))
+	}
+}
+
+// rehearsalDatabases the databases referenced by the change log, mirrors the databases identified by the object
+// exists validator
+func rehearsalDatabases(changes *objects.ChangeLog) []string {
+	databases := make(map[string]bool)
+	for _, bundle := range changes.Bundles {
+		for _, change := range bundle.Items {
+			if database := rehearsalDatabase(change); len(database) > 0 {
+				databases[database] = true
+			}
+		}
+	}
+
+	out := make([]string, 0)
+	for database := range databases {
+		out = append(out, database)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// rehearsalChange a copy of the rendered change with the commands of each scope rewritten to reference the clones, the
+// scopes of the change log item are not altered
+func rehearsalChange(item *objects.ChangeItem, clones []*sfRehearsalClone) *common.RenderedChange {
+	copied := &objects.ChangeItem{ObjectType: item.ObjectType, Item: item.Item, Metadata: item.Metadata, Bundle: item.Bundle}
+	scopes := make([]*objects.ApplyScope, 0)
+	for _, scope := range item.ApplyInformation.GetScopes() {
+		commands := make([]string, len(scope.Commands))
+		for i, cmd := range scope.Commands {
+			for _, clone := range clones {
+				cmd = clone.rewrite(cmd)
+			}
+			commands[i] = cmd
+		}
+		scopes = append(scopes, common.NewScope(scope.Name, commands))
+	}
+	return common.NewRenderedChange(copied, scopes)
+}
+
+// recordRehearsal records the outcome of the rehearsed copy within the apply information of the change log item
+func recordRehearsal(item *objects.ChangeItem, rehearsed *objects.ChangeItem) {
+	item.ApplyInformation.Executed = rehearsed.ApplyInformation.Executed
+	item.ApplyInformation.Completed = rehearsed.ApplyInformation.Completed
+	item.ApplyInformation.Error = rehearsed.ApplyInformation.Error
+	copies := rehearsed.ApplyInformation.GetScopes()
+	for i, scope := range item.ApplyInformation.GetScopes() {
+		if i < len(copies) {
+			effect := copies[i].GetEffectInfo()
+			scope.SetEffectInfo(effect.Executed, effect.Success, effect.Partial, effect.Error)
+		}
+	}
+}
+
+// openRehearsalSession a session dedicated to the rehearsal, opened without a current database so statements which are
+// not qualified with a database are never executed against the operating database. The clone of the database of
+// each change is used prior to the change being executed.
+func (s *SnowflakeTarget) openRehearsalSession(context context.Context) (*sql.DB, *sql.Conn, error) {
+	config := s.config
+	config.Database = ""
+	dsn, err := sf.DSN(&config)
+	if err != nil {
+		return nil, nil, err
+	}
+	db, err := sql.Open("snowflake", dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	session, err := db.Conn(context)
+	if err != nil {
+		_ = db.Close()
+		return nil, nil, utility.WrapError("unable to open rehearsal session:", err)
+	}
+	return db, session, nil
+}
+
+// RehearseChangeLog executes the change log against zero-copy clones of the databases it references. Each database is
+// cloned, the rendered scopes are rewritten to reference the clones and every item is executed, a failing item does
+// not halt the rehearsal. Items are executed over a dedicated session using the clone of the database of the item,
+// statements which do not qualify objects with a database resolve within the clone. The outcome of each item is
+// recorded within its apply information, account level objects are not executed. Clones are dropped once the
+// rehearsal completes and no tracking information is recorded.
+func (s *SnowflakeTarget) RehearseChangeLog(context context.Context, changes *objects.ChangeLog) (err error) {
+	if changes == nil {
+		return common.ErrNoChangesProvided
+	}
+
+	if err := s.ResetActiveRole(); err != nil {
+		return err
+	}
+
+	rendered, err := s.RenderChangeLog(changes)
+	if err != nil {
+		return err
+	}
+
+	suffix := time.Now().UTC().Format("20060102150405")
+	clones := make([]*sfRehearsalClone, 0)
+	for _, database := range rehearsalDatabases(changes) {
+		clones = append(clones, newRehearsalClone(database, suffix))
+	}
+
+	defer func() {
+		if cleanupErr := s.dropRehearsalClones(clones); cleanupErr != nil && err == nil {
+			err = cleanupErr
+		}
+	}()
+
+	for _, clone := range clones {
+		if err := s.createRehearsalClone(clone); err != nil {
+			return err
+		}
+	}
+
+	warehouseCoordinator := s.renderer.GetWarehouseCoordinator()
+	if err := warehouseCoordinator.Activate(s.connection); err != nil {
+		return err
+	}
+	defer warehouseCoordinator.DeActivate()
+
+	db, session, err := s.openRehearsalSession(context)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	defer session.Close()
+
+	cloneNames := make(map[string]string)
+	for _, clone := range clones {
+		cloneNames[clone.source] = clone.name
+	}
+
+	for _, renderedChg := range rendered {
+		item := renderedChg.Item()
+		clone, ok := cloneNames[rehearsalDatabase(item)]
+		if rehearsalSkippedTypes[StringToSnowflakeObjectType(item.Item.Type)] || !ok {
+			item.ApplyInformation.Executed = false
+			item.ApplyInformation.Completed = false
+			item.ApplyInformation.Error = ErrNotRehearsed
+			continue
+		}
+
+		//a database created by the change log has no clone until the change is executed
+		if _, err := session.ExecContext(context, fmt.Sprintf("USE DATABASE %s;", clone)); err != nil &&
+			StringToSnowflakeObjectType(item.Item.Type) != Database {
+			item.ApplyInformation.Executed = false
+			item.ApplyInformation.Completed = false
+			item.ApplyInformation.Error = utility.WrapError(fmt.Sprintf("unable to use clone [%s]:", clone), err)
+			continue
+		}
+
+		//the error is recorded against the item, continue so every item of the change log is reported
+		rehearsed := rehearsalChange(item, clones)
+		_ = common.ApplyRenderedChange(context, session, rehearsed)
+		recordRehearsal(item, rehearsed.Item())
+	}
+
+	return s.ResetActiveRole()
+}
+
+// createRehearsalClone clones the source database when it exists, databases created by the change log are created
+// under the clone name instead. Database level grants are not carried by a clone and are copied from the source, the
+// clone is then handed to the owner of the source.
+func (s *SnowflakeTarget) createRehearsalClone(clone *sfRehearsalClone) error {
+	inspector := s.renderer.inspector
+	source, exists, err := inspector.Database(clone.source)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+
+	vars := gonja.Context{"NAME": clone.source, "CLONE": clone.name}
+	stmt, err := common.RenderStatement(CloneDatabaseSQL, &vars)
+	if err != nil {
+		return err
+	}
+	if _, err := s.connection.Exec(stmt); err != nil {
+		return utility.WrapError(fmt.Sprintf("clone of [%s]:", clone.source), err)
+	}
+
+	stmts := make([]string, 0)
+	grants, err := inspector.Grants("DATABASE", clone.source)
+	if err != nil {
+		return err
+	}
+	for _, row := range grants {
+		if !strings.EqualFold(row["granted_to"], "ROLE") || strings.EqualFold(row["privilege"], "OWNERSHIP") {
+			continue
+		}
+		stmt, err := common.RenderStatement(GrantPrivilegesSQL, &gonja.Context{"PRIVILEGES": row["privilege"],
+			"KIND": "DATABASE", "OBJECT": clone.name, "GRANTEE_KIND": "ROLE", "GRANTEE": row["grantee_name"]})
+		if err != nil {
+			return err
+		}
+		stmts = append(stmts, stmt)
+	}
+
+	owner := strings.ToUpper(source["owner"])
+	if len(owner) > 0 && !strings.EqualFold(owner, s.renderer.defaultRole) {
+		stmt, err := common.RenderStatement(GrantDatabaseOwnershipCopySQL, &gonja.Context{"NAME": clone.name, "ROLE": owner})
+		if err != nil {
+			return err
+		}
+		stmts = append(stmts, stmt)
+	}
+
+	for _, stmt := range stmts {
+		if _, err := s.connection.Exec(stmt); err != nil {
+			return utility.WrapError(fmt.Sprintf("clone of [%s]:", clone.source), err)
+		}
+	}
+	return nil
+}
+
+// dropRehearsalClones drops every clone, including databases created under a clone name by the change log. The
+// default change mgmt role is expected to hold the owner roles used by the change log.
+func (s *SnowflakeTarget) dropRehearsalClones(clones []*sfRehearsalClone) error {
+	if err := s.ResetActiveRole(); err != nil {
+		return err
+	}
+
+	remaining := make([]string, 0)
+	for _, clone := range clones {
+		stmt, err := common.RenderStatement(DropDatabaseIfExistsSQL, &gonja.Context{"NAME": clone.name})
+		if err != nil {
+			return err
+		}
+		if _, err := s.connection.Exec(stmt); err != nil {
+			remaining = append(remaining, clone.name)
+		}
+	}
+	if len(remaining) > 0 {
+		return utility.WrapError(fmt.Sprintf("[%s]", strings.Join(remaining, ", ")), ErrRehearsalCleanup)
+	}
+	return nil
+}
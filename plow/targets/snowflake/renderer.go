@@ -2,10 +2,13 @@ package snowflake
 
 import (
 	"Plow/plow/objects"
+	"Plow/plow/secrets"
 	"Plow/plow/targets/common"
 	"Plow/plow/utility"
+	"fmt"
 	"github.com/noirbizarre/gonja"
 	"regexp"
+	"strings"
 )
 
 var (
@@ -16,6 +19,9 @@ var (
 type SnowflakeRenderer struct {
 	defaultRole          string
 	warehouseCoordinator *WarehouseUnitCoordinator
+	inspector            *SnowflakeInspector
+	secretStore          secrets.SecretStore
+	monitorRole          string
 }
 
 func evalAllowedCommands(input string) bool {
@@ -27,10 +33,13 @@ func evalAllowedCommands(input string) bool {
 	return true
 }
 
-func newSnowflakeRenderer(role string, warehouseName string) *SnowflakeRenderer {
+func newSnowflakeRenderer(config *SnowflakeConfiguration, inspector *SnowflakeInspector, secretStore secrets.SecretStore) *SnowflakeRenderer {
 	return &SnowflakeRenderer{
-		defaultRole:          role,
-		warehouseCoordinator: newWarehouseUnitCoordinator(warehouseName, role),
+		defaultRole:          config.Role,
+		warehouseCoordinator: newWarehouseUnitCoordinator(config.Warehouse, config.Role),
+		inspector:            inspector,
+		secretStore:          secretStore,
+		monitorRole:          config.monitorRole(),
 	}
 }
 
@@ -72,6 +81,19 @@ func (sfr *SnowflakeRenderer) RenderWithContext(change *objects.ChangeItem, para
 
 			return sfr.renderSchemaSpec(spec, change, params)
 		}
+	case Table:
+		{
+			if !isDeclarative(change) {
+				return sfr.renderDefault(change, params)
+			}
+			spec := &sfTableSpecification{}
+			err := utility.UnmarshalYamlSubObject(change.Item.Spec, spec)
+			if err != nil {
+				return nil, err
+			}
+
+			return sfr.renderTableSpec(spec, change, params)
+		}
 	case Warehouse:
 		{
 			spec := &sfWarehouseSpecification{}
@@ -82,16 +104,58 @@ func (sfr *SnowflakeRenderer) RenderWithContext(change *objects.ChangeItem, para
 
 			return sfr.renderWarehouseSpec(spec, change, params)
 		}
-	default:
+	case MaskingPolicy, RowAccessPolicy:
 		{
-			spec := &sfDefaultSpecification{}
+			spec := &sfPolicySpecification{}
 			err := utility.UnmarshalYamlSubObject(change.Item.Spec, spec)
 			if err != nil {
 				return nil, err
 			}
-			return sfr.renderDefaultSpec(spec, change, params)
+
+			return sfr.renderPolicySpec(spec, change, params)
+		}
+	case Tag:
+		{
+			spec := &sfTagSpecification{}
+			err := utility.UnmarshalYamlSubObject(change.Item.Spec, spec)
+			if err != nil {
+				return nil, err
+			}
+
+			return sfr.renderTagSpec(spec, change, params)
 		}
+	case ResourceMonitor:
+		{
+			spec := &sfResourceMonitorSpecification{}
+			err := utility.UnmarshalYamlSubObject(change.Item.Spec, spec)
+			if err != nil {
+				return nil, err
+			}
+
+			return sfr.renderResourceMonitorSpec(spec, change, params)
+		}
+	case User:
+		{
+			spec := &sfUserSpecification{}
+			err := utility.UnmarshalYamlSubObject(change.Item.Spec, spec)
+			if err != nil {
+				return nil, err
+			}
+
+			return sfr.renderUserSpec(spec, change, params)
+		}
+	default:
+		return sfr.renderDefault(change, params)
+	}
+}
+
+func (sfr *SnowflakeRenderer) renderDefault(change *objects.ChangeItem, params *map[string]interface{}) ([]*objects.ApplyScope, error) {
+	spec := &sfDefaultSpecification{}
+	err := utility.UnmarshalYamlSubObject(change.Item.Spec, spec)
+	if err != nil {
+		return nil, err
 	}
+	return sfr.renderDefaultSpec(spec, change, params)
 }
 
 func (sfr *SnowflakeRenderer) Render(change *objects.ChangeItem) ([]*objects.ApplyScope, error) {
@@ -99,6 +163,10 @@ func (sfr *SnowflakeRenderer) Render(change *objects.ChangeItem) ([]*objects.App
 }
 
 func (sfr *SnowflakeRenderer) renderRoleSpec(spec *sfRoleSpecification, item *objects.ChangeItem, params *map[string]interface{}) ([]*objects.ApplyScope, error) {
+	if err := spec.validate(); err != nil {
+		return nil, err
+	}
+
 	stmts := make([]string, 0)
 	stmts = append(stmts, generateUseRoleStmt(string(SECURITYADMIN)))
 
@@ -117,65 +185,53 @@ func (sfr *SnowflakeRenderer) renderRoleSpec(spec *sfRoleSpecification, item *ob
 		stmts = append(stmts, stmt)
 	}
 
+	//database roles are created by their owner, grants are then issued back under SECURITYADMIN
+	if len(spec.DatabaseRoles) > 0 {
+		dbRoleStmts, err := sfr.renderDatabaseRoles(spec)
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, dbRoleStmts...)
+		stmts = append(stmts, generateUseRoleStmt(string(SECURITYADMIN)))
+	}
+
 	//process role grants
-	for _, grant := range spec.Grants {
-		variables := make(map[string]interface{})
-		if !IsProtectedSystemRole(grant.Role) {
-			variables["ROLE"] = grant.Role
-			var stmt string
-			switch StringToSnowflakeObjectType(grant.Object.ObjectType) {
-			case Role:
-				{
-					stmt = GrantRoleToRoleSQL
-					variables["ROLENAME"] = grant.Object.Identifier
-					break
-				}
-			case User:
-				{
-					stmt = GrantRoleToUserSQL
-					variables["USER"] = grant.Object.Identifier
-					break
-				}
-			}
-			stmt, err := common.RenderStatement(stmt, (*gonja.Context)(&variables))
-			if err != nil {
-				return nil, err
-			}
-			stmts = append(stmts, stmt)
+	for i := range spec.Grants {
+		if IsProtectedSystemRole(spec.Grants[i].Role) {
+			continue
+		}
+		stmt, err := spec.Grants[i].statement(false)
+		if err != nil {
+			return nil, err
 		}
+		stmts = append(stmts, stmt)
 	}
 
 	//process role revokes
-	for _, revoke := range spec.Revokes {
-		variables := make(map[string]interface{})
-		if !IsProtectedSystemRole(revoke.Role) {
-			variables["ROLE"] = revoke.Role
-			var stmt string
-			switch StringToSnowflakeObjectType(revoke.Object.ObjectType) {
-			case Role:
-				{
-					stmt = RevokeRoleToRoleSQL
-					variables["ROLENAME"] = revoke.Object.Identifier
-					break
-				}
-			case User:
-				{
-					stmt = RevokeRoleToUserSQL
-					variables["USER"] = revoke.Object.Identifier
-					break
-				}
-			}
-			stmt, err := common.RenderStatement(stmt, (*gonja.Context)(&variables))
-			if err != nil {
-				return nil, err
-			}
-			stmts = append(stmts, stmt)
+	for i := range spec.Revokes {
+		if IsProtectedSystemRole(spec.Revokes[i].Role) {
+			continue
 		}
+		stmt, err := spec.Revokes[i].statement(true)
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, stmt)
 	}
 
 	stmts = append(stmts, generateUseRoleStmt(sfr.defaultRole)) //set role back to default for good measure
 
-	return []*objects.ApplyScope{common.NewScope("role", stmts)}, nil
+	out := []*objects.ApplyScope{common.NewScope("role", stmts)}
+	if spec.Reconcile {
+		scope, err := sfr.renderRoleReconcile(spec)
+		if err != nil {
+			return nil, err
+		}
+		if scope != nil {
+			out = append(out, scope)
+		}
+	}
+	return out, nil
 }
 
 func (sfr *SnowflakeRenderer) renderDatabaseSpec(spec *sfDatabaseSpecification, item *objects.ChangeItem, params *map[string]interface{}) ([]*objects.ApplyScope, error) {
@@ -287,7 +343,27 @@ func (sfr *SnowflakeRenderer) renderDatabaseSpec(spec *sfDatabaseSpecification,
 			stmts = append(stmts, stmt)
 		}
 	}
-	return []*objects.ApplyScope{common.NewScope("database", stmts)}, nil
+	out := []*objects.ApplyScope{common.NewScope("database", stmts)}
+	if !item.Item.Options.Drop {
+		name, _ := (*params)["NAME"].(string)
+		future, err := sfr.renderFutureGrants(spec.FutureGrants, "DATABASE", name, name, item.ExistsFlag)
+		if err != nil {
+			return nil, err
+		}
+		if future != nil {
+			out = append(out, future)
+		}
+		if spec.Reconcile {
+			scope, err := sfr.renderUsageReconcile("DATABASE", name, spec.Usage, ownerRole(spec.Owner), item.ExistsFlag)
+			if err != nil {
+				return nil, err
+			}
+			if scope != nil {
+				out = append(out, scope)
+			}
+		}
+	}
+	return out, nil
 }
 
 func (sfr *SnowflakeRenderer) renderSchemaSpec(spec *sfSchemaSpecification, item *objects.ChangeItem, params *map[string]interface{}) ([]*objects.ApplyScope, error) {
@@ -384,7 +460,28 @@ func (sfr *SnowflakeRenderer) renderSchemaSpec(spec *sfSchemaSpecification, item
 		}
 	}
 
-	return []*objects.ApplyScope{common.NewScope("schema", stmts)}, nil
+	out := []*objects.ApplyScope{common.NewScope("schema", stmts)}
+	if !item.Item.Options.Drop {
+		database, _ := (*params)["DATABASE"].(string)
+		name, _ := (*params)["NAME"].(string)
+		future, err := sfr.renderFutureGrants(spec.FutureGrants, "SCHEMA", fmt.Sprintf("%s.%s", database, name), database, item.ExistsFlag)
+		if err != nil {
+			return nil, err
+		}
+		if future != nil {
+			out = append(out, future)
+		}
+		if spec.Reconcile {
+			scope, err := sfr.renderUsageReconcile("SCHEMA", fmt.Sprintf("%s.%s", database, name), spec.Usage, ownerRole(spec.Owner), item.ExistsFlag)
+			if err != nil {
+				return nil, err
+			}
+			if scope != nil {
+				out = append(out, scope)
+			}
+		}
+	}
+	return out, nil
 }
 
 func (sfr *SnowflakeRenderer) renderDefaultSpec(spec *sfDefaultSpecification, item *objects.ChangeItem, params *map[string]interface{}) ([]*objects.ApplyScope, error) {
@@ -425,11 +522,12 @@ func (sfr *SnowflakeRenderer) renderDefaultSpec(spec *sfDefaultSpecification, it
 	initPresent := !utility.IsStringEmpty(&spec.Init)
 	changePresent := !utility.IsStringEmpty(&spec.Change)
 
+	var change *objects.ApplyScope
 	if initPresent {
 		if item.ExistsFlag {
 			if changePresent {
-				if scope, err = renderSpecStatement(spec.Change, "change", (*gonja.Context)(params)); err == nil {
-					out = append(out, scope)
+				if change, err = renderSpecStatement(spec.Change, "change", (*gonja.Context)(params)); err == nil {
+					out = append(out, change)
 				} else {
 					return nil, err
 				}
@@ -443,6 +541,21 @@ func (sfr *SnowflakeRenderer) renderDefaultSpec(spec *sfDefaultSpecification, it
 		}
 	}
 
+	//grants are rendered under the owner following the object scopes
+	if scope, err = sfr.renderObjectGrants(spec.Grants, spec.Metadata.Signature, item.ExistsFlag, item, params); err != nil {
+		return nil, err
+	} else if scope != nil {
+		out = append(out, scope)
+	}
+
+	//a change scope recreating the object drops its policies and tags, other change scopes retain them
+	replaced := replacesObject(change, attachableObjectKinds[StringToSnowflakeObjectType(item.Item.Type)], params)
+	if scope, err = sfr.renderAttachments(&spec.sfAttachmentSpecification, item.ExistsFlag, replaced, item, params); err != nil {
+		return nil, err
+	} else if scope != nil {
+		out = append(out, scope)
+	}
+
 	//post scope statements are always applied if present in the spec
 	if !utility.IsStringEmpty(&spec.Post) {
 		if scope, err = renderSpecStatement(spec.Post, "post", (*gonja.Context)(params)); err == nil {
@@ -455,13 +568,214 @@ func (sfr *SnowflakeRenderer) renderDefaultSpec(spec *sfDefaultSpecification, it
 	return out, nil
 }
 
+// renderTableSpec renders a declarative table spec, the table is created when absent, otherwise the current
+// definition is compared against the spec and the ALTER statements required to align them are rendered
+func (sfr *SnowflakeRenderer) renderTableSpec(spec *sfTableSpecification, item *objects.ChangeItem, params *map[string]interface{}) ([]*objects.ApplyScope, error) {
+	out := make([]*objects.ApplyScope, 0)
+	vars := *params
+
+	if err := spec.validate(); err != nil {
+		return nil, err
+	}
+
+	if spec.Metadata.Owner != nil {
+		sfr.addOwnerToWarehouseCoordinator(*spec.Metadata.Owner)
+		if StringToSnowflakeObjectType(spec.Metadata.Owner.ObjectType) == Role && !utility.IsStringEmpty(&spec.Metadata.Owner.Identifier) {
+			if IsProtectedSystemRole(spec.Metadata.Owner.Identifier) {
+				return nil, ErrDisallowedPrivilegedRole
+			}
+			out = append(out, common.NewScope("security", []string{generateUseRoleStmt(spec.Metadata.Owner.Identifier)}))
+		}
+	}
+
+	if item.Item.Options.Drop {
+		stmt, err := common.RenderStatement(DropTableSQL, (*gonja.Context)(&vars))
+		if err != nil {
+			return nil, err
+		}
+		return append(out, common.NewScope("drop", []string{stmt})), nil
+	}
+
+	if !utility.IsStringEmpty(&spec.Pre) {
+		scope, err := renderSpecStatement(spec.Pre, "pre", (*gonja.Context)(params))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, scope)
+	}
+
+	state, exists, err := sfr.inspector.Table(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		vars["DEFINITION"] = spec.createDefinition()
+		vars["CLUSTER_BY"] = strings.Join(spec.ClusterBy, ", ")
+		vars["COMMENT"] = ""
+		if spec.Comment != nil && len(*spec.Comment) > 0 {
+			vars["COMMENT"] = quoteLiteral(*spec.Comment)
+		}
+		stmt, err := common.RenderStatement(CreateTableSQL, (*gonja.Context)(&vars))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, common.NewScope("init", []string{stmt}))
+	} else {
+		if !strings.EqualFold(state.TableType, "BASE TABLE") {
+			return nil, utility.WrapError(fmt.Sprintf("existing object is a [%s]", state.TableType), ErrInvalidTableSpecification)
+		}
+		actions, err := spec.alterActions(state)
+		if err != nil {
+			return nil, err
+		}
+		stmts := make([]string, 0)
+		for _, action := range actions {
+			vars["ACTION"] = action
+			stmt, err := common.RenderStatement(AlterTableSQL, (*gonja.Context)(&vars))
+			if err != nil {
+				return nil, err
+			}
+			stmts = append(stmts, stmt)
+		}
+		if len(stmts) > 0 {
+			out = append(out, common.NewScope("change", stmts))
+		}
+	}
+
+	grants, err := sfr.renderObjectGrants(spec.Grants, "", exists, item, params)
+	if err != nil {
+		return nil, err
+	}
+	if grants != nil {
+		out = append(out, grants)
+	}
+
+	attachments, err := sfr.renderAttachments(&spec.sfAttachmentSpecification, exists, false, item, params)
+	if err != nil {
+		return nil, err
+	}
+	if attachments != nil {
+		out = append(out, attachments)
+	}
+
+	if !utility.IsStringEmpty(&spec.Post) {
+		scope, err := renderSpecStatement(spec.Post, "post", (*gonja.Context)(params))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, scope)
+	}
+
+	return out, nil
+}
+
 func (sfr *SnowflakeRenderer) renderWarehouseSpec(spec *sfWarehouseSpecification, item *objects.ChangeItem, params *map[string]interface{}) ([]*objects.ApplyScope, error) {
 	stmts := make([]string, 0)
 
+	vars := *params
+
 	sfr.addOwnerToWarehouseCoordinator(spec.Owner)
 
-	//render statement setting role to default change mgmt role
-	stmts = append(stmts, generateUseRoleStmt(sfr.defaultRole))
+	owner := ""
+	if StringToSnowflakeObjectType(spec.Owner.ObjectType) == Role && !utility.IsStringEmpty(&spec.Owner.Identifier) {
+		owner = strings.ToUpper(strings.TrimSpace(spec.Owner.Identifier))
+		if IsProtectedSystemRole(owner) {
+			return nil, ErrDisallowedPrivilegedRole
+		}
+	}
+
+	if item.Item.Options.Drop {
+		if len(owner) > 0 {
+			stmts = append(stmts, generateUseRoleStmt(owner))
+		} else {
+			stmts = append(stmts, generateUseRoleStmt(sfr.defaultRole))
+		}
+		stmt, err := common.RenderStatement(DropWarehouseSQL, (*gonja.Context)(&vars))
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, stmt, generateUseRoleStmt(sfr.defaultRole))
+		return []*objects.ApplyScope{common.NewScope("warehouse", stmts)}, nil
+	}
+
+	props, err := spec.properties()
+	if err != nil {
+		return nil, err
+	}
+
+	//the warehouse properties are read at render time, the existence validation only records whether it exists
+	current, exists, err := sfr.inspector.Warehouse(vars["NAME"].(string))
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		stmts = append(stmts, generateUseRoleStmt(sfr.defaultRole))
+		vars["PROPERTIES"] = strings.Join(createProperties(props), " ")
+		stmt, err := common.RenderStatement(CreateWarehouseSQL, (*gonja.Context)(&vars))
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, stmt)
+
+		if len(owner) > 0 && owner != strings.ToUpper(sfr.defaultRole) {
+			vars["ROLE"] = owner
+			if stmt, err = common.RenderStatement(GrantWarehouseOwnershipSQL, (*gonja.Context)(&vars)); err != nil {
+				return nil, err
+			}
+			stmts = append(stmts, stmt)
+		}
+	} else {
+		//alter under the declared owner when it already owns the warehouse, otherwise the default change mgmt role
+		//is expected to hold the privileges to modify and transfer the warehouse
+		currentOwner := strings.ToUpper(current["owner"])
+		if len(owner) > 0 && currentOwner == owner {
+			stmts = append(stmts, generateUseRoleStmt(owner))
+		} else {
+			stmts = append(stmts, generateUseRoleStmt(sfr.defaultRole))
+		}
+
+		set, unset := diffProperties(props, current)
+
+		if len(set) > 0 {
+			vars["PROPERTIES"] = strings.Join(set, " ")
+			stmt, err := common.RenderStatement(AlterWarehouseSetSQL, (*gonja.Context)(&vars))
+			if err != nil {
+				return nil, err
+			}
+			stmts = append(stmts, stmt)
+		}
+		if len(unset) > 0 {
+			vars["PROPERTIES"] = strings.Join(unset, ", ")
+			stmt, err := common.RenderStatement(AlterWarehouseUnsetSQL, (*gonja.Context)(&vars))
+			if err != nil {
+				return nil, err
+			}
+			stmts = append(stmts, stmt)
+		}
+
+		//ownership transfer, existing grants are retained
+		if len(owner) > 0 && currentOwner != owner {
+			vars["ROLE"] = owner
+			stmt, err := common.RenderStatement(GrantWarehouseOwnershipSQL, (*gonja.Context)(&vars))
+			if err != nil {
+				return nil, err
+			}
+			stmts = append(stmts, stmt)
+		}
+	}
+
+	//grants are issued by the owner, retaining usage for the default change mgmt role
+	if len(owner) > 0 && owner != strings.ToUpper(sfr.defaultRole) {
+		stmts = append(stmts, generateUseRoleStmt(owner))
+		vars["ROLE"] = sfr.defaultRole
+		stmt, err := common.RenderStatement(GrantUsageOnWarehouseToRoleSQL, (*gonja.Context)(&vars))
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, stmt)
+	}
 
 	//process usage grants/revokes
 	//process grants
@@ -516,7 +830,19 @@ func (sfr *SnowflakeRenderer) renderWarehouseSpec(spec *sfWarehouseSpecification
 		stmts = append(stmts, stmt)
 	}
 
-	return []*objects.ApplyScope{common.NewScope("warehouse", stmts)}, nil
+	stmts = append(stmts, generateUseRoleStmt(sfr.defaultRole)) //set role back to default for good measure
+
+	out := []*objects.ApplyScope{common.NewScope("warehouse", stmts)}
+	if spec.Reconcile {
+		scope, err := sfr.renderUsageReconcile("WAREHOUSE", vars["NAME"].(string), spec.Usage, owner, exists)
+		if err != nil {
+			return nil, err
+		}
+		if scope != nil {
+			out = append(out, scope)
+		}
+	}
+	return out, nil
 }
 
 func (sfr *SnowflakeRenderer) addOwnerToWarehouseCoordinator(owner objects.ObjectDesignation) {
@@ -544,6 +870,19 @@ func evaluateCommands(commands []string) bool {
 	return utility.All(commands, evalAllowedCommands)
 }
 
+// ownerRole the owning role identifier, empty when the owner is not a role
+func ownerRole(owner objects.ObjectDesignation) string {
+	if StringToSnowflakeObjectType(owner.ObjectType) == Role {
+		return owner.Identifier
+	}
+	return ""
+}
+
+// quoteLiteral renders the value as a single quoted string literal
+func quoteLiteral(value string) string {
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(value, "'", "''"))
+}
+
 func generateUseRoleStmt(role string) string {
 	if stmt, err := common.RenderStatement(UseRoleSQL, &gonja.Context{"ROLE": role}); err == nil {
 		return stmt
@@ -0,0 +1,251 @@
+package snowflake
+
+import (
+	"Plow/plow/objects"
+	"Plow/plow/targets/common"
+	"Plow/plow/utility"
+	"fmt"
+	"github.com/noirbizarre/gonja"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	NotifyTrigger           = "NOTIFY"
+	SuspendTrigger          = "SUSPEND"
+	SuspendImmediateTrigger = "SUSPEND_IMMEDIATE"
+	triggersColumn          = "triggers"
+)
+
+var (
+	monitorFrequencies = []string{"DAILY", "WEEKLY", "MONTHLY", "YEARLY", "NEVER"}
+	//the SHOW RESOURCE MONITORS column reporting the thresholds of each action
+	monitorTriggerColumns = map[string]string{
+		NotifyTrigger:           "notify_at",
+		SuspendTrigger:          "suspend_at",
+		SuspendImmediateTrigger: "suspend_immediately_at",
+	}
+	monitorTimestampLayouts = []string{"2006-01-02", "2006-01-02 15:04", "2006-01-02 15:04:05"}
+)
+
+// action the normalized trigger action, e.g. suspend-immediate, suspend_immediate
+func (t *sfMonitorTriggerSpecification) action() string {
+	return strings.ReplaceAll(normalizeToken(t.Action), "-", "_")
+}
+
+// properties the parameters declared by the spec in a stable order
+func (spec *sfResourceMonitorSpecification) properties() ([]sfProperty, error) {
+	props := make([]sfProperty, 0)
+
+	if spec.CreditQuota != nil {
+		if *spec.CreditQuota <= 0 {
+			return nil, utility.WrapError("creditQuota must be greater than 0", ErrInvalidResourceMonitor)
+		}
+		quota := strconv.FormatFloat(*spec.CreditQuota, 'f', -1, 64)
+		props = append(props, sfProperty{parameter: "CREDIT_QUOTA", column: "credit_quota",
+			value: quota, desired: quota, normalize: normalizeCredits})
+	}
+
+	if spec.Frequency != nil {
+		frequency := normalizeToken(*spec.Frequency)
+		if !utility.Include(monitorFrequencies, frequency) {
+			return nil, utility.WrapError(fmt.Sprintf("frequency [%s]", *spec.Frequency), ErrInvalidResourceMonitor)
+		}
+		props = append(props, sfProperty{parameter: "FREQUENCY", column: "frequency",
+			value: frequency, desired: frequency, normalize: normalizeToken})
+	}
+
+	if spec.StartTimestamp != nil {
+		start := strings.TrimSpace(*spec.StartTimestamp)
+		if strings.EqualFold(start, "IMMEDIATELY") {
+			//the monitor starts when created, the reported start time is the time of creation
+			props = append(props, sfProperty{parameter: "START_TIMESTAMP", value: "IMMEDIATELY", normalize: normalizeToken})
+		} else {
+			ts, err := parseMonitorTimestamp(start)
+			if err != nil {
+				return nil, err
+			}
+			props = append(props, sfProperty{parameter: "START_TIMESTAMP", column: "start_time",
+				value: quoteLiteral(ts), desired: ts, normalize: normalizeMonitorTimestamp})
+		}
+	}
+
+	return props, nil
+}
+
+// triggers the trigger definitions ordered by threshold, along with the summary compared against the current state.
+// Returns an empty definition when triggers are not managed by the spec.
+func (spec *sfResourceMonitorSpecification) triggers() (string, string, error) {
+	if spec.Triggers == nil {
+		return "", "", nil
+	}
+	if len(spec.Triggers) == 0 {
+		return "", "", utility.WrapError("triggers, when declared, require at least one trigger", ErrInvalidResourceMonitor)
+	}
+
+	thresholds := make(map[string][]int)
+	for i := range spec.Triggers {
+		action := spec.Triggers[i].action()
+		if _, ok := monitorTriggerColumns[action]; !ok {
+			return "", "", utility.WrapError(fmt.Sprintf("trigger action [%s]", spec.Triggers[i].Action), ErrInvalidResourceMonitor)
+		}
+		if spec.Triggers[i].Threshold <= 0 {
+			return "", "", utility.WrapError("trigger threshold must be greater than 0", ErrInvalidResourceMonitor)
+		}
+		for _, threshold := range thresholds[action] {
+			if threshold == spec.Triggers[i].Threshold {
+				return "", "", utility.WrapError(fmt.Sprintf("duplicate %s trigger at [%d]", action, threshold), ErrInvalidResourceMonitor)
+			}
+		}
+		thresholds[action] = append(thresholds[action], spec.Triggers[i].Threshold)
+	}
+	if len(thresholds[SuspendTrigger]) > 1 || len(thresholds[SuspendImmediateTrigger]) > 1 {
+		return "", "", utility.WrapError("at most one suspend and one suspend_immediate trigger can be declared", ErrInvalidResourceMonitor)
+	}
+
+	sorted := make([]sfMonitorTriggerSpecification, len(spec.Triggers))
+	copy(sorted, spec.Triggers)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Threshold < sorted[j].Threshold })
+	definitions := make([]string, 0)
+	for _, trigger := range sorted {
+		definitions = append(definitions, fmt.Sprintf("ON %d PERCENT DO %s", trigger.Threshold, trigger.action()))
+	}
+	return strings.Join(definitions, " "), summarizeTriggers(thresholds), nil
+}
+
+// summarizeTriggers a stable representation of the thresholds of each action, e.g. NOTIFY=75,90;SUSPEND=100
+func summarizeTriggers(thresholds map[string][]int) string {
+	parts := make([]string, 0)
+	for _, action := range []string{NotifyTrigger, SuspendTrigger, SuspendImmediateTrigger} {
+		values := append([]int(nil), thresholds[action]...)
+		sort.Ints(values)
+		strs := make([]string, 0)
+		for _, v := range values {
+			strs = append(strs, strconv.Itoa(v))
+		}
+		parts = append(parts, fmt.Sprintf("%s=%s", action, strings.Join(strs, ",")))
+	}
+	return strings.Join(parts, ";")
+}
+
+// currentTriggers summarizes the thresholds reported by SHOW RESOURCE MONITORS, e.g. notify_at 75%,90%
+func currentTriggers(current map[string]string) string {
+	thresholds := make(map[string][]int)
+	for action, column := range monitorTriggerColumns {
+		for _, value := range strings.Split(current[column], ",") {
+			value = strings.TrimSuffix(strings.TrimSpace(value), "%")
+			if threshold, err := strconv.Atoi(value); err == nil {
+				thresholds[action] = append(thresholds[action], threshold)
+			}
+		}
+	}
+	return summarizeTriggers(thresholds)
+}
+
+func parseMonitorTimestamp(value string) (string, error) {
+	for _, layout := range monitorTimestampLayouts {
+		if ts, err := time.Parse(layout, value); err == nil {
+			return ts.Format("2006-01-02 15:04"), nil
+		}
+	}
+	return "", utility.WrapError(fmt.Sprintf("startTimestamp [%s] expected IMMEDIATELY or YYYY-MM-DD [HH:MI]", value), ErrInvalidResourceMonitor)
+}
+
+// normalizeMonitorTimestamp reduces the reported start time to the minute, e.g. 2024-01-01 00:00:00.000 -0800,
+// the time is reported, and declared, within the session time zone
+func normalizeMonitorTimestamp(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) > len("2006-01-02 15:04") {
+		return value[:len("2006-01-02 15:04")]
+	}
+	return value
+}
+
+// normalizeCredits SHOW RESOURCE MONITORS reports quotas with a fixed scale, e.g. 100.00
+func normalizeCredits(value string) string {
+	if credits, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+		return strconv.FormatFloat(credits, 'f', -1, 64)
+	}
+	return normalizeToken(value)
+}
+
+// renderResourceMonitorSpec creates the monitor, or alters the properties and triggers which differ from the current
+// state, then assigns the monitor to the declared warehouses. Statements are issued under the configured monitor role.
+func (sfr *SnowflakeRenderer) renderResourceMonitorSpec(spec *sfResourceMonitorSpecification, item *objects.ChangeItem, params *map[string]interface{}) ([]*objects.ApplyScope, error) {
+	stmts := []string{generateUseRoleStmt(sfr.monitorRole)}
+	vars := *params
+
+	if item.Item.Options.Drop {
+		stmt, err := common.RenderStatement(DropResourceMonitorSQL, (*gonja.Context)(&vars))
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, stmt, generateUseRoleStmt(sfr.defaultRole))
+		return []*objects.ApplyScope{common.NewScope("resource monitor", stmts)}, nil
+	}
+
+	props, err := spec.properties()
+	if err != nil {
+		return nil, err
+	}
+	triggers, summary, err := spec.triggers()
+	if err != nil {
+		return nil, err
+	}
+
+	//monitors are account level objects, the state is read at render time
+	current, exists, err := sfr.inspector.ResourceMonitor(vars["NAME"].(string))
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		vars["PROPERTIES"] = strings.Join(createProperties(props), " ")
+		vars["TRIGGERS"] = triggers
+		stmt, err := common.RenderStatement(CreateResourceMonitorSQL, (*gonja.Context)(&vars))
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, stmt)
+	} else {
+		set, _ := diffProperties(props, current)
+		vars["PROPERTIES"] = strings.Join(set, " ")
+		vars["TRIGGERS"] = ""
+		if len(triggers) > 0 && currentTriggers(current) != summary {
+			vars["TRIGGERS"] = triggers
+		}
+		if len(set) > 0 || len(vars["TRIGGERS"].(string)) > 0 {
+			stmt, err := common.RenderStatement(AlterResourceMonitorSQL, (*gonja.Context)(&vars))
+			if err != nil {
+				return nil, err
+			}
+			stmts = append(stmts, stmt)
+		}
+	}
+
+	//assign the monitor to warehouses not already assigned to it
+	monitor := strings.ToUpper(vars["NAME"].(string))
+	for _, warehouse := range spec.Warehouses {
+		warehouse = strings.ToUpper(strings.TrimSpace(warehouse))
+		whProps, whExists, err := sfr.inspector.Warehouse(warehouse)
+		if err != nil {
+			return nil, err
+		}
+		if !whExists {
+			return nil, utility.WrapError(fmt.Sprintf("warehouse [%s] does not exist, declare resourceMonitor within the warehouse spec", warehouse), ErrInvalidResourceMonitor)
+		}
+		if normalizeToken(whProps["resource_monitor"]) == monitor {
+			continue
+		}
+		stmt, err := common.RenderStatement(AlterWarehouseSetSQL, &gonja.Context{"NAME": warehouse, "PROPERTIES": "RESOURCE_MONITOR = " + monitor})
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, stmt)
+	}
+
+	stmts = append(stmts, generateUseRoleStmt(sfr.defaultRole)) //set role back to default for good measure
+	return []*objects.ApplyScope{common.NewScope("resource monitor", stmts)}, nil
+}
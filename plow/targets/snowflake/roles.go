@@ -0,0 +1,255 @@
+package snowflake
+
+import (
+	"Plow/plow/objects"
+	"Plow/plow/targets/common"
+	"Plow/plow/utility"
+	"fmt"
+	"github.com/noirbizarre/gonja"
+	"sort"
+	"strings"
+)
+
+// roleNode a role within the declared hierarchy, kind is one of RoleGrantee, DatabaseRoleGrantee or UserGrantee
+type roleNode struct {
+	kind string
+	name string
+}
+
+func (n roleNode) String() string {
+	return fmt.Sprintf("%s %s", n.kind, n.name)
+}
+
+// isDatabaseRole database roles are identified by their database qualified name, e.g. DEMO.ANALYST
+func isDatabaseRole(name string) bool {
+	return strings.Contains(name, ".")
+}
+
+// grantedNode the role granted by the association
+func (a *sfRoleAssoc) grantedNode() roleNode {
+	name := strings.ToUpper(strings.TrimSpace(a.Role))
+	if isDatabaseRole(name) {
+		return roleNode{kind: DatabaseRoleGrantee, name: name}
+	}
+	return roleNode{kind: RoleGrantee, name: name}
+}
+
+// granteeNode the role or user receiving the granted role, user names are case-sensitive
+func (a *sfRoleAssoc) granteeNode() (roleNode, error) {
+	id := strings.TrimSpace(a.Object.Identifier)
+	switch strings.ToLower(strings.ReplaceAll(strings.TrimSpace(a.Object.ObjectType), "_", "")) {
+	case "role":
+		return roleNode{kind: RoleGrantee, name: strings.ToUpper(id)}, nil
+	case "databaserole":
+		return roleNode{kind: DatabaseRoleGrantee, name: strings.ToUpper(id)}, nil
+	case "user":
+		return roleNode{kind: UserGrantee, name: id}, nil
+	default:
+		return roleNode{}, utility.WrapError(fmt.Sprintf("grant of [%s] to unsupported object type [%s]", a.Role, a.Object.ObjectType), ErrInvalidRoleSpecification)
+	}
+}
+
+// statement renders the grant, or revoke, of the association
+func (a *sfRoleAssoc) statement(revoke bool) (string, error) {
+	granted := a.grantedNode()
+	grantee, err := a.granteeNode()
+	if err != nil {
+		return "", err
+	}
+
+	var grantSQL, revokeSQL string
+	vars := gonja.Context{"ROLE": granted.name}
+	switch {
+	case granted.kind == RoleGrantee && grantee.kind == RoleGrantee:
+		grantSQL, revokeSQL = GrantRoleToRoleSQL, RevokeRoleToRoleSQL
+		vars["ROLENAME"] = grantee.name
+	case granted.kind == RoleGrantee && grantee.kind == UserGrantee:
+		grantSQL, revokeSQL = GrantRoleToUserSQL, RevokeRoleToUserSQL
+		vars["USER"] = grantee.name
+	case granted.kind == DatabaseRoleGrantee && grantee.kind == RoleGrantee:
+		grantSQL, revokeSQL = GrantDatabaseRoleToRoleSQL, RevokeDatabaseRoleToRoleSQL
+		vars["ROLENAME"] = grantee.name
+	case granted.kind == DatabaseRoleGrantee && grantee.kind == DatabaseRoleGrantee:
+		grantSQL, revokeSQL = GrantDatabaseRoleToDatabaseRoleSQL, RevokeDatabaseRoleToDatabaseRoleSQL
+		vars["ROLENAME"] = grantee.name
+	default:
+		return "", utility.WrapError(fmt.Sprintf("[%s] can not be granted to [%s]", granted, grantee), ErrInvalidRoleSpecification)
+	}
+
+	template := grantSQL
+	if revoke {
+		template = revokeSQL
+	}
+	return common.RenderStatement(template, &vars)
+}
+
+func (d *sfDatabaseRoleSpecification) identifier() string {
+	return strings.ToUpper(strings.TrimSpace(d.Name))
+}
+
+// validate verifies the role names, the grant combinations and the hierarchy formed by the grants. Grants into a
+// protected system role and cycles within the hierarchy are rejected.
+func (spec *sfRoleSpecification) validate() error {
+	for _, role := range spec.Roles {
+		if isDatabaseRole(role) {
+			return utility.WrapError(fmt.Sprintf("[%s] is a database role, declare under databaseRoles", role), ErrInvalidRoleSpecification)
+		}
+	}
+	for _, dbRole := range spec.DatabaseRoles {
+		if parts := strings.Split(dbRole.identifier(), "."); len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			return utility.WrapError(fmt.Sprintf("database role [%s] must be qualified as DATABASE.ROLE", dbRole.Name), ErrInvalidRoleSpecification)
+		}
+		if IsProtectedSystemRole(dbRole.Owner) {
+			return ErrDisallowedPrivilegedRole
+		}
+	}
+
+	edges := make(map[roleNode][]roleNode)
+	for i := range spec.Grants {
+		granted := spec.Grants[i].grantedNode()
+		grantee, err := spec.Grants[i].granteeNode()
+		if err != nil {
+			return err
+		}
+		if grantee.kind == RoleGrantee && IsProtectedSystemRole(grantee.name) {
+			return utility.WrapError(fmt.Sprintf("[%s] granted to protected role [%s]", granted.name, grantee.name), ErrProtectedRoleGrant)
+		}
+		if granted == grantee {
+			return utility.WrapError(fmt.Sprintf("[%s] granted to itself", granted.name), ErrRoleHierarchyCycle)
+		}
+		if _, err := spec.Grants[i].statement(false); err != nil {
+			return err
+		}
+		edges[granted] = append(edges[granted], grantee)
+	}
+
+	return checkRoleHierarchy(edges)
+}
+
+// checkRoleHierarchy walks the grant graph, granted role to grantee, reporting the first cycle found
+func checkRoleHierarchy(edges map[roleNode][]roleNode) error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[roleNode]int)
+	path := make([]roleNode, 0)
+
+	var visit func(node roleNode) error
+	visit = func(node roleNode) error {
+		state[node] = visiting
+		path = append(path, node)
+		for _, next := range edges[node] {
+			switch state[next] {
+			case visiting:
+				names := make([]string, 0)
+				for _, n := range path {
+					names = append(names, n.name)
+				}
+				return utility.WrapError(fmt.Sprintf("[%s -> %s]", strings.Join(names, " -> "), next.name), ErrRoleHierarchyCycle)
+			case unvisited:
+				if err := visit(next); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[node] = visited
+		return nil
+	}
+
+	//walk in a stable order so the reported cycle is deterministic
+	nodes := make([]roleNode, 0)
+	for node := range edges {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].String() < nodes[j].String() })
+	for _, node := range nodes {
+		if state[node] == unvisited {
+			if err := visit(node); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// renderDatabaseRoles creates the declared database roles, each under its owner or the default change mgmt role
+func (sfr *SnowflakeRenderer) renderDatabaseRoles(spec *sfRoleSpecification) ([]string, error) {
+	stmts := make([]string, 0)
+	for _, dbRole := range spec.DatabaseRoles {
+		owner := strings.TrimSpace(dbRole.Owner)
+		if len(owner) == 0 {
+			owner = sfr.defaultRole
+		}
+		stmt, err := common.RenderStatement(CreateDatabaseRoleSQL, &gonja.Context{"ROLE": dbRole.identifier()})
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, generateUseRoleStmt(owner), stmt)
+	}
+	return stmts, nil
+}
+
+// roleHolderRows the holders of an account or database role as reported by SHOW GRANTS OF
+func (sfr *SnowflakeRenderer) roleHolderRows(node roleNode) ([]map[string]string, bool, error) {
+	if node.kind == DatabaseRoleGrantee {
+		return sfr.inspector.DatabaseRoleHolders(node.name)
+	}
+	return sfr.inspector.RoleHolders(node.name)
+}
+
+// declaredHolders the grantees of the role declared by the spec
+func (spec *sfRoleSpecification) declaredHolders(node roleNode) map[roleNode]bool {
+	declared := make(map[roleNode]bool)
+	for i := range spec.Grants {
+		if spec.Grants[i].grantedNode() != node {
+			continue
+		}
+		if grantee, err := spec.Grants[i].granteeNode(); err == nil {
+			declared[grantee] = true
+		}
+	}
+	return declared
+}
+
+// managedRoles the account and database roles declared by the spec
+func (spec *sfRoleSpecification) managedRoles() []roleNode {
+	out := make([]roleNode, 0)
+	for _, role := range spec.Roles {
+		out = append(out, roleNode{kind: RoleGrantee, name: strings.ToUpper(strings.TrimSpace(role))})
+	}
+	for _, dbRole := range spec.DatabaseRoles {
+		out = append(out, roleNode{kind: DatabaseRoleGrantee, name: dbRole.identifier()})
+	}
+	return out
+}
+
+// holderFromShow the grantee of a SHOW GRANTS OF row
+func holderFromShow(row map[string]string) (roleNode, bool) {
+	switch strings.ToUpper(row["granted_to"]) {
+	case "ROLE":
+		return roleNode{kind: RoleGrantee, name: strings.ToUpper(row["grantee_name"])}, true
+	case "DATABASE_ROLE":
+		return roleNode{kind: DatabaseRoleGrantee, name: strings.ToUpper(strings.ReplaceAll(row["grantee_name"], `"`, ""))}, true
+	case "USER":
+		return roleNode{kind: UserGrantee, name: row["grantee_name"]}, true
+	default:
+		return roleNode{}, false
+	}
+}
+
+// revokeHolder the statement revoking the role from the holder
+func revokeHolder(role roleNode, holder roleNode) (string, error) {
+	assoc := sfRoleAssoc{Role: role.name, Object: objects.ObjectDesignation{Identifier: holder.name}}
+	switch holder.kind {
+	case RoleGrantee:
+		assoc.Object.ObjectType = "role"
+	case DatabaseRoleGrantee:
+		assoc.Object.ObjectType = "databaserole"
+	default:
+		assoc.Object.ObjectType = "user"
+	}
+	return assoc.statement(true)
+}
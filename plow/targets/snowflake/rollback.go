@@ -0,0 +1,229 @@
+package snowflake
+
+import (
+	"Plow/plow/objects"
+	"Plow/plow/targets/common"
+	"Plow/plow/utility"
+	"context"
+	"fmt"
+	"github.com/noirbizarre/gonja"
+	"strconv"
+	"time"
+)
+
+// restoreTypes object types a restore point is captured for prior to being changed
+var restoreTypes = map[SnowflakeObjectType]string{Table: "TABLE", Schema: "SCHEMA"}
+
+// ensureRestoreTables creates the restore point and rollback tracking tables when not present, targets established
+// prior to rollback support are migrated on first use. Tables are created under the default role owning the schema.
+func (s *SnowflakeTarget) ensureRestoreTables() error {
+	if err := s.ResetActiveRole(); err != nil {
+		return err
+	}
+	for _, template := range []string{CreateRestorePointsTableSQL, CreateRollbacksTableSQL} {
+		stmt, err := common.RenderStatement(template, &gonja.Context{"DATABASE": s.config.Database})
+		if err != nil {
+			return err
+		}
+		if _, err := s.connection.Exec(stmt); err != nil {
+			return utility.WrapError("unable to create restore point tracking tables:", err)
+		}
+	}
+	return nil
+}
+
+// captureRestorePoint records the existence, owner and retention of a table or schema immediately before the item is
+// applied, the capture time is taken from the target so it is consistent with time travel. Other types are ignored.
+func (s *SnowflakeTarget) captureRestorePoint(item *objects.ChangeItem) error {
+	kind, ok := restoreTypes[StringToSnowflakeObjectType(item.Item.Type)]
+	if !ok || item.Bundle == nil {
+		return nil
+	}
+
+	vars := *common.NewRenderContextFromObjectInfo(item.Item.Object)
+	object := fmt.Sprintf("%s.%s.%s", vars["DATABASE"], vars["SCHEMA"], vars["NAME"])
+	template := GetTableRestoreStateSQL
+	if kind == "SCHEMA" {
+		object = fmt.Sprintf("%s.%s", vars["DATABASE"], vars["NAME"])
+		template = GetSchemaRestoreStateSQL
+	}
+
+	stmt, err := common.RenderStatement(template, (*gonja.Context)(&vars))
+	if err != nil {
+		return err
+	}
+	rows, err := s.renderer.inspector.show(stmt)
+	if err != nil {
+		return utility.WrapError(fmt.Sprintf("unable to capture restore point of [%s]:", object), err)
+	}
+
+	point := objects.RestorePoint{TrackingId: item.Bundle.Ref.Hash, ObjectType: kind, Object: object,
+		Dropped: item.Item.Options.Drop}
+	if len(rows) > 0 {
+		point.Existed = true
+		point.Retention, _ = strconv.Atoi(rows[0]["retention_time"])
+		point.Owner = rows[0]["table_owner"]
+		if kind == "SCHEMA" {
+			point.Owner = rows[0]["schema_owner"]
+		}
+	}
+
+	stmt, err = common.RenderStatement(InsertRestorePointSQL, &gonja.Context{"DATABASE": s.config.Database,
+		"COMMIT":    point.TrackingId,
+		"KIND":      point.ObjectType,
+		"OBJECT":    point.Object,
+		"OWNER":     point.Owner,
+		"EXISTED":   strconv.FormatBool(point.Existed),
+		"DROPPED":   strconv.FormatBool(point.Dropped),
+		"RETENTION": strconv.Itoa(point.Retention)})
+	if err != nil {
+		return err
+	}
+	//the prior change may have left another role active
+	if err := s.ResetActiveRole(); err != nil {
+		return err
+	}
+	_, err = s.connection.Exec(stmt)
+	return err
+}
+
+// getRestorePoints the restore points recorded for the commit in the order they are undone, most recent capture
+// first. An object changed by several items of the commit is restored once to its state prior to the first change.
+func (s *SnowflakeTarget) getRestorePoints(commit string) ([]*objects.RestorePoint, error) {
+	stmt, err := common.RenderStatement(GetRestorePointsSQL, &gonja.Context{"DATABASE": s.config.Database, "COMMIT": commit})
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.connection.Query(stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := make([]*objects.RestorePoint, 0)
+	index := make(map[string]*objects.RestorePoint)
+	for rows.Next() {
+		point := objects.RestorePoint{TrackingId: commit}
+		if err := rows.Scan(&point.ObjectType, &point.Object, &point.Owner, &point.Existed, &point.Dropped,
+			&point.Captured, &point.Retention); err != nil {
+			return nil, err
+		}
+
+		key := fmt.Sprintf("%s %s", point.ObjectType, point.Object)
+		if prior, ok := index[key]; ok {
+			dropped := prior.Dropped || point.Dropped
+			*prior = point
+			prior.Dropped = dropped
+			continue
+		}
+		index[key] = &point
+		points = append(points, &point)
+	}
+	return points, rows.Err()
+}
+
+// restoreStatement the statement returning the object to its captured state, objects created by the commit are
+// dropped, dropped objects are undropped and changed objects are replaced with a time travel clone of themselves
+func restoreStatement(point *objects.RestorePoint, now time.Time) (string, error) {
+	vars := gonja.Context{"KIND": point.ObjectType, "OBJECT": point.Object}
+	if !point.Existed {
+		return common.RenderStatement(DropObjectIfExistsSQL, &vars)
+	}
+
+	if now.Sub(point.Captured) >= time.Duration(point.Retention)*24*time.Hour {
+		return "", utility.WrapError(fmt.Sprintf("[%s] captured %s, retention %d day(s)", point.Object,
+			point.Captured.Format(time.RFC3339), point.Retention), ErrOutsideRetention)
+	}
+
+	if point.Dropped {
+		return common.RenderStatement(UndropObjectSQL, &vars)
+	}
+
+	vars["AT"] = point.Captured.Format("2006-01-02T15:04:05.000000000Z07:00")
+	if point.ObjectType == "SCHEMA" {
+		return common.RenderStatement(RestoreSchemaSQL, &vars)
+	}
+	return common.RenderStatement(RestoreTableSQL, &vars)
+}
+
+// RollbackCommit restores the tables and schemas changed by the commit to their state prior to the commit being
+// applied. Objects are restored under the role that owned them at capture, every restore point is attempted and each
+// outcome is recorded within the tracking tables.
+func (s *SnowflakeTarget) RollbackCommit(context context.Context, commit string) ([]objects.RollbackItemEntry, error) {
+	if err := s.ensureRestoreTables(); err != nil {
+		return nil, err
+	}
+	points, err := s.getRestorePoints(commit)
+	if err != nil {
+		return nil, err
+	}
+	if len(points) == 0 {
+		return nil, common.ErrNoRestorePoints
+	}
+
+	now := time.Now()
+	results := make([]objects.RollbackItemEntry, 0)
+	failed := false
+	for _, point := range points {
+		entry := objects.RollbackItemEntry{TrackingId: commit, ObjectType: point.ObjectType, Object: point.Object}
+		stmt, err := restoreStatement(point, now)
+		if err == nil {
+			entry.Statement = stmt
+			err = s.executeRestore(point, stmt)
+		}
+
+		entry.Status = err == nil
+		entry.ApplyDate = time.Now()
+		if err != nil {
+			entry.Message = err.Error()
+			failed = true
+		}
+		results = append(results, entry)
+
+		//dont halt the rollback for a logging issue of an individual object, logged under the default role
+		if err := s.ResetActiveRole(); err == nil {
+			_ = s.PersistRollbackEntry(&entry)
+		}
+	}
+
+	if err := s.ResetActiveRole(); err != nil {
+		return results, err
+	}
+	if failed {
+		return results, ErrRollbackFailed
+	}
+	return results, nil
+}
+
+// executeRestore runs the restore statement under the owner of the object so the restored object retains its owner
+func (s *SnowflakeTarget) executeRestore(point *objects.RestorePoint, stmt string) error {
+	role := s.config.Role
+	if len(point.Owner) > 0 && !IsProtectedSystemRole(point.Owner) {
+		role = point.Owner
+	}
+	if _, err := s.connection.Exec(generateUseRoleStmt(role)); err != nil {
+		return err
+	}
+	_, err := s.connection.Exec(stmt)
+	return err
+}
+
+func (s *SnowflakeTarget) PersistRollbackEntry(entry *objects.RollbackItemEntry) error {
+	gc := gonja.Context{"DATABASE": s.config.Database,
+		"COMMIT": entry.TrackingId,
+		"KIND":   entry.ObjectType,
+		"OBJECT": entry.Object,
+		"STMT":   common.MakeStringDatabaseSafe(entry.Statement),
+		"STATUS": strconv.FormatBool(entry.Status),
+		"TIME":   entry.ApplyDate.UTC().Format("2006-01-02 15:04:05"),
+		"WHO":    s.config.User,
+		"MSG":    common.MakeStringDatabaseSafe(entry.Message)}
+
+	stmt, err := common.RenderStatement(InsertRollbackSQL, &gc)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.connection.Exec(stmt)
+	return err
+}
@@ -0,0 +1,44 @@
+package snowflake
+
+import (
+	"Plow/plow/targets/common"
+)
+
+// SpecSchemaID identifier of the exported snowflake spec schema
+const SpecSchemaID = "snowflake.schema.json"
+
+// SpecSchema the JSON Schema of snowflake spec files, the spec element of each object type processed by the target is
+// described by the specification the renderer decodes it into
+func SpecSchema() *common.JSONSchema {
+	types := make([]common.SpecSchemaType, 0)
+	for _, sfType := range SnowflakeProcessingOrder {
+		names := sfType.TypeNames()
+		switch sfType {
+		case Role:
+			types = append(types, common.SpecSchemaType{Names: names, Spec: sfRoleSpecification{}})
+		case User:
+			types = append(types, common.SpecSchemaType{Names: names, Spec: sfUserSpecification{}})
+		case ResourceMonitor:
+			types = append(types, common.SpecSchemaType{Names: names, Spec: sfResourceMonitorSpecification{}})
+		case Warehouse:
+			types = append(types, common.SpecSchemaType{Names: names, Spec: sfWarehouseSpecification{}})
+		case Database:
+			types = append(types, common.SpecSchemaType{Names: names, Spec: sfDatabaseSpecification{}})
+		case Schema:
+			types = append(types, common.SpecSchemaType{Names: names, Spec: sfSchemaSpecification{}})
+		case Tag:
+			types = append(types, common.SpecSchemaType{Names: names, Spec: sfTagSpecification{}})
+		case MaskingPolicy, RowAccessPolicy:
+			types = append(types, common.SpecSchemaType{Names: names, Spec: sfPolicySpecification{}})
+		case Table:
+			types = append(types,
+				common.SpecSchemaType{Names: names, Styles: []string{"snowflake"}, Spec: sfDefaultSpecification{}},
+				common.SpecSchemaType{Names: names, Styles: []string{DeclarativeDefinitionStyle}, Spec: sfTableSpecification{}})
+		default:
+			types = append(types, common.SpecSchemaType{Names: names, Spec: sfDefaultSpecification{}})
+		}
+	}
+	//security specs are accepted and ignored by the target
+	types = append(types, common.SpecSchemaType{Names: Security.TypeNames(), Spec: sfDefaultSpecification{}})
+	return common.NewSpecSchema(SpecSchemaID, "Plow snowflake spec", []string{"snowflake", DeclarativeDefinitionStyle}, types)
+}
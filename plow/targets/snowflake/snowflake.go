@@ -4,16 +4,12 @@ import (
 	"Plow/plow/objects"
 	"Plow/plow/secrets"
 	"Plow/plow/targets/common"
+	"Plow/plow/utility"
 	"context"
 	"database/sql"
-	"encoding/pem"
-	"errors"
 	"fmt"
 	"github.com/noirbizarre/gonja"
 	sf "github.com/snowflakedb/gosnowflake"
-	"github.com/youmark/pkcs8"
-	"os"
-	"strconv"
 	"time"
 )
 
@@ -24,6 +20,9 @@ type SnowflakeTarget struct {
 	validation  *common.ValidationHandler
 	options     *objects.Options
 	renderer    *SnowflakeRenderer
+	policy      *common.Policy
+	validators  map[string]common.ValidatorConfiguration
+	snapshot    *common.MetadataSnapshot
 }
 
 func (s *SnowflakeTarget) Open(config SnowflakeConfiguration, options *objects.Options, secretStore secrets.SecretStore) error {
@@ -31,36 +30,36 @@ func (s *SnowflakeTarget) Open(config SnowflakeConfiguration, options *objects.O
 		return ErrDisallowedPrivilegedRole
 	}
 
-	s.renderer = newSnowflakeRenderer(config.Role, config.Warehouse)
-	s.options = options
-	pkeyFile := config.PublicKeyFile
-	s.secretStore = secretStore
-	bytes, err := os.ReadFile(pkeyFile)
-	if err != nil {
+	if err := config.Validate(); err != nil {
 		return err
 	}
 
-	block, _ := pem.Decode(bytes)
-
-	pwd, err := secretStore.GetSecret(config.KeyPasswordSecret)
-	if err != nil {
-		return err
+	var err error
+	if !utility.IsStringEmpty(&config.Policy) {
+		if s.policy, err = common.LoadPolicy(config.Policy); err != nil {
+			return err
+		}
 	}
 
-	pkey, err := pkcs8.ParsePKCS8PrivateKeyRSA(block.Bytes, []byte(pwd))
-	if err != nil {
+	s.options = options
+	s.secretStore = secretStore
+	s.validators = config.Validators
+
+	if s.snapshot, err = common.NewMetadataSnapshot(config.MetadataSnapshot); err != nil {
 		return err
 	}
 
 	s.config = sf.Config{
-		Authenticator: sf.AuthTypeJwt,
-		PrivateKey:    pkey,
-		User:          config.UserId,
-		Account:       config.Account,
-		Region:        config.Region,
-		Database:      config.Database,
-		Warehouse:     config.Warehouse,
-		Role:          config.Role}
+		User:      config.UserId,
+		Account:   config.Account,
+		Region:    config.Region,
+		Database:  config.Database,
+		Warehouse: config.Warehouse,
+		Role:      config.Role}
+
+	if err := applyAuthentication(&config, secretStore, &s.config); err != nil {
+		return err
+	}
 
 	dsn, err := sf.DSN(&s.config)
 	if err != nil {
@@ -73,6 +72,7 @@ func (s *SnowflakeTarget) Open(config SnowflakeConfiguration, options *objects.O
 	}
 
 	s.connection = db
+	s.renderer = newSnowflakeRenderer(&config, newSnowflakeInspector(db), secretStore)
 	return nil
 }
 func (s *SnowflakeTarget) GetTrackingHistory(depth int) (*objects.TrackingLog, error) {
@@ -80,8 +80,6 @@ func (s *SnowflakeTarget) GetTrackingHistory(depth int) (*objects.TrackingLog, e
 	if err != nil {
 		return nil, err
 	}
-	rez := objects.NewTrackingLog()
-
 	if depth > 0 {
 		stmt = fmt.Sprintf("%s LIMIT %d", stmt, depth)
 	}
@@ -91,33 +89,8 @@ func (s *SnowflakeTarget) GetTrackingHistory(depth int) (*objects.TrackingLog, e
 		return nil, err
 	}
 	defer rows.Close()
-	count := 0
-
-	for rows.Next() {
-		var entry objects.LogEntry
-		err := rows.Scan(&entry.TrackingId,
-			&entry.Message,
-			&entry.Start,
-			&entry.End,
-			&entry.AppliedBy,
-			&entry.TotalChanges,
-			&entry.SuccessfulChanges,
-			&entry.FailedChanges,
-			&entry.Completed,
-			&entry.FastForward)
-
-		if err != nil {
-			return nil, err
-		}
-		count += 1
-		rez.Add(entry)
-	}
 
-	if count == 0 {
-		rez.Empty = true
-	}
-
-	return rez, nil
+	return common.ScanTrackingHistory(rows, depth)
 }
 
 func (s *SnowflakeTarget) GetTrackingLogDetail(entry objects.LogEntry) ([]objects.LogItemEntry, error) {
@@ -125,48 +98,7 @@ func (s *SnowflakeTarget) GetTrackingLogDetail(entry objects.LogEntry) ([]object
 }
 
 func (s *SnowflakeTarget) RenderChangeLog(changes *objects.ChangeLog) ([]*common.RenderedChange, error) {
-	if changes == nil {
-		return nil, common.ErrNoChangesProvided
-	}
-	renderedChanges := make([]*common.RenderedChange, 0)
-
-	validationDisabled := s.options.OptionFlags.Has(objects.SkipValidationSetting)
-	//for each bundle in order
-	for _, bundle := range changes.Bundles {
-
-		for _, objType := range SnowflakeProcessingOrder {
-
-			items, err := bundle.GetChangesOfType(int64(objType))
-			if err != nil {
-				return nil, err //can't get a list of objects in the correct order type, time to error and halt
-			}
-			if len(items) > 0 {
-				for _, item := range items {
-					//check the bundle header see if validation was run,
-					//if any changes are configured for validation, we need to stop and not go on for this item
-					if (!bundle.Validated || validationDisabled) && item.Item.Options.Validate {
-						item.ApplyInformation.Executed = false
-						item.ApplyInformation.Completed = false
-						item.ApplyInformation.Error = errors.New("validation not performed and object has validation enabled")
-						continue
-					}
-					//also check if this item failed validation then should be skipped
-					if !validationDisabled && !item.Validation.PassedValidation() {
-						item.ApplyInformation.Executed = false
-						item.ApplyInformation.Completed = false
-						item.ApplyInformation.Error = errors.New("validation not performed and object has validation enabled")
-						continue
-					}
-
-					if rezult := s.renderChange(item); rezult != nil {
-						renderedChanges = append(renderedChanges, rezult)
-					}
-				}
-			}
-		}
-	}
-
-	return renderedChanges, nil
+	return common.RenderChangeLogInOrder(changes, s.GetObjectTypeExecutionOrder(), s.options.OptionFlags.Has(objects.SkipValidationSetting), s.renderChange)
 }
 
 func (s *SnowflakeTarget) ApplyChangeLog(context context.Context, changes *objects.ChangeLog) error {
@@ -190,10 +122,30 @@ func (s *SnowflakeTarget) ApplyChangeLog(context context.Context, changes *objec
 	}
 	defer warehouseCoordinator.DeActivate()
 
+	//the target is about to change, objects created or dropped are not reflected by the metadata snapshot
+	if s.snapshot != nil && len(rendered) > 0 {
+		if err := s.snapshot.Invalidate(); err != nil {
+			return err
+		}
+	}
+
+	//restore points are recorded as tables and schemas are changed, older targets lack the tables
+	if len(rendered) > 0 {
+		if err := s.ensureRestoreTables(); err != nil {
+			return err
+		}
+	}
+
 	//apply rendered changes in order, if error occurs in application halt
 	timeStart := time.Now()
 	for _, renderedChg := range rendered {
-		if err := s.applyChangeToTarget(renderedChg); err != nil {
+		//tables and schemas are captured before being changed so the commit can be rolled back
+		if err := s.captureRestorePoint(renderedChg.Item()); err != nil {
+			renderedChg.Item().ApplyInformation.Error = err
+			return err
+		}
+		//the header scope of each change sets the default role
+		if err := common.ApplyRenderedChange(context, s.connection, renderedChg); err != nil {
 			return err
 		}
 	}
@@ -203,70 +155,7 @@ func (s *SnowflakeTarget) ApplyChangeLog(context context.Context, changes *objec
 		return err
 	}
 
-	//for each bundle gather statistics and log
-	for _, bundle := range changes.Bundles {
-		//for each object type in processing order, get list of changelog items of type
-		total := 0
-		success := 0
-		failed := 0
-
-		//for _, objType := range SnowflakeProcessingOrder {
-		//
-		//	items, err := bundle.GetChangesOfType(int64(objType)) // log in order of type order to match rendering order
-		//	if err != nil {
-		//		return err //can't get a list of objects in the correct order type, time to error and halt
-		//	}
-		//collect and track item level apply metrics
-		for _, item := range bundle.Items {
-			total += 1
-			successful, partial, err := item.ApplyInformation.IsSuccess()
-
-			if successful {
-				success += 1
-			} else {
-				failed += 1
-			}
-
-			var msg string
-			if err != nil {
-				msg = err.Error()
-			}
-
-			logEntry := &objects.LogItemEntry{TrackingId: bundle.Ref.Hash,
-				FileName:  item.Metadata.Name,
-				Reference: item.Metadata.GitHash,
-				Hash:      item.Metadata.IdentifierHash,
-				Status:    successful,
-				ApplyDate: time.Now(),
-				Message:   msg,
-				Partial:   partial}
-			//dont error main process for logging issue of an individual item
-			_ = s.PersistTrackingLogDetail(logEntry)
-		}
-		//}
-		timeEnd := time.Now()
-
-		//gather bundle metrics
-		log := &objects.LogEntry{TrackingId: bundle.Ref.Hash, Message: bundle.Ref.Message,
-			Start:             timeStart,
-			End:               timeEnd,
-			AppliedBy:         s.config.User,
-			TotalChanges:      total,
-			SuccessfulChanges: success,
-			FailedChanges:     failed,
-			Completed:         true,
-			FastForward:       s.options.OptionFlags.Has(objects.FastForwardSetting),
-		}
-
-		// log bundle to tracking
-		err := s.PersistTrackingLogEntry(log)
-		if err != nil {
-			// something occurred saving log to DB, because this is critical to the logic of this solution that these entries exist
-			// error and halt
-			return errors.New(fmt.Sprintf("failed to save to comit log :%s", err.Error()))
-		}
-	}
-	return nil
+	return common.TrackChangeLog(s, changes, timeStart, s.config.User, s.options.OptionFlags.Has(objects.FastForwardSetting))
 }
 
 func (s *SnowflakeTarget) renderChange(item *objects.ChangeItem) *common.RenderedChange {
@@ -287,52 +176,32 @@ func (s *SnowflakeTarget) renderChange(item *objects.ChangeItem) *common.Rendere
 	return common.NewRenderedChange(item, scopes)
 }
 
-func (s *SnowflakeTarget) applyChangeToTarget(renderedChange *common.RenderedChange) error {
-	// apply scopes
-	appliedScopes := 0
-	item := renderedChange.Item()
-	item.ApplyInformation.Executed = true
-	renderedChange.TimeApplied = time.Now()
-
-	//set role to default role
-	if err := s.ResetActiveRole(); err != nil {
-		item.ApplyInformation.Error = err
-		return err
-	}
-
-	for _, scope := range item.ApplyInformation.GetScopes() {
-		appliedCmds := 0
-		for _, cmd := range scope.Commands {
-			_, err := s.connection.Exec(cmd)
-			if err != nil {
-				scope.SetEffectInfo(true, false, appliedCmds > 0 || appliedScopes > 0, err)
-				item.ApplyInformation.Error = err
-				return err
-			}
-			appliedCmds += 1
-		}
-		scope.SetEffectInfo(true, true, false, nil)
-		appliedScopes += 1
-	}
-
-	item.ApplyInformation.Completed = len(item.ApplyInformation.GetScopes()) == appliedScopes
-	return nil
-}
-
 func (s *SnowflakeTarget) ValidateChangeLog(changes *objects.ChangeLog) error {
 	if changes != nil {
 		//initialize the validation handler
 		s.validation = common.NewValidationHandler(StringToSnowflakeObjectTypeInt64)
-		s.validation.RegisterGlobalValidator(newSnowflakeObjectExistsValidator(s, changes))
+		s.validation.RegisterRequiredValidator(newSnowflakeObjectExistsValidator(s, changes))
+		s.validation.RegisterGlobalValidator(common.NewSpecSchemaValidator())
+		if s.policy != nil {
+			s.validation.RegisterGlobalValidator(common.NewPolicyValidator(s.policy, s.renderer, s.options.Environment))
+		}
 		s.validation.RegisterTypeValidator(int64(Table), newSnowflakeTableStructureValidator(s))
+		compileValidator := newSnowflakeCompileValidator(s)
+		for sfType := range compileKinds {
+			s.validation.RegisterTypeValidator(int64(sfType), compileValidator)
+		}
+		if err := s.validation.Configure(s.validators, s.options); err != nil {
+			return err
+		}
 		if err := s.validation.Initialize(); err != nil {
 			return err
 		}
+		defer s.validation.Close()
 
+		if err := s.validation.ValidateItems(changes.Items()); err != nil {
+			return err
+		}
 		for _, bundle := range changes.Bundles {
-			if err := s.validateBundle(bundle); err != nil {
-				return err
-			}
 			bundle.Validated = true
 		}
 	}
@@ -344,63 +213,30 @@ func (s *SnowflakeTarget) Close() error {
 	return s.connection.Close()
 }
 
-func (s *SnowflakeTarget) validateBundle(bundle *objects.ChangeLogBundle) error {
-	if s.validation == nil {
-		return errors.New("ASSERT Validation handler is null")
-	}
-
-	for _, item := range bundle.Items {
-		if err := s.validation.Validate(item); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
 func (s *SnowflakeTarget) PersistTrackingLogDetail(detail *objects.LogItemEntry) error {
-	gc := gonja.Context{"DATABASE": s.config.Database,
-		"COMMIT": detail.TrackingId,
-		"FILE":   detail.FileName,
-		"REF":    detail.Reference,
-		"HASH":   detail.Hash,
-		"STATUS": strconv.FormatBool(detail.Status),
-		"TIME":   detail.ApplyDate.UTC().Format("2006-01-02 15:04:05"),
-		"MSG":    common.MakeStringDatabaseSafe(detail.Message)}
-
-	stmt, err := common.RenderStatement(InsertTrackingDetailSQL, &gc)
+	gc := common.NewTrackingDetailContext(detail)
+	(*gc)["DATABASE"] = s.config.Database
+
+	stmt, err := common.RenderStatement(InsertTrackingDetailSQL, gc)
 	if err != nil {
 		return err
 	}
 
 	_, err = s.connection.Exec(stmt)
-	if err != nil {
-		return err
-	}
-	return nil
+	return err
 }
+
 func (s *SnowflakeTarget) PersistTrackingLogEntry(entry *objects.LogEntry) error {
-	gc := gonja.Context{"DATABASE": s.config.Database,
-		"COMMIT":       entry.TrackingId,
-		"MSG":          common.MakeStringDatabaseSafe(entry.Message),
-		"START":        entry.Start.UTC().Format("2006-01-02 15:04:05"),
-		"END":          entry.End.UTC().Format("2006-01-02 15:04:05"),
-		"WHO":          entry.AppliedBy,
-		"TOTAL":        strconv.Itoa(entry.TotalChanges),
-		"SUCCESS":      strconv.Itoa(entry.SuccessfulChanges),
-		"FAIL":         strconv.Itoa(entry.FailedChanges),
-		"COMPLETED":    strconv.FormatBool(entry.Completed),
-		"FAST_FORWARD": strconv.FormatBool(entry.FastForward)}
-
-	stmt, err := common.RenderStatement(InsertTrackingInfoSQL, &gc)
+	gc := common.NewTrackingEntryContext(entry)
+	(*gc)["DATABASE"] = s.config.Database
+
+	stmt, err := common.RenderStatement(InsertTrackingInfoSQL, gc)
 	if err != nil {
 		return err
 	}
 
 	_, err = s.connection.Exec(stmt)
-	if err != nil {
-		return err
-	}
-	return nil
+	return err
 }
 
 func (s *SnowflakeTarget) GetObjectTypeTranslator() objects.ObjectTypeTranslator {
@@ -421,3 +257,8 @@ func (s *SnowflakeTarget) GetObjectTypeExecutionOrder() []int64 {
 	}
 	return rv
 }
+
+// GetSpecSchema the schema spec files applied to the target are validated against when loaded
+func (s *SnowflakeTarget) GetSpecSchema() *common.JSONSchema {
+	return SpecSchema()
+}
@@ -3,25 +3,42 @@ package snowflake
 import "Plow/plow/objects"
 
 type sfSpecMetadata struct {
-	Owner *objects.ObjectDesignation `yaml:"owner,omitempty"`
+	Owner     *objects.ObjectDesignation `yaml:"owner,omitempty"`
+	Signature string                     `yaml:"signature,omitempty"`
 }
 
 type sfRoleAssoc struct {
 	Role   string                    `yaml:"role"`
 	Object objects.ObjectDesignation `yaml:"object"`
 }
+type sfDatabaseRoleSpecification struct {
+	Name  string `yaml:"name"`
+	Owner string `yaml:"owner"`
+}
 type sfRoleSpecification struct {
-	Roles   []string      `yaml:"roles"`
-	Grants  []sfRoleAssoc `yaml:"grants"`
-	Revokes []sfRoleAssoc `yaml:"revoke"`
+	Roles         []string                      `yaml:"roles"`
+	DatabaseRoles []sfDatabaseRoleSpecification `yaml:"databaseRoles"`
+	Grants        []sfRoleAssoc                 `yaml:"grants"`
+	Revokes       []sfRoleAssoc                 `yaml:"revoke"`
+	Reconcile     bool                          `yaml:"reconcile"`
 }
 
 type sfDefaultSpecification struct {
-	Metadata sfSpecMetadata `yaml:"meta"`
-	Pre      string         `yaml:"pre"`
-	Init     string         `yaml:"init"`
-	Change   string         `yaml:"change"`
-	Post     string         `yaml:"post"`
+	Metadata sfSpecMetadata         `yaml:"meta"`
+	Pre      string                 `yaml:"pre"`
+	Init     string                 `yaml:"init"`
+	Change   string                 `yaml:"change"`
+	Post     string                 `yaml:"post"`
+	Grants   []sfGrantSpecification `yaml:"grants,omitempty"`
+
+	sfAttachmentSpecification `yaml:",inline"`
+}
+
+// sfGrantSpecification privileges on the object granted to an account role or database role
+type sfGrantSpecification struct {
+	Privileges   []string `yaml:"privileges"`
+	Role         string   `yaml:"role,omitempty"`
+	DatabaseRole string   `yaml:"databaseRole,omitempty"`
 }
 
 type sfUsageSpecification struct {
@@ -29,17 +46,137 @@ type sfUsageSpecification struct {
 	Revokes []objects.ObjectDesignation `yaml:"revoke"`
 }
 
+// sfFutureGrantSpecification privileges granted on objects of a kind created in the future within a database or schema
+type sfFutureGrantSpecification struct {
+	ObjectKind           string `yaml:"objectKind"`
+	sfGrantSpecification `yaml:",inline"`
+}
+
 type sfDatabaseSpecification struct {
-	Owner objects.ObjectDesignation `yaml:"owner"`
-	Usage sfUsageSpecification      `yaml:"usage"`
+	Owner        objects.ObjectDesignation    `yaml:"owner"`
+	Usage        sfUsageSpecification         `yaml:"usage"`
+	FutureGrants []sfFutureGrantSpecification `yaml:"futureGrants,omitempty"`
+	Reconcile    bool                         `yaml:"reconcile"`
 }
 
 type sfSchemaSpecification struct {
-	Owner objects.ObjectDesignation `yaml:"owner"`
-	Usage sfUsageSpecification      `yaml:"usage"`
+	Owner        objects.ObjectDesignation    `yaml:"owner"`
+	Usage        sfUsageSpecification         `yaml:"usage"`
+	FutureGrants []sfFutureGrantSpecification `yaml:"futureGrants,omitempty"`
+	Reconcile    bool                         `yaml:"reconcile"`
 }
 
+// sfWarehouseSpecification declarative warehouse definition, properties left unset are not managed
 type sfWarehouseSpecification struct {
-	Owner objects.ObjectDesignation `yaml:"owner"`
-	Usage sfUsageSpecification      `yaml:"usage"`
+	Owner              objects.ObjectDesignation `yaml:"owner"`
+	Usage              sfUsageSpecification      `yaml:"usage"`
+	Size               *string                   `yaml:"size,omitempty"`
+	Type               *string                   `yaml:"type,omitempty"`
+	AutoSuspend        *int                      `yaml:"autoSuspend,omitempty"`
+	AutoResume         *bool                     `yaml:"autoResume,omitempty"`
+	InitiallySuspended *bool                     `yaml:"initiallySuspended,omitempty"`
+	MinClusterCount    *int                      `yaml:"minClusterCount,omitempty"`
+	MaxClusterCount    *int                      `yaml:"maxClusterCount,omitempty"`
+	ScalingPolicy      *string                   `yaml:"scalingPolicy,omitempty"`
+	ResourceMonitor    *string                   `yaml:"resourceMonitor,omitempty"`
+	Comment            *string                   `yaml:"comment,omitempty"`
+	Reconcile          bool                      `yaml:"reconcile"`
+}
+
+type sfUserSpecification struct {
+	Owner            objects.ObjectDesignation `yaml:"owner"`
+	LoginName        *string                   `yaml:"loginName,omitempty"`
+	Type             *string                   `yaml:"type,omitempty"`
+	DefaultRole      *string                   `yaml:"defaultRole,omitempty"`
+	DefaultWarehouse *string                   `yaml:"defaultWarehouse,omitempty"`
+	DefaultNamespace *string                   `yaml:"defaultNamespace,omitempty"`
+	Disabled         *bool                     `yaml:"disabled,omitempty"`
+	PublicKeySecrets []string                  `yaml:"publicKeySecrets,omitempty"`
+	Comment          *string                   `yaml:"comment,omitempty"`
+}
+
+type sfMonitorTriggerSpecification struct {
+	Threshold int    `yaml:"threshold"`
+	Action    string `yaml:"action"`
+}
+type sfResourceMonitorSpecification struct {
+	CreditQuota    *float64                        `yaml:"creditQuota,omitempty"`
+	Frequency      *string                         `yaml:"frequency,omitempty"`
+	StartTimestamp *string                         `yaml:"startTimestamp,omitempty"`
+	Triggers       []sfMonitorTriggerSpecification `yaml:"triggers,omitempty"`
+	Warehouses     []string                        `yaml:"warehouses,omitempty"`
+}
+
+type sfPolicyArgument struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type"`
+}
+type sfPolicySpecification struct {
+	Metadata  sfSpecMetadata         `yaml:"meta"`
+	Signature []sfPolicyArgument     `yaml:"signature"`
+	Returns   string                 `yaml:"returns"`
+	Body      string                 `yaml:"body"`
+	Comment   *string                `yaml:"comment,omitempty"`
+	Grants    []sfGrantSpecification `yaml:"grants,omitempty"`
+}
+type sfTagSpecification struct {
+	Metadata      sfSpecMetadata         `yaml:"meta"`
+	AllowedValues []string               `yaml:"allowedValues,omitempty"`
+	Comment       *string                `yaml:"comment,omitempty"`
+	Grants        []sfGrantSpecification `yaml:"grants,omitempty"`
+}
+
+type sfMaskingPolicyAttachment struct {
+	Column string `yaml:"column"`
+	Policy string `yaml:"policy"`
+}
+type sfRowAccessPolicyAttachment struct {
+	Policy string   `yaml:"policy"`
+	On     []string `yaml:"on"`
+}
+type sfTagAssignment struct {
+	Tag    string `yaml:"tag"`
+	Value  string `yaml:"value"`
+	Column string `yaml:"column,omitempty"`
+}
+type sfAttachmentSpecification struct {
+	MaskingPolicies []sfMaskingPolicyAttachment  `yaml:"maskingPolicies,omitempty"`
+	RowAccessPolicy *sfRowAccessPolicyAttachment `yaml:"rowAccessPolicy,omitempty"`
+	Tags            []sfTagAssignment            `yaml:"tags,omitempty"`
+}
+
+type sfColumnSpecification struct {
+	Name     string  `yaml:"name"`
+	Type     string  `yaml:"type"`
+	Nullable *bool   `yaml:"nullable,omitempty"`
+	Default  *string `yaml:"default,omitempty"`
+	Comment  *string `yaml:"comment,omitempty"`
+}
+
+type sfConstraintReference struct {
+	Table   string   `yaml:"table"`
+	Columns []string `yaml:"columns"`
+}
+
+type sfConstraintSpecification struct {
+	Name       string                 `yaml:"name"`
+	Type       string                 `yaml:"type"`
+	Columns    []string               `yaml:"columns"`
+	References *sfConstraintReference `yaml:"references,omitempty"`
+}
+
+// sfTableSpecification declarative table definition, used when the definition style is declarative
+type sfTableSpecification struct {
+	Metadata              sfSpecMetadata              `yaml:"meta"`
+	Columns               []sfColumnSpecification     `yaml:"columns"`
+	ClusterBy             []string                    `yaml:"clusterBy,omitempty"`
+	Constraints           []sfConstraintSpecification `yaml:"constraints,omitempty"`
+	Comment               *string                     `yaml:"comment,omitempty"`
+	AllowNarrowing        bool                        `yaml:"allowNarrowing"`
+	DropUndeclaredColumns bool                        `yaml:"dropUndeclaredColumns"`
+	Pre                   string                      `yaml:"pre"`
+	Post                  string                      `yaml:"post"`
+	Grants                []sfGrantSpecification      `yaml:"grants,omitempty"`
+
+	sfAttachmentSpecification `yaml:",inline"`
 }
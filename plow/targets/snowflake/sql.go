@@ -9,41 +9,108 @@ const (
 	GetDatabasesSQL          = "SELECT * FROM {{DATABASE}}.PLOW.MANAGED_DATABASES;"
 	GetSchemasSQL            = "SELECT SCHEMA_NAME FROM {{DATABASE}}.INFORMATION_SCHEMA.SCHEMATA"
 	GetTablesViewsSQL        = "SELECT TABLE_SCHEMA, TABLE_NAME, TABLE_TYPE FROM {{DATABASE}}.INFORMATION_SCHEMA.TABLES"
-	TableStructureCLeanUpSQL = "DROP TABLE IF EXISTS {{CHG_MGMT_DB}}.ORIGIN.{{NAME}}; DROP TABLE IF EXISTS {{CHG_MGMT_DB}}.VALIDATE.{{NAME}};"
-	CreateMocTableSQL        = "CREATE TABLE {{CHG_MGMT_DB}}.ORIGIN.{{NAME}} LIKE {{DATABASE}}.{{SCHEMA}}.{{NAME}}"
-	TableStructureVerifySQL  = `WITH ORIGIN (NAME, POS, NULLABLE, DTYPE, CHARLEN, PRECISION, SCALE) AS 
+	ShowObjectsInDatabaseSQL = "SHOW {{KINDS}} IN DATABASE {{DATABASE}};"
+	ShowAccountObjectsSQL    = "SHOW {{KINDS}} LIKE '{{NAME}}';"
+	TableStructureCLeanUpSQL = "DROP TABLE IF EXISTS {{CHG_MGMT_DB}}.ORIGIN.{{MOCK}}; DROP TABLE IF EXISTS {{CHG_MGMT_DB}}.VALIDATE.{{MOCK}};"
+	CreateMocTableSQL        = "CREATE TABLE {{CHG_MGMT_DB}}.ORIGIN.{{MOCK}} LIKE {{DATABASE}}.{{SCHEMA}}.{{NAME}}"
+	TableStructureVerifySQL  = `WITH ORIGIN (NAME, POS, NULLABLE, DTYPE, CHARLEN, PRECISION, SCALE, DEFAULT_VALUE, COMMENT) AS 
 								(     
-								SELECT COLUMN_NAME, ORDINAL_POSITION, IS_NULLABLE, DATA_TYPE, CHARACTER_MAXIMUM_LENGTH, NUMERIC_PRECISION, NUMERIC_SCALE
+								SELECT COLUMN_NAME, ORDINAL_POSITION, IS_NULLABLE, DATA_TYPE, CHARACTER_MAXIMUM_LENGTH, NUMERIC_PRECISION, NUMERIC_SCALE, COLUMN_DEFAULT, COMMENT
 								FROM {{CHG_MGMT_DB}}.INFORMATION_SCHEMA.COLUMNS 
 								WHERE TABLE_SCHEMA = 'ORIGIN' AND TABLE_NAME = '{{NAME}}'
 								),
-								TARGET (NAME, POS, NULLABLE, DTYPE, CHARLEN, PRECISION, SCALE) AS (  
-									SELECT COLUMN_NAME, ORDINAL_POSITION, IS_NULLABLE, DATA_TYPE, CHARACTER_MAXIMUM_LENGTH, NUMERIC_PRECISION, NUMERIC_SCALE
+								TARGET (NAME, POS, NULLABLE, DTYPE, CHARLEN, PRECISION, SCALE, DEFAULT_VALUE, COMMENT) AS (  
+									SELECT COLUMN_NAME, ORDINAL_POSITION, IS_NULLABLE, DATA_TYPE, CHARACTER_MAXIMUM_LENGTH, NUMERIC_PRECISION, NUMERIC_SCALE, COLUMN_DEFAULT, COMMENT
 									FROM {{CHG_MGMT_DB}}.INFORMATION_SCHEMA.COLUMNS
 									WHERE TABLE_SCHEMA = 'VALIDATE' AND TABLE_NAME = '{{NAME}}'
 								)
 								SELECT * FROM ORIGIN O FULL OUTER JOIN TARGET T ON O.NAME = T.NAME;`
-	CreateDatabaseSQL               = "CREATE DATABASE {{NAME}};"
-	GrantDatabaseOwnershipSQL       = "GRANT OWNERSHIP ON DATABASE {{NAME}} TO ROLE {{ROLE}};"
-	GrantUsageToRoleDatabaseSQL     = "GRANT USAGE ON DATABASE {{NAME}} TO ROLE {{ROLE}};"
-	RevokeUsageToRoleDatabaseSQL    = "REVOKE USAGE ON DATABASE {{NAME}} FROM ROLE {{ROLE}};"
-	GrantUsageToUserDatabaseSQL     = "GRANT USAGE ON DATABASE {{NAME}} TO USER \"{{USER}}\";"
-	RevokeUsageToUserDatabaseSQL    = "REVOKE USAGE ON DATABASE {{NAME}} FROM USER \"{{USER}}\";"
-	DropDatabaseSQL                 = "DROP DATABASE {{NAME}};"
-	CreateSchemaSQL                 = "CREATE SCHEMA IF NOT EXISTS {{DATABASE}}.{{NAME}};"
-	DropSchemaSQL                   = "DROP SCHEMA {{DATABASE}}.{{NAME}};"
-	GrantUsageToRoleSchemaSQL       = "GRANT USAGE ON SCHEMA {{DATABASE}}.{{NAME}} TO ROLE {{ROLE}};"
-	RevokeUsageToRoleSchemaSQL      = "REVOKE USAGE ON SCHEMA {{DATABASE}}.{{NAME}} FROM ROLE {{ROLE}};"
-	GrantUsageToUserSchemaSQL       = "GRANT USAGE ON SCHEMA {{DATABASE}}.{{NAME}} TO USER \"{{USER}}\";"
-	RevokeUsageToUserSchemaSQL      = "REVOKE USAGE ON SCHEMA {{DATABASE}}.{{NAME}} FROM USER \"{{USER}}\";"
-	CreateRoleSQL                   = "CREATE ROLE IF NOT EXISTS {{ROLE}};"
-	GrantRoleToRoleSQL              = "GRANT ROLE {{ROLE}} TO ROLE {{ROLENAME}};"
-	GrantRoleToUserSQL              = "GRANT ROLE {{ROLE}} TO USER \"{{USER}}\";"
-	RevokeRoleToRoleSQL             = "REVOKE ROLE {{ROLE}} FROM ROLE {{ROLENAME}};"
-	RevokeRoleToUserSQL             = "REVOKE ROLE {{ROLE}} TO USER \"{{USER}}\";"
-	GrantUsageOnWarehouseToRoleSQL  = "GRANT USAGE ON WAREHOUSE {{NAME}} TO ROLE {{ROLE}};"
-	GrantUsageOnWarehouseToUserSQL  = "GRANT USAGE ON WAREHOUSE {{NAME}} TO USER {{USER}};"
-	RevokeUsageOnWarehouseToRoleSQL = "REVOKE USAGE ON WAREHOUSE {{NAME}} FROM ROLE {{ROLE}};"
-	RevokeUsageOnWarehouseToUserSQL = "REVOKE USAGE ON WAREHOUSE {{NAME}} FROM USER {{USER}};"
-	DropDefaultPublicSchemaSQL      = "DROP SCHEMA {{NAME}}.PUBLIC;"
+	TableClusteringVerifySQL            = "SELECT TABLE_SCHEMA, CLUSTERING_KEY FROM {{CHG_MGMT_DB}}.INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA IN ('ORIGIN', 'VALIDATE') AND TABLE_NAME = '{{NAME}}'"
+	CreateDatabaseSQL                   = "CREATE DATABASE {{NAME}};"
+	GrantDatabaseOwnershipSQL           = "GRANT OWNERSHIP ON DATABASE {{NAME}} TO ROLE {{ROLE}};"
+	GrantUsageToRoleDatabaseSQL         = "GRANT USAGE ON DATABASE {{NAME}} TO ROLE {{ROLE}};"
+	RevokeUsageToRoleDatabaseSQL        = "REVOKE USAGE ON DATABASE {{NAME}} FROM ROLE {{ROLE}};"
+	GrantUsageToUserDatabaseSQL         = "GRANT USAGE ON DATABASE {{NAME}} TO USER \"{{USER}}\";"
+	RevokeUsageToUserDatabaseSQL        = "REVOKE USAGE ON DATABASE {{NAME}} FROM USER \"{{USER}}\";"
+	DropDatabaseSQL                     = "DROP DATABASE {{NAME}};"
+	CreateSchemaSQL                     = "CREATE SCHEMA IF NOT EXISTS {{DATABASE}}.{{NAME}};"
+	DropSchemaSQL                       = "DROP SCHEMA {{DATABASE}}.{{NAME}};"
+	GrantUsageToRoleSchemaSQL           = "GRANT USAGE ON SCHEMA {{DATABASE}}.{{NAME}} TO ROLE {{ROLE}};"
+	RevokeUsageToRoleSchemaSQL          = "REVOKE USAGE ON SCHEMA {{DATABASE}}.{{NAME}} FROM ROLE {{ROLE}};"
+	GrantUsageToUserSchemaSQL           = "GRANT USAGE ON SCHEMA {{DATABASE}}.{{NAME}} TO USER \"{{USER}}\";"
+	RevokeUsageToUserSchemaSQL          = "REVOKE USAGE ON SCHEMA {{DATABASE}}.{{NAME}} FROM USER \"{{USER}}\";"
+	CreateRoleSQL                       = "CREATE ROLE IF NOT EXISTS {{ROLE}};"
+	GrantRoleToRoleSQL                  = "GRANT ROLE {{ROLE}} TO ROLE {{ROLENAME}};"
+	GrantRoleToUserSQL                  = "GRANT ROLE {{ROLE}} TO USER \"{{USER}}\";"
+	RevokeRoleToRoleSQL                 = "REVOKE ROLE {{ROLE}} FROM ROLE {{ROLENAME}};"
+	RevokeRoleToUserSQL                 = "REVOKE ROLE {{ROLE}} FROM USER \"{{USER}}\";"
+	GrantUsageOnWarehouseToRoleSQL      = "GRANT USAGE ON WAREHOUSE {{NAME}} TO ROLE {{ROLE}};"
+	GrantUsageOnWarehouseToUserSQL      = "GRANT USAGE ON WAREHOUSE {{NAME}} TO USER {{USER}};"
+	RevokeUsageOnWarehouseToRoleSQL     = "REVOKE USAGE ON WAREHOUSE {{NAME}} FROM ROLE {{ROLE}};"
+	RevokeUsageOnWarehouseToUserSQL     = "REVOKE USAGE ON WAREHOUSE {{NAME}} FROM USER {{USER}};"
+	DropDefaultPublicSchemaSQL          = "DROP SCHEMA {{NAME}}.PUBLIC;"
+	ShowWarehousesSQL                   = "SHOW WAREHOUSES LIKE '{{NAME}}';"
+	CreateWarehouseSQL                  = "CREATE WAREHOUSE IF NOT EXISTS {{NAME}}{% if PROPERTIES %} WITH {{PROPERTIES}}{% endif %};"
+	AlterWarehouseSetSQL                = "ALTER WAREHOUSE {{NAME}} SET {{PROPERTIES}};"
+	AlterWarehouseUnsetSQL              = "ALTER WAREHOUSE {{NAME}} UNSET {{PROPERTIES}};"
+	GrantWarehouseOwnershipSQL          = "GRANT OWNERSHIP ON WAREHOUSE {{NAME}} TO ROLE {{ROLE}} COPY CURRENT GRANTS;"
+	DropWarehouseSQL                    = "DROP WAREHOUSE IF EXISTS {{NAME}};"
+	GetTableStateSQL                    = "SELECT TABLE_TYPE, CLUSTERING_KEY, COMMENT FROM {{DATABASE}}.INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = '{{SCHEMA}}' AND TABLE_NAME = '{{NAME}}'"
+	GetTableColumnsSQL                  = "SELECT COLUMN_NAME, DATA_TYPE, IS_NULLABLE, COLUMN_DEFAULT, COMMENT, CHARACTER_MAXIMUM_LENGTH, NUMERIC_PRECISION, NUMERIC_SCALE FROM {{DATABASE}}.INFORMATION_SCHEMA.COLUMNS WHERE TABLE_SCHEMA = '{{SCHEMA}}' AND TABLE_NAME = '{{NAME}}' ORDER BY ORDINAL_POSITION"
+	GetTableConstraintsSQL              = "SELECT CONSTRAINT_NAME, CONSTRAINT_TYPE FROM {{DATABASE}}.INFORMATION_SCHEMA.TABLE_CONSTRAINTS WHERE TABLE_SCHEMA = '{{SCHEMA}}' AND TABLE_NAME = '{{NAME}}'"
+	CreateTableSQL                      = "CREATE TABLE {{DATABASE}}.{{SCHEMA}}.{{NAME}} ({{DEFINITION}}){% if CLUSTER_BY %} CLUSTER BY ({{CLUSTER_BY}}){% endif %}{% if COMMENT %} COMMENT = {{COMMENT}}{% endif %};"
+	AlterTableSQL                       = "ALTER TABLE {{DATABASE}}.{{SCHEMA}}.{{NAME}} {{ACTION}};"
+	DropTableSQL                        = "DROP TABLE IF EXISTS {{DATABASE}}.{{SCHEMA}}.{{NAME}};"
+	ShowGrantsOnSQL                     = "SHOW GRANTS ON {{KIND}} {{OBJECT}};"
+	GrantPrivilegesSQL                  = "GRANT {{PRIVILEGES}} ON {{KIND}} {{OBJECT}} TO {{GRANTEE_KIND}} {{GRANTEE}};"
+	RevokePrivilegesSQL                 = "REVOKE {{PRIVILEGES}} ON {{KIND}} {{OBJECT}} FROM {{GRANTEE_KIND}} {{GRANTEE}};"
+	ShowFutureGrantsSQL                 = "SHOW FUTURE GRANTS IN {{CONTAINER_KIND}} {{CONTAINER}};"
+	GrantFutureSQL                      = "GRANT {{PRIVILEGES}} ON FUTURE {{KIND}} IN {{CONTAINER_KIND}} {{CONTAINER}} TO {{GRANTEE_KIND}} {{GRANTEE}};"
+	RevokeFutureSQL                     = "REVOKE {{PRIVILEGES}} ON FUTURE {{KIND}} IN {{CONTAINER_KIND}} {{CONTAINER}} FROM {{GRANTEE_KIND}} {{GRANTEE}};"
+	ShowRolesSQL                        = "SHOW ROLES LIKE '{{ROLE}}';"
+	ShowGrantsOfRoleSQL                 = "SHOW GRANTS OF ROLE {{ROLE}};"
+	CreateDatabaseRoleSQL               = "CREATE DATABASE ROLE IF NOT EXISTS {{ROLE}};"
+	GrantDatabaseRoleToRoleSQL          = "GRANT DATABASE ROLE {{ROLE}} TO ROLE {{ROLENAME}};"
+	RevokeDatabaseRoleToRoleSQL         = "REVOKE DATABASE ROLE {{ROLE}} FROM ROLE {{ROLENAME}};"
+	GrantDatabaseRoleToDatabaseRoleSQL  = "GRANT DATABASE ROLE {{ROLE}} TO DATABASE ROLE {{ROLENAME}};"
+	RevokeDatabaseRoleToDatabaseRoleSQL = "REVOKE DATABASE ROLE {{ROLE}} FROM DATABASE ROLE {{ROLENAME}};"
+	ShowDatabaseRolesSQL                = "SHOW DATABASE ROLES IN DATABASE {{DATABASE}};"
+	ShowGrantsOfDatabaseRoleSQL         = "SHOW GRANTS OF DATABASE ROLE {{ROLE}};"
+	ShowUsersSQL                        = "SHOW USERS LIKE '{{NAME}}';"
+	DescribeUserSQL                     = "DESC USER {{NAME}};"
+	CreateUserSQL                       = "CREATE USER IF NOT EXISTS {{NAME}}{% if PROPERTIES %} {{PROPERTIES}}{% endif %};"
+	AlterUserSetSQL                     = "ALTER USER {{NAME}} SET {{PROPERTIES}};"
+	AlterUserUnsetSQL                   = "ALTER USER {{NAME}} UNSET {{PROPERTIES}};"
+	GrantUserOwnershipSQL               = "GRANT OWNERSHIP ON USER {{NAME}} TO ROLE {{ROLE}} COPY CURRENT GRANTS;"
+	DropUserSQL                         = "DROP USER IF EXISTS {{NAME}};"
+	ShowResourceMonitorsSQL             = "SHOW RESOURCE MONITORS LIKE '{{NAME}}';"
+	CreateResourceMonitorSQL            = "CREATE RESOURCE MONITOR IF NOT EXISTS {{NAME}}{% if PROPERTIES or TRIGGERS %} WITH{% if PROPERTIES %} {{PROPERTIES}}{% endif %}{% if TRIGGERS %} TRIGGERS {{TRIGGERS}}{% endif %}{% endif %};"
+	AlterResourceMonitorSQL             = "ALTER RESOURCE MONITOR {{NAME}}{% if PROPERTIES %} SET {{PROPERTIES}}{% endif %}{% if TRIGGERS %} TRIGGERS {{TRIGGERS}}{% endif %};"
+	DropResourceMonitorSQL              = "DROP RESOURCE MONITOR IF EXISTS {{NAME}};"
+	ShowSchemaObjectsSQL                = "SHOW {{KINDS}} LIKE '{{NAME}}' IN SCHEMA {{DATABASE}}.{{SCHEMA}};"
+	DescribeSchemaObjectSQL             = "DESC {{KIND}} {{DATABASE}}.{{SCHEMA}}.{{NAME}};"
+	AlterSchemaObjectSQL                = "ALTER {{KIND}} {{DATABASE}}.{{SCHEMA}}.{{NAME}} {{ACTION}};"
+	DropSchemaObjectSQL                 = "DROP {{KIND}} IF EXISTS {{DATABASE}}.{{SCHEMA}}.{{NAME}};"
+	CreatePolicySQL                     = "CREATE {{KIND}} IF NOT EXISTS {{DATABASE}}.{{SCHEMA}}.{{NAME}} AS ({{SIGNATURE}}) RETURNS {{RETURNS}} -> {{BODY}}{% if COMMENT %} COMMENT = {{COMMENT}}{% endif %};"
+	CreateTagSQL                        = "CREATE TAG IF NOT EXISTS {{DATABASE}}.{{SCHEMA}}.{{NAME}}{% if ALLOWED_VALUES %} ALLOWED_VALUES {{ALLOWED_VALUES}}{% endif %}{% if COMMENT %} COMMENT = {{COMMENT}}{% endif %};"
+	GetPolicyReferencesSQL              = "SELECT POLICY_DB, POLICY_SCHEMA, POLICY_NAME, POLICY_KIND, REF_COLUMN_NAME, REF_ARG_COLUMN_NAMES FROM TABLE({{DATABASE}}.INFORMATION_SCHEMA.POLICY_REFERENCES(REF_ENTITY_NAME => '{{OBJECT}}', REF_ENTITY_DOMAIN => '{{DOMAIN}}'))"
+	GetTagReferencesSQL                 = "SELECT TAG_DATABASE, TAG_SCHEMA, TAG_NAME, TAG_VALUE, LEVEL, COLUMN_NAME FROM TABLE({{DATABASE}}.INFORMATION_SCHEMA.TAG_REFERENCES('{{OBJECT}}', 'TABLE'))"
+	GetColumnTagReferencesSQL           = "SELECT TAG_DATABASE, TAG_SCHEMA, TAG_NAME, TAG_VALUE, LEVEL, COLUMN_NAME FROM TABLE({{DATABASE}}.INFORMATION_SCHEMA.TAG_REFERENCES_ALL_COLUMNS('{{OBJECT}}', 'TABLE'))"
+	AlterObjectSQL                      = "ALTER {{KIND}} {{OBJECT}} {{ACTION}};"
+	ShowDatabasesSQL                    = "SHOW DATABASES LIKE '{{NAME}}';"
+	CloneDatabaseSQL                    = "CREATE DATABASE {{CLONE}} CLONE {{NAME}};"
+	DropDatabaseIfExistsSQL             = "DROP DATABASE IF EXISTS {{NAME}};"
+	GrantDatabaseOwnershipCopySQL       = "GRANT OWNERSHIP ON DATABASE {{NAME}} TO ROLE {{ROLE}} COPY CURRENT GRANTS;"
+	GetTableRestoreStateSQL             = "SELECT RETENTION_TIME, TABLE_OWNER FROM {{DATABASE}}.INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = '{{SCHEMA}}' AND TABLE_NAME = '{{NAME}}'"
+	GetSchemaRestoreStateSQL            = "SELECT RETENTION_TIME, SCHEMA_OWNER FROM {{DATABASE}}.INFORMATION_SCHEMA.SCHEMATA WHERE SCHEMA_NAME = '{{NAME}}'"
+	CreateRestorePointsTableSQL         = "CREATE TABLE IF NOT EXISTS {{DATABASE}}.PLOW.RESTORE_POINTS (COMMIT_ID VARCHAR(100) NOT NULL, OBJECT_TYPE VARCHAR(20) NOT NULL, OBJECT_NAME VARCHAR NOT NULL, OWNER VARCHAR(500) NOT NULL, EXISTED BOOLEAN NOT NULL, DROPPED BOOLEAN NOT NULL, CAPTURED_AT TIMESTAMP_LTZ NOT NULL, RETENTION INT NOT NULL DEFAULT 0)"
+	CreateRollbacksTableSQL             = "CREATE TABLE IF NOT EXISTS {{DATABASE}}.PLOW.ROLLBACKS (COMMIT_ID VARCHAR(100) NOT NULL, OBJECT_TYPE VARCHAR(20) NOT NULL, OBJECT_NAME VARCHAR NOT NULL, STMT VARCHAR NOT NULL, STATUS BOOLEAN NOT NULL, EXEC_TIME TIMESTAMP_NTZ NOT NULL, EXEC_WHO VARCHAR(500) NOT NULL, MSG VARCHAR NOT NULL)"
+	InsertRestorePointSQL               = "INSERT INTO {{DATABASE}}.PLOW.RESTORE_POINTS SELECT '{{COMMIT}}', '{{KIND}}', '{{OBJECT}}', '{{OWNER}}', {{EXISTED}}, {{DROPPED}}, CURRENT_TIMESTAMP(), {{RETENTION}}"
+	GetRestorePointsSQL                 = "SELECT OBJECT_TYPE, OBJECT_NAME, OWNER, EXISTED, DROPPED, CAPTURED_AT, RETENTION FROM {{DATABASE}}.PLOW.RESTORE_POINTS WHERE COMMIT_ID = '{{COMMIT}}' ORDER BY CAPTURED_AT DESC"
+	InsertRollbackSQL                   = "INSERT INTO {{DATABASE}}.PLOW.ROLLBACKS VALUES ('{{COMMIT}}', '{{KIND}}', '{{OBJECT}}', '{{STMT}}', '{{STATUS}}', '{{TIME}}', '{{WHO}}', '{{MSG}}')"
+	RestoreTableSQL                     = "CREATE OR REPLACE TABLE {{OBJECT}} CLONE {{OBJECT}} AT(TIMESTAMP => '{{AT}}'::TIMESTAMP_LTZ) COPY GRANTS;"
+	RestoreSchemaSQL                    = "CREATE OR REPLACE SCHEMA {{OBJECT}} CLONE {{OBJECT}} AT(TIMESTAMP => '{{AT}}'::TIMESTAMP_LTZ);"
+	UndropObjectSQL                     = "UNDROP {{KIND}} {{OBJECT}};"
+	DropObjectIfExistsSQL               = "DROP {{KIND}} IF EXISTS {{OBJECT}};"
+	ShowValidateObjectsSQL              = "SHOW {{KINDS}} LIKE '{{NAME}}' IN SCHEMA {{CHG_MGMT_DB}}.VALIDATE;"
 )
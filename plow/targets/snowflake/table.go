@@ -0,0 +1,417 @@
+package snowflake
+
+import (
+	"Plow/plow/objects"
+	"Plow/plow/utility"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DeclarativeDefinitionStyle definition style of specs declaring the desired state of the object, the statements
+// required to reach the state are generated by the renderer
+const DeclarativeDefinitionStyle = "declarative"
+
+var (
+	regexColumnType = regexp.MustCompile(`^\s*([A-Za-z_][A-Za-z0-9_ ]*?)\s*(?:\(\s*(\d+)\s*(?:,\s*(\d+)\s*)?\))?\s*$`)
+
+	columnTypeAliases = map[string]string{
+		"VARCHAR": "TEXT", "STRING": "TEXT", "TEXT": "TEXT", "CHAR": "TEXT", "CHARACTER": "TEXT", "NCHAR": "TEXT",
+		"NVARCHAR": "TEXT", "NVARCHAR2": "TEXT", "CHAR VARYING": "TEXT", "NCHAR VARYING": "TEXT",
+		"NUMBER": "NUMBER", "NUMERIC": "NUMBER", "DECIMAL": "NUMBER", "DEC": "NUMBER", "INT": "NUMBER",
+		"INTEGER": "NUMBER", "BIGINT": "NUMBER", "SMALLINT": "NUMBER", "TINYINT": "NUMBER", "BYTEINT": "NUMBER",
+		"FLOAT": "FLOAT", "FLOAT4": "FLOAT", "FLOAT8": "FLOAT", "DOUBLE": "FLOAT", "DOUBLE PRECISION": "FLOAT",
+		"REAL": "FLOAT", "BINARY": "BINARY", "VARBINARY": "BINARY", "DATETIME": "TIMESTAMP_NTZ",
+		"TIMESTAMP": "TIMESTAMP_NTZ",
+	}
+	fixedLengthTextTypes = []string{"CHAR", "CHARACTER", "NCHAR"}
+
+	regexDefaultCast         = regexp.MustCompile(`(?is)^CAST\s*\((.*)\s+AS\s+[A-Z_][A-Z0-9_ ]*(?:\(\s*\d+\s*(?:,\s*\d+\s*)?\))?\s*\)$`)
+	regexDefaultCastOperator = regexp.MustCompile(`(?is)^(.*?)\s*::\s*[A-Z_][A-Z0-9_ ]*(?:\(\s*\d+\s*(?:,\s*\d+\s*)?\))?$`)
+
+	constraintTypes = map[string]string{
+		"PRIMARY KEY": "PRIMARY KEY", "PRIMARYKEY": "PRIMARY KEY", "PK": "PRIMARY KEY",
+		"UNIQUE": "UNIQUE", "FOREIGN KEY": "FOREIGN KEY", "FOREIGNKEY": "FOREIGN KEY", "FK": "FOREIGN KEY",
+	}
+)
+
+const (
+	maxTextLength     = 16777216
+	maxBinaryLength   = 8388608
+	defaultPrecision  = 38
+	columnTypeSame    = 0
+	columnTypeWiden   = 1
+	columnTypeNarrow  = 2
+	columnTypeReplace = 3
+)
+
+// sfColumnType canonical form of a column data type, synonyms are reduced to the type reported by INFORMATION_SCHEMA
+type sfColumnType struct {
+	Base      string
+	Length    int64
+	Precision int64
+	Scale     int64
+}
+
+func (t sfColumnType) String() string {
+	switch t.Base {
+	case "TEXT", "BINARY":
+		return fmt.Sprintf("%s(%d)", t.Base, t.Length)
+	case "NUMBER":
+		return fmt.Sprintf("%s(%d,%d)", t.Base, t.Precision, t.Scale)
+	default:
+		return t.Base
+	}
+}
+
+func isDeclarative(item *objects.ChangeItem) bool {
+	return strings.EqualFold(strings.TrimSpace(item.Item.DefinitionStyle), DeclarativeDefinitionStyle)
+}
+
+// parseColumnType reduces a declared data type, e.g. VARCHAR(100), INT, NUMBER(15,2), to its canonical form
+func parseColumnType(declared string) (sfColumnType, error) {
+	match := regexColumnType.FindStringSubmatch(declared)
+	if match == nil {
+		return sfColumnType{}, utility.WrapError(fmt.Sprintf("column type [%s]", declared), ErrInvalidTableSpecification)
+	}
+
+	name := strings.Join(strings.Fields(strings.ToUpper(match[1])), " ")
+	first, _ := strconv.ParseInt(match[2], 10, 64)
+	second, _ := strconv.ParseInt(match[3], 10, 64)
+
+	colType := sfColumnType{Base: name}
+	if base, ok := columnTypeAliases[name]; ok {
+		colType.Base = base
+	}
+
+	switch colType.Base {
+	case "TEXT":
+		colType.Length = maxTextLength
+		if utility.Include(fixedLengthTextTypes, name) {
+			colType.Length = 1
+		}
+		if len(match[2]) > 0 {
+			colType.Length = first
+		}
+	case "BINARY":
+		colType.Length = maxBinaryLength
+		if len(match[2]) > 0 {
+			colType.Length = first
+		}
+	case "NUMBER":
+		colType.Precision = defaultPrecision
+		if len(match[2]) > 0 {
+			colType.Precision = first
+			colType.Scale = second
+		}
+	}
+	return colType, nil
+}
+
+func columnTypeFromState(col sfColumnState) sfColumnType {
+	colType := sfColumnType{Base: strings.ToUpper(col.DataType)}
+	switch colType.Base {
+	case "TEXT", "BINARY":
+		colType.Length = col.Length.Int64
+	case "NUMBER":
+		colType.Precision = col.Precision.Int64
+		colType.Scale = col.Scale.Int64
+	}
+	return colType
+}
+
+// compareColumnTypes classifies the change from the current to the desired type
+func compareColumnTypes(current sfColumnType, desired sfColumnType) int {
+	if current.Base != desired.Base {
+		return columnTypeReplace
+	}
+	var from, to int64
+	switch current.Base {
+	case "TEXT", "BINARY":
+		from, to = current.Length, desired.Length
+	case "NUMBER":
+		if current.Scale != desired.Scale {
+			return columnTypeReplace
+		}
+		from, to = current.Precision, desired.Precision
+	default:
+		return columnTypeSame
+	}
+
+	switch {
+	case to > from:
+		return columnTypeWiden
+	case to < from:
+		return columnTypeNarrow
+	default:
+		return columnTypeSame
+	}
+}
+
+func (col *sfColumnSpecification) identifier() string {
+	return strings.ToUpper(strings.TrimSpace(col.Name))
+}
+
+func (col *sfColumnSpecification) nullable() bool {
+	return col.Nullable == nil || *col.Nullable
+}
+
+func (col *sfColumnSpecification) definition() string {
+	def := fmt.Sprintf("%s %s", col.identifier(), strings.TrimSpace(col.Type))
+	if col.Default != nil && !utility.IsStringEmpty(col.Default) {
+		def = fmt.Sprintf("%s DEFAULT %s", def, strings.TrimSpace(*col.Default))
+	}
+	if !col.nullable() {
+		def = def + " NOT NULL"
+	}
+	if col.Comment != nil && len(*col.Comment) > 0 {
+		def = fmt.Sprintf("%s COMMENT %s", def, quoteLiteral(*col.Comment))
+	}
+	return def
+}
+
+func (con *sfConstraintSpecification) identifier() string {
+	return strings.ToUpper(strings.TrimSpace(con.Name))
+}
+
+func (con *sfConstraintSpecification) definition() string {
+	kind := constraintTypes[strings.ToUpper(strings.TrimSpace(con.Type))]
+	def := fmt.Sprintf("CONSTRAINT %s %s (%s)", con.identifier(), kind, joinIdentifiers(con.Columns))
+	if kind == "FOREIGN KEY" {
+		def = fmt.Sprintf("%s REFERENCES %s (%s)", def, strings.ToUpper(strings.TrimSpace(con.References.Table)),
+			joinIdentifiers(con.References.Columns))
+	}
+	return def
+}
+
+// validate verifies the structure of the spec prior to rendering
+func (spec *sfTableSpecification) validate() error {
+	if len(spec.Columns) == 0 {
+		return utility.WrapError("at least one column is required", ErrInvalidTableSpecification)
+	}
+	seen := make(map[string]bool)
+	for _, col := range spec.Columns {
+		if utility.IsStringEmpty(&col.Name) || utility.IsStringEmpty(&col.Type) {
+			return utility.WrapError("columns require a name and type", ErrInvalidTableSpecification)
+		}
+		if seen[col.identifier()] {
+			return utility.WrapError(fmt.Sprintf("column [%s] declared more than once", col.Name), ErrInvalidTableSpecification)
+		}
+		seen[col.identifier()] = true
+		if _, err := parseColumnType(col.Type); err != nil {
+			return err
+		}
+	}
+	for _, con := range spec.Constraints {
+		kind, ok := constraintTypes[strings.ToUpper(strings.TrimSpace(con.Type))]
+		if !ok || utility.IsStringEmpty(&con.Name) || len(con.Columns) == 0 {
+			return utility.WrapError("constraints require a name, type and columns", ErrInvalidTableSpecification)
+		}
+		if kind == "FOREIGN KEY" && (con.References == nil || utility.IsStringEmpty(&con.References.Table)) {
+			return utility.WrapError(fmt.Sprintf("foreign key [%s] requires references", con.Name), ErrInvalidTableSpecification)
+		}
+	}
+	return nil
+}
+
+// createDefinition column and constraint definitions of the CREATE TABLE statement
+func (spec *sfTableSpecification) createDefinition() string {
+	defs := make([]string, 0)
+	for i := range spec.Columns {
+		defs = append(defs, spec.Columns[i].definition())
+	}
+	for i := range spec.Constraints {
+		defs = append(defs, spec.Constraints[i].definition())
+	}
+	return strings.Join(defs, ", ")
+}
+
+// alterActions the ALTER TABLE actions required to bring the current table definition in line with the spec,
+// properties not declared by the spec are left unmanaged
+func (spec *sfTableSpecification) alterActions(state *sfTableState) ([]string, error) {
+	actions := make([]string, 0)
+
+	current := make(map[string]sfColumnState)
+	for _, col := range state.Columns {
+		current[strings.ToUpper(col.Name)] = col
+	}
+
+	declared := make(map[string]bool)
+	for i := range spec.Columns {
+		col := &spec.Columns[i]
+		name := col.identifier()
+		declared[name] = true
+
+		existing, ok := current[name]
+		if !ok {
+			actions = append(actions, "ADD COLUMN "+col.definition())
+			continue
+		}
+
+		desired, _ := parseColumnType(col.Type)
+		switch compareColumnTypes(columnTypeFromState(existing), desired) {
+		case columnTypeWiden:
+			actions = append(actions, fmt.Sprintf("ALTER COLUMN %s SET DATA TYPE %s", name, strings.TrimSpace(col.Type)))
+		case columnTypeNarrow:
+			if spec.AllowNarrowing {
+				//snowflake accepts the change when the existing data fits the narrower type
+				actions = append(actions, fmt.Sprintf("ALTER COLUMN %s SET DATA TYPE %s", name, strings.TrimSpace(col.Type)))
+				break
+			}
+			return nil, utility.WrapError(fmt.Sprintf("column [%s] %s to %s", name, columnTypeFromState(existing), desired), ErrColumnTypeNarrowing)
+		case columnTypeReplace:
+			return nil, utility.WrapError(fmt.Sprintf("column [%s] %s to %s", name, existing.DataType, col.Type), ErrIncompatibleColumnChange)
+		}
+
+		if col.nullable() != existing.Nullable {
+			if col.nullable() {
+				actions = append(actions, fmt.Sprintf("ALTER COLUMN %s DROP NOT NULL", name))
+			} else {
+				actions = append(actions, fmt.Sprintf("ALTER COLUMN %s SET NOT NULL", name))
+			}
+		}
+
+		if col.Default != nil {
+			want := strings.TrimSpace(*col.Default)
+			have := strings.TrimSpace(existing.Default.String)
+			if !defaultsEqual(want, have) {
+				switch {
+				case len(want) == 0:
+					actions = append(actions, fmt.Sprintf("ALTER COLUMN %s DROP DEFAULT", name))
+				case strings.HasSuffix(strings.ToUpper(want), ".NEXTVAL"):
+					actions = append(actions, fmt.Sprintf("ALTER COLUMN %s SET DEFAULT %s", name, want))
+				default:
+					return nil, utility.WrapError(fmt.Sprintf("column [%s]", name), ErrUnsupportedDefaultChange)
+				}
+			}
+		}
+
+		if col.Comment != nil && *col.Comment != existing.Comment.String {
+			if len(*col.Comment) == 0 {
+				actions = append(actions, fmt.Sprintf("ALTER COLUMN %s UNSET COMMENT", name))
+			} else {
+				actions = append(actions, fmt.Sprintf("ALTER COLUMN %s COMMENT %s", name, quoteLiteral(*col.Comment)))
+			}
+		}
+	}
+
+	if spec.DropUndeclaredColumns {
+		for _, col := range state.Columns {
+			if !declared[strings.ToUpper(col.Name)] {
+				actions = append(actions, "DROP COLUMN "+strings.ToUpper(col.Name))
+			}
+		}
+	}
+
+	if spec.ClusterBy != nil {
+		want := normalizeClusteringKey(strings.Join(spec.ClusterBy, ","))
+		if want != normalizeClusteringKey(state.ClusteringKey.String) {
+			if len(want) == 0 {
+				actions = append(actions, "DROP CLUSTERING KEY")
+			} else {
+				actions = append(actions, fmt.Sprintf("CLUSTER BY (%s)", strings.Join(spec.ClusterBy, ", ")))
+			}
+		}
+	}
+
+	for i := range spec.Constraints {
+		if _, ok := state.Constraints[spec.Constraints[i].identifier()]; !ok {
+			actions = append(actions, "ADD "+spec.Constraints[i].definition())
+		}
+	}
+
+	if spec.Comment != nil && *spec.Comment != state.Comment.String {
+		if len(*spec.Comment) == 0 {
+			actions = append(actions, "UNSET COMMENT")
+		} else {
+			actions = append(actions, "SET COMMENT = "+quoteLiteral(*spec.Comment))
+		}
+	}
+
+	return actions, nil
+}
+
+// defaultsEqual compares a declared column default with the default reported by INFORMATION_SCHEMA in their
+// normalized forms
+func defaultsEqual(declared string, current string) bool {
+	return normalizeDefault(declared) == normalizeDefault(current)
+}
+
+// normalizeDefault reduces a column default expression to a comparable form. Snowflake reports defaults in a
+// normalized form, e.g. CURRENT_TIMESTAMP is reported as CURRENT_TIMESTAMP() and may be wrapped in a cast to the column
+// type. Casts, enclosing parentheses, empty argument lists, identifier quotes and case are removed, the contents of
+// string literals are retained as declared.
+func normalizeDefault(expr string) string {
+	expr = strings.TrimSpace(expr)
+	for {
+		prev := expr
+		if match := regexDefaultCast.FindStringSubmatch(expr); match != nil {
+			expr = strings.TrimSpace(match[1])
+		} else if match := regexDefaultCastOperator.FindStringSubmatch(expr); match != nil {
+			expr = strings.TrimSpace(match[1])
+		} else if strings.HasPrefix(expr, "(") && strings.HasSuffix(expr, ")") && enclosed(expr) {
+			expr = strings.TrimSpace(expr[1 : len(expr)-1])
+		}
+		if expr == prev {
+			break
+		}
+	}
+
+	var out strings.Builder
+	literal := false
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case c == '\'':
+			literal = !literal
+			out.WriteByte(c)
+		case literal:
+			out.WriteByte(c)
+		case c == '"' || c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			continue
+		case c == '(' && i+1 < len(expr) && expr[i+1] == ')':
+			i++
+		default:
+			out.WriteString(strings.ToUpper(string(c)))
+		}
+	}
+	return out.String()
+}
+
+// enclosed reports if the opening parenthesis of the expression is closed by its final character
+func enclosed(expr string) bool {
+	depth := 0
+	literal := false
+	for i := 0; i < len(expr); i++ {
+		switch c := expr[i]; {
+		case c == '\'':
+			literal = !literal
+		case literal:
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth--; depth == 0 && i < len(expr)-1 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
+// normalizeClusteringKey reduces a clustering key, as declared or reported as LINEAR(A, B), to a comparable form
+func normalizeClusteringKey(key string) string {
+	key = strings.ToUpper(strings.TrimSpace(key))
+	if strings.HasPrefix(key, "LINEAR(") && strings.HasSuffix(key, ")") {
+		key = key[len("LINEAR(") : len(key)-1]
+	}
+	return strings.Join(strings.Fields(key), "")
+}
+
+func joinIdentifiers(names []string) string {
+	out := make([]string, 0)
+	for _, name := range names {
+		out = append(out, strings.ToUpper(strings.TrimSpace(name)))
+	}
+	return strings.Join(out, ", ")
+}
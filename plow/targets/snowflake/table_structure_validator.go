@@ -4,12 +4,45 @@ import (
 	"Plow/plow/objects"
 	"Plow/plow/targets/common"
 	"Plow/plow/utility"
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/noirbizarre/gonja"
+	"strconv"
 	"strings"
 )
 
+// attributes compared by the structure validator, the severity of a difference in each is set by the structure policy
+// and can be configured within the attributes of the validator configuration
+const (
+	StructureColumn        = "column"
+	StructurePosition      = "position"
+	StructureNullable      = "nullable"
+	StructureDataType      = "data_type"
+	StructureLength        = "length"
+	StructurePrecision     = "precision"
+	StructureScale         = "scale"
+	StructureDefault       = "default"
+	StructureComment       = "comment"
+	StructureClusteringKey = "clustering_key"
+)
+
+// defaultStructurePolicy differences altering the data held by a column are critical, differences in presentation
+// are warnings
+var defaultStructurePolicy = map[string]objects.ValidationErrorSeverity{
+	StructureColumn:        objects.ValidationErrorCritical,
+	StructurePosition:      objects.ValidationErrorWarn,
+	StructureNullable:      objects.ValidationErrorCritical,
+	StructureDataType:      objects.ValidationErrorCritical,
+	StructureLength:        objects.ValidationErrorCritical,
+	StructurePrecision:     objects.ValidationErrorCritical,
+	StructureScale:         objects.ValidationErrorCritical,
+	StructureDefault:       objects.ValidationErrorWarn,
+	StructureComment:       objects.ValidationErrorWarn,
+	StructureClusteringKey: objects.ValidationErrorWarn,
+}
+
 type columnInformation struct {
 	Name             sql.NullString
 	Position         sql.NullInt64
@@ -18,6 +51,8 @@ type columnInformation struct {
 	MaxCharLen       sql.NullInt64
 	NumericPrecision sql.NullInt64
 	NumericScale     sql.NullInt64
+	Default          sql.NullString
+	Comment          sql.NullString
 }
 
 type columnVerify struct {
@@ -28,10 +63,38 @@ type columnVerify struct {
 type SnowflakeTableStructureValidator struct {
 	db           *sql.DB
 	databaseName string
+	policy       map[string]objects.ValidationErrorSeverity
 }
 
 func newSnowflakeTableStructureValidator(snowflake *SnowflakeTarget) *SnowflakeTableStructureValidator {
-	return &SnowflakeTableStructureValidator{db: snowflake.connection, databaseName: snowflake.config.Database}
+	policy := make(map[string]objects.ValidationErrorSeverity)
+	for attribute, severity := range defaultStructurePolicy {
+		policy[attribute] = severity
+	}
+	return &SnowflakeTableStructureValidator{db: snowflake.connection, databaseName: snowflake.config.Database, policy: policy}
+}
+
+// ConfigureAttributes overrides the severity of the attributes of the default structure policy
+func (tsv *SnowflakeTableStructureValidator) ConfigureAttributes(severities map[string]objects.ValidationErrorSeverity) error {
+	for attribute, severity := range severities {
+		if _, ok := defaultStructurePolicy[attribute]; !ok {
+			return utility.WrapError(fmt.Sprintf("unknown attribute [%s]", attribute), ErrInvalidStructurePolicy)
+		}
+		tsv.policy[attribute] = severity
+	}
+	return nil
+}
+
+// validationObjectName the name of the objects created for the change within the ORIGIN and VALIDATE schemas, the name
+// is suffixed by a hash of the commit, file and object so changes validated at once do not collide
+func validationObjectName(change *objects.ChangeItem) string {
+	commit := ""
+	if change.Bundle != nil {
+		commit = change.Bundle.Ref.Hash
+	}
+	obj := change.Item.Object
+	hash := utility.Sha256Hashf("%s|%s|%s.%s.%s", commit, change.Metadata.Name, obj.Database, obj.Schema, obj.Name)
+	return fmt.Sprintf("%s_%s", strings.ToUpper(strings.TrimSpace(obj.Name)), strings.ToUpper(hash[:8]))
 }
 
 func (tsv *SnowflakeTableStructureValidator) Init() error {
@@ -42,6 +105,11 @@ func (tsv *SnowflakeTableStructureValidator) Destroy() error {
 	return nil
 }
 
+// Concurrent the moc tables are named for the change and the statements of a change are executed over one connection
+func (tsv *SnowflakeTableStructureValidator) Concurrent() bool {
+	return true
+}
+
 func (tsv *SnowflakeTableStructureValidator) Designation() string {
 	return "TableStructureValidator"
 }
@@ -49,6 +117,12 @@ func (tsv *SnowflakeTableStructureValidator) Designation() string {
 func (tsv *SnowflakeTableStructureValidator) Validate(change *objects.ChangeItem) error {
 
 	if change.ObjectType == "table" {
+		//declarative specs are diffed against the target during rendering, there are no scopes to validate
+		if isDeclarative(change) {
+			change.Validation.AddValidationStepInfo(objects.ValidationErrorNone, true, nil, tsv.Designation())
+			return nil
+		}
+
 		if change.Item.Options.Validate && change.Item.Options.CheckExists {
 			if change.ExistsFlag {
 
@@ -70,23 +144,26 @@ func (tsv *SnowflakeTableStructureValidator) Validate(change *objects.ChangeItem
 					return nil //  validator did not fail but has completed its task
 				}
 
-				//prep values needed to render moc& validation object statements
+				//prep values needed to render moc& validation object statements, the moc tables are named for the change
+				//so changes validated at once do not collide
+				mock := validationObjectName(change)
 				//moc clone table
 				paramsMocTable := gonja.Context{
 					"CHG_MGMT_DB": tsv.databaseName,
 					"DATABASE":    strings.ToUpper(change.Item.Object.Database),
 					"SCHEMA":      strings.ToUpper(change.Item.Object.Schema),
-					"NAME":        strings.ToUpper(change.Item.Object.Name)}
+					"NAME":        strings.ToUpper(change.Item.Object.Name),
+					"MOCK":        mock}
 
 				//moc create new from init for validation against variables
 				paramsValidateTable := gonja.Context{
 					"DATABASE":    tsv.databaseName,
 					"CHG_MGMT_DB": tsv.databaseName,
 					"SCHEMA":      "VALIDATE",
-					"NAME":        strings.ToUpper(change.Item.Object.Name)}
+					"NAME":        mock}
 
 				//validation logic variables
-				paramValidationSql := gonja.Context{"NAME": strings.ToUpper(change.Item.Object.Name),
+				paramValidationSql := gonja.Context{"NAME": mock,
 					"SCHEMA":      "ORIGIN",
 					"DATABASE":    tsv.databaseName,
 					"CHG_MGMT_DB": tsv.databaseName,
@@ -101,10 +178,23 @@ func (tsv *SnowflakeTableStructureValidator) Validate(change *objects.ChangeItem
 					return nil //  validator did not fail but has completed its task
 				}
 
+				//the scopes may depend on session state, every statement of the change is executed over the same
+				//connection of the pool
+				ctx := context.Background()
+				conn, err := tsv.db.Conn(ctx)
+				if err != nil {
+					change.Validation.AddValidationStepInfo(objects.ValidationErrorCritical,
+						false,
+						utility.WrapError("failed to obtain connection:", err),
+						tsv.Designation())
+					return nil //  validator did not fail but has completed its task
+				}
+				defer conn.Close()
+
 				prepAndCleanUpCmds := common.SegmentScopeCommands(prepAndCleanup)
 				//run in event prior run created but never cleaned up after itself
 				for _, cmd := range prepAndCleanUpCmds {
-					_, err := tsv.db.Exec(cmd)
+					_, err := conn.ExecContext(ctx, cmd)
 					if err != nil {
 						change.Validation.AddValidationStepInfo(objects.ValidationErrorCritical,
 							false,
@@ -151,7 +241,7 @@ func (tsv *SnowflakeTableStructureValidator) Validate(change *objects.ChangeItem
 				}
 
 				//create origin and validate object structures
-				_, err = tsv.db.Exec(moc)
+				_, err = conn.ExecContext(ctx, moc)
 				if err != nil {
 					change.Validation.AddValidationStepInfo(objects.ValidationErrorCritical,
 						false,
@@ -161,7 +251,7 @@ func (tsv *SnowflakeTableStructureValidator) Validate(change *objects.ChangeItem
 				}
 
 				for _, cmdStmt := range initScope.Commands {
-					_, err = tsv.db.Exec(cmdStmt)
+					_, err = conn.ExecContext(ctx, cmdStmt)
 					if err != nil {
 						change.Validation.AddValidationStepInfo(objects.ValidationErrorCritical,
 							false,
@@ -172,7 +262,7 @@ func (tsv *SnowflakeTableStructureValidator) Validate(change *objects.ChangeItem
 				}
 
 				for _, cmdStmt := range changeScope.Commands {
-					_, err = tsv.db.Exec(cmdStmt)
+					_, err = conn.ExecContext(ctx, cmdStmt)
 					if err != nil {
 						change.Validation.AddValidationStepInfo(objects.ValidationErrorCritical,
 							false,
@@ -183,7 +273,13 @@ func (tsv *SnowflakeTableStructureValidator) Validate(change *objects.ChangeItem
 				}
 
 				//execute verification sql
-				rows, err := tsv.db.Query(verifyStmt)
+				differences, passed, err := tsv.verify(ctx, conn, verifyStmt, &paramValidationSql)
+
+				// run cleanup commands, if error dont fail validator, next pass will cleanup in prep stage
+				for _, cmd := range prepAndCleanUpCmds {
+					_, _ = conn.ExecContext(ctx, cmd)
+				}
+
 				if err != nil {
 					change.Validation.AddValidationStepInfo(objects.ValidationErrorCritical,
 						false,
@@ -192,58 +288,25 @@ func (tsv *SnowflakeTableStructureValidator) Validate(change *objects.ChangeItem
 					return nil //  validator did not fail but has completed its task
 				}
 
-				passed := false // at least one column must be validated to pass
-
-				for rows.Next() {
-					var cv columnVerify
-					err = rows.Scan(&cv.Origin.Name,
-						&cv.Origin.Position,
-						&cv.Origin.Nullable,
-						&cv.Origin.DataType,
-						&cv.Origin.MaxCharLen,
-						&cv.Origin.NumericPrecision,
-						&cv.Origin.NumericScale,
-						&cv.Validate.Name,
-						&cv.Validate.Position,
-						&cv.Validate.Nullable,
-						&cv.Validate.DataType,
-						&cv.Validate.MaxCharLen,
-						&cv.Validate.NumericPrecision,
-						&cv.Validate.NumericScale)
-
-					if !cv.Origin.Name.Valid ||
-						!cv.Validate.Name.Valid ||
-						strings.Compare(strings.ToUpper(cv.Origin.Name.String), strings.ToUpper(cv.Validate.Name.String)) != 0 {
-
-						change.Validation.AddValidationStepInfo(objects.ValidationErrorCritical, false, errors.New("column failed validation"), tsv.Designation())
-
-						return nil //  validator did not fail but has completed its task
-					}
-
-					if !cv.Origin.DataType.Valid || !cv.Validate.DataType.Valid ||
-						strings.Compare(strings.ToUpper(cv.Origin.DataType.String), strings.ToUpper(cv.Validate.DataType.String)) != 0 {
-						change.Validation.AddValidationStepInfo(objects.ValidationErrorCritical,
-							false,
-							errors.New("column failed validation"),
-							tsv.Designation())
-
-						return nil //  validator did not fail but has completed its task
+				severity := objects.ValidationErrorNone
+				for _, difference := range differences {
+					if difference.Severity > severity {
+						severity = difference.Severity
 					}
-					passed = true
 				}
 
-				if passed {
+				if len(differences) > 0 {
+					change.Validation.AddValidationStepDifferences(severity,
+						false,
+						fmt.Errorf("structure differs from init scope, %d difference(s)", len(differences)),
+						tsv.Designation(),
+						differences)
+				} else if passed {
 					// if we have gotten to here all is good the object passes structural validation
 					change.Validation.AddValidationStepInfo(objects.ValidationErrorNone,
 						true,
 						nil,
 						tsv.Designation())
-
-				}
-
-				// run cleanup commands, if error dont fail validator, next pass will cleanup in prep stage
-				for _, cmd := range prepAndCleanUpCmds {
-					_, _ = tsv.db.Exec(cmd)
 				}
 
 				return nil //  validator did not fail but has completed its task
@@ -258,9 +321,137 @@ func (tsv *SnowflakeTableStructureValidator) Validate(change *objects.ChangeItem
 				err,
 				tsv.Designation())
 
-			return err
+			return nil //  validator did not fail but has completed its task
 		}
 	}
 
 	return nil //no errors occurred
 }
+
+// verify compares the columns, and clustering key, of the table produced by the change scope with the table produced
+// by the init scope. Differences are reported with the init scope as expected, attributes ignored by the policy are
+// not reported. The second value is false when no columns were compared.
+func (tsv *SnowflakeTableStructureValidator) verify(ctx context.Context, conn *sql.Conn, verifyStmt string, params *gonja.Context) ([]objects.ValidationDifference, bool, error) {
+	rows, err := conn.QueryContext(ctx, verifyStmt)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	differences := make([]objects.ValidationDifference, 0)
+	compared := false
+	for rows.Next() {
+		var cv columnVerify
+		err = rows.Scan(&cv.Origin.Name,
+			&cv.Origin.Position,
+			&cv.Origin.Nullable,
+			&cv.Origin.DataType,
+			&cv.Origin.MaxCharLen,
+			&cv.Origin.NumericPrecision,
+			&cv.Origin.NumericScale,
+			&cv.Origin.Default,
+			&cv.Origin.Comment,
+			&cv.Validate.Name,
+			&cv.Validate.Position,
+			&cv.Validate.Nullable,
+			&cv.Validate.DataType,
+			&cv.Validate.MaxCharLen,
+			&cv.Validate.NumericPrecision,
+			&cv.Validate.NumericScale,
+			&cv.Validate.Default,
+			&cv.Validate.Comment)
+		if err != nil {
+			return nil, false, err
+		}
+		compared = true
+		differences = append(differences, tsv.diffColumn(&cv)...)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	stmt, err := common.RenderStatement(TableClusteringVerifySQL, params)
+	if err != nil {
+		return nil, false, err
+	}
+	keys, err := conn.QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, false, err
+	}
+	defer keys.Close()
+
+	clustering := make(map[string]string)
+	for keys.Next() {
+		var schema string
+		var key sql.NullString
+		if err := keys.Scan(&schema, &key); err != nil {
+			return nil, false, err
+		}
+		clustering[strings.ToUpper(schema)] = key.String
+	}
+	if normalizeStructureValue(clustering["VALIDATE"]) != normalizeStructureValue(clustering["ORIGIN"]) {
+		differences = tsv.addDifference(differences, "table", StructureClusteringKey, clustering["VALIDATE"], clustering["ORIGIN"])
+	}
+
+	return differences, compared, keys.Err()
+}
+
+// diffColumn the differences between the column of the table produced by the change scope (origin) and the column of
+// the table produced by the init scope (validate)
+func (tsv *SnowflakeTableStructureValidator) diffColumn(cv *columnVerify) []objects.ValidationDifference {
+	differences := make([]objects.ValidationDifference, 0)
+	if !cv.Validate.Name.Valid {
+		return tsv.addDifference(differences, fmt.Sprintf("column [%s]", cv.Origin.Name.String), StructureColumn, "absent", "present")
+	}
+	subject := fmt.Sprintf("column [%s]", cv.Validate.Name.String)
+	if !cv.Origin.Name.Valid {
+		return tsv.addDifference(differences, subject, StructureColumn, "present", "absent")
+	}
+
+	compare := []struct {
+		attribute string
+		expected  string
+		actual    string
+	}{
+		{StructurePosition, nullIntString(cv.Validate.Position), nullIntString(cv.Origin.Position)},
+		{StructureNullable, cv.Validate.Nullable.String, cv.Origin.Nullable.String},
+		{StructureDataType, cv.Validate.DataType.String, cv.Origin.DataType.String},
+		{StructureLength, nullIntString(cv.Validate.MaxCharLen), nullIntString(cv.Origin.MaxCharLen)},
+		{StructurePrecision, nullIntString(cv.Validate.NumericPrecision), nullIntString(cv.Origin.NumericPrecision)},
+		{StructureScale, nullIntString(cv.Validate.NumericScale), nullIntString(cv.Origin.NumericScale)},
+		{StructureDefault, cv.Validate.Default.String, cv.Origin.Default.String},
+		{StructureComment, cv.Validate.Comment.String, cv.Origin.Comment.String},
+	}
+	for _, c := range compare {
+		expected, actual := c.expected, c.actual
+		//comments are compared as written, other attributes are case-insensitive
+		if c.attribute != StructureComment {
+			expected, actual = normalizeStructureValue(expected), normalizeStructureValue(actual)
+		}
+		if expected != actual {
+			differences = tsv.addDifference(differences, subject, c.attribute, c.expected, c.actual)
+		}
+	}
+	return differences
+}
+
+// addDifference appends the difference with the severity set by the policy, ignored attributes are not appended
+func (tsv *SnowflakeTableStructureValidator) addDifference(differences []objects.ValidationDifference, subject string, attribute string, expected string, actual string) []objects.ValidationDifference {
+	severity := tsv.policy[attribute]
+	if severity == objects.ValidationErrorNone {
+		return differences
+	}
+	return append(differences, objects.ValidationDifference{Subject: subject, Attribute: attribute,
+		Expected: expected, Actual: actual, Severity: severity})
+}
+
+func normalizeStructureValue(value string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(value), " "))
+}
+
+func nullIntString(value sql.NullInt64) string {
+	if !value.Valid {
+		return ""
+	}
+	return strconv.FormatInt(value.Int64, 10)
+}
@@ -0,0 +1,291 @@
+package snowflake
+
+import (
+	"Plow/plow/objects"
+	"Plow/plow/secrets"
+	"Plow/plow/targets/common"
+	"Plow/plow/utility"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"github.com/noirbizarre/gonja"
+	"strconv"
+	"strings"
+)
+
+var (
+	userTypes = []string{"PERSON", "SERVICE", "LEGACY_SERVICE"}
+	//users hold at most two public keys, the second slot allows a key to be rotated without interruption
+	publicKeySlots = [2]sfProperty{
+		{parameter: "RSA_PUBLIC_KEY", column: "rsa_public_key_fp"},
+		{parameter: "RSA_PUBLIC_KEY_2", column: "rsa_public_key_2_fp"},
+	}
+)
+
+// sfPublicKey an RSA public key resolved from the secret store, fingerprint matches the form reported by DESC USER
+type sfPublicKey struct {
+	secret      string
+	body        string
+	fingerprint string
+}
+
+// loadPublicKey reads the PEM, or bare base64, encoded public key from the secret store
+func loadPublicKey(secretStore secrets.SecretStore, secret string) (*sfPublicKey, error) {
+	if secretStore == nil {
+		return nil, utility.WrapError(fmt.Sprintf("unable to resolve [%s]: no secret store configured", secret), ErrInvalidPublicKey)
+	}
+	material, err := secretStore.GetSecret(secret)
+	if err != nil {
+		return nil, utility.WrapError(fmt.Sprintf("unable to resolve [%s]:", secret), err)
+	}
+	//secret stores backed by single line values commonly carry escaped line feeds
+	if !strings.Contains(material, "\n") {
+		material = strings.ReplaceAll(material, `\n`, "\n")
+	}
+
+	var der []byte
+	if block, _ := pem.Decode([]byte(material)); block != nil {
+		der = block.Bytes
+	} else if der, err = base64.StdEncoding.DecodeString(strings.Join(strings.Fields(material), "")); err != nil {
+		return nil, utility.WrapError(fmt.Sprintf("[%s]", secret), ErrInvalidPublicKey)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, utility.WrapError(fmt.Sprintf("[%s]", secret), ErrInvalidPublicKey)
+	}
+	if _, ok := key.(*rsa.PublicKey); !ok {
+		return nil, utility.WrapError(fmt.Sprintf("[%s]", secret), ErrInvalidPublicKey)
+	}
+
+	digest := sha256.Sum256(der)
+	return &sfPublicKey{
+		secret:      secret,
+		body:        base64.StdEncoding.EncodeToString(der),
+		fingerprint: "SHA256:" + base64.StdEncoding.EncodeToString(digest[:]),
+	}, nil
+}
+
+// publicKeyProperties assigns the declared keys to the two key slots. A declared key already held by a slot is left
+// in place so clients authenticating with it are unaffected, a new key takes an empty slot, or failing that the slot
+// of a key no longer declared. Slots not holding a declared key are unset.
+func publicKeyProperties(keys []*sfPublicKey, current map[string]string) []sfProperty {
+	held := [2]string{}
+	for i, slot := range publicKeySlots {
+		held[i] = normalizeFingerprint(current[slot.column])
+	}
+
+	assigned := [2]*sfPublicKey{}
+	pending := make([]*sfPublicKey, 0)
+	for _, key := range keys {
+		switch key.fingerprint {
+		case held[0]:
+			assigned[0] = key
+		case held[1]:
+			assigned[1] = key
+		default:
+			pending = append(pending, key)
+		}
+	}
+	for _, key := range pending {
+		free := -1
+		for i := range assigned {
+			if assigned[i] != nil {
+				continue
+			}
+			if len(held[i]) == 0 {
+				free = i
+				break
+			}
+			if free < 0 {
+				free = i
+			}
+		}
+		assigned[free] = key
+	}
+
+	props := make([]sfProperty, 0)
+	for i, slot := range publicKeySlots {
+		prop := slot
+		prop.normalize = normalizeFingerprint
+		if assigned[i] != nil {
+			prop.value = quoteLiteral(assigned[i].body)
+			prop.desired = assigned[i].fingerprint
+		}
+		props = append(props, prop)
+	}
+	return props
+}
+
+// normalizeFingerprint fingerprints are case-sensitive base64, only null is normalized
+func normalizeFingerprint(value string) string {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, "null") {
+		return ""
+	}
+	return value
+}
+
+// properties the parameters declared by the spec in a stable order, public keys are resolved from the secret store
+// and assigned to slots based on the keys currently held by the user
+func (spec *sfUserSpecification) properties(secretStore secrets.SecretStore, current map[string]string) ([]sfProperty, error) {
+	props := make([]sfProperty, 0)
+
+	if spec.LoginName != nil {
+		login := strings.TrimSpace(*spec.LoginName)
+		if len(login) == 0 {
+			return nil, utility.WrapError("loginName can not be empty", ErrInvalidUserProperty)
+		}
+		props = append(props, sfProperty{parameter: "LOGIN_NAME", column: "login_name",
+			value: quoteLiteral(login), desired: strings.ToUpper(login), normalize: normalizeToken})
+	}
+
+	if spec.Type != nil {
+		userType := strings.ReplaceAll(normalizeToken(*spec.Type), "-", "_")
+		if !utility.Include(userTypes, userType) {
+			return nil, utility.WrapError(fmt.Sprintf("type [%s]", *spec.Type), ErrInvalidUserProperty)
+		}
+		props = append(props, sfProperty{parameter: "TYPE", column: "type",
+			value: userType, desired: userType, normalize: normalizeToken})
+	}
+
+	for _, def := range []struct {
+		parameter string
+		column    string
+		value     *string
+	}{
+		{"DEFAULT_ROLE", "default_role", spec.DefaultRole},
+		{"DEFAULT_WAREHOUSE", "default_warehouse", spec.DefaultWarehouse},
+		{"DEFAULT_NAMESPACE", "default_namespace", spec.DefaultNamespace},
+	} {
+		if def.value == nil {
+			continue
+		}
+		val := normalizeToken(*def.value)
+		if def.parameter == "DEFAULT_NAMESPACE" && len(strings.Split(val, ".")) > 2 {
+			return nil, utility.WrapError(fmt.Sprintf("defaultNamespace [%s] expected DATABASE or DATABASE.SCHEMA", *def.value), ErrInvalidUserProperty)
+		}
+		props = append(props, sfProperty{parameter: def.parameter, column: def.column,
+			value: val, desired: val, normalize: normalizeToken})
+	}
+
+	if spec.Disabled != nil {
+		val := strings.ToUpper(strconv.FormatBool(*spec.Disabled))
+		props = append(props, sfProperty{parameter: "DISABLED", column: "disabled",
+			value: val, desired: val, normalize: normalizeToken})
+	}
+
+	if spec.PublicKeySecrets != nil {
+		if len(spec.PublicKeySecrets) > len(publicKeySlots) {
+			return nil, utility.WrapError("at most two publicKeySecrets can be declared", ErrInvalidUserProperty)
+		}
+		keys := make([]*sfPublicKey, 0)
+		for _, secret := range spec.PublicKeySecrets {
+			key, err := loadPublicKey(secretStore, secret)
+			if err != nil {
+				return nil, err
+			}
+			if len(keys) > 0 && keys[0].fingerprint == key.fingerprint {
+				return nil, utility.WrapError("publicKeySecrets resolve to the same key", ErrInvalidUserProperty)
+			}
+			keys = append(keys, key)
+		}
+		props = append(props, publicKeyProperties(keys, current)...)
+	}
+
+	if spec.Comment != nil {
+		comment := *spec.Comment
+		var value string
+		if len(comment) > 0 {
+			value = quoteLiteral(comment)
+		}
+		props = append(props, sfProperty{parameter: "COMMENT", column: "comment",
+			value: value, desired: comment, normalize: func(v string) string { return v }})
+	}
+
+	return props, nil
+}
+
+// renderUserSpec creates the user, or alters the properties which differ from the current state. Users are managed
+// by USERADMIN unless the declared owner already owns the user.
+func (sfr *SnowflakeRenderer) renderUserSpec(spec *sfUserSpecification, item *objects.ChangeItem, params *map[string]interface{}) ([]*objects.ApplyScope, error) {
+	stmts := make([]string, 0)
+	vars := *params
+
+	owner := ""
+	if StringToSnowflakeObjectType(spec.Owner.ObjectType) == Role && !utility.IsStringEmpty(&spec.Owner.Identifier) {
+		owner = strings.ToUpper(strings.TrimSpace(spec.Owner.Identifier))
+		if IsProtectedSystemRole(owner) {
+			return nil, ErrDisallowedPrivilegedRole
+		}
+	}
+
+	//the user properties are read at render time, the existence validation only records whether it exists
+	current, exists, err := sfr.inspector.User(vars["NAME"].(string))
+	if err != nil {
+		return nil, err
+	}
+	currentOwner := strings.ToUpper(current["owner"])
+	if exists && len(owner) > 0 && currentOwner == owner {
+		stmts = append(stmts, generateUseRoleStmt(owner))
+	} else {
+		stmts = append(stmts, generateUseRoleStmt(string(USERADMIN)))
+	}
+
+	if item.Item.Options.Drop {
+		stmt, err := common.RenderStatement(DropUserSQL, (*gonja.Context)(&vars))
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, stmt, generateUseRoleStmt(sfr.defaultRole))
+		return []*objects.ApplyScope{common.NewScope("user", stmts)}, nil
+	}
+
+	props, err := spec.properties(sfr.secretStore, current)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		vars["PROPERTIES"] = strings.Join(createProperties(props), " ")
+		stmt, err := common.RenderStatement(CreateUserSQL, (*gonja.Context)(&vars))
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, stmt)
+	} else {
+		set, unset := diffProperties(props, current)
+		if len(set) > 0 {
+			vars["PROPERTIES"] = strings.Join(set, " ")
+			stmt, err := common.RenderStatement(AlterUserSetSQL, (*gonja.Context)(&vars))
+			if err != nil {
+				return nil, err
+			}
+			stmts = append(stmts, stmt)
+		}
+		if len(unset) > 0 {
+			vars["PROPERTIES"] = strings.Join(unset, ", ")
+			stmt, err := common.RenderStatement(AlterUserUnsetSQL, (*gonja.Context)(&vars))
+			if err != nil {
+				return nil, err
+			}
+			stmts = append(stmts, stmt)
+		}
+	}
+
+	//ownership transfer, existing grants are retained
+	if len(owner) > 0 && currentOwner != owner {
+		vars["ROLE"] = owner
+		stmt, err := common.RenderStatement(GrantUserOwnershipSQL, (*gonja.Context)(&vars))
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, stmt)
+	}
+
+	stmts = append(stmts, generateUseRoleStmt(sfr.defaultRole)) //set role back to default for good measure
+	return []*objects.ApplyScope{common.NewScope("user", stmts)}, nil
+}
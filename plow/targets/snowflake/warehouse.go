@@ -0,0 +1,129 @@
+package snowflake
+
+import (
+	"Plow/plow/utility"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	warehouseSizes = map[string]string{
+		"XSMALL": "X-SMALL", "SMALL": "SMALL", "MEDIUM": "MEDIUM", "LARGE": "LARGE", "XLARGE": "X-LARGE",
+		"2XLARGE": "2X-LARGE", "3XLARGE": "3X-LARGE", "4XLARGE": "4X-LARGE", "5XLARGE": "5X-LARGE", "6XLARGE": "6X-LARGE",
+	}
+	warehouseSizeAliases = map[string]string{
+		"XXLARGE": "2XLARGE", "X2LARGE": "2XLARGE", "XXXLARGE": "3XLARGE", "X3LARGE": "3XLARGE",
+		"X4LARGE": "4XLARGE", "X5LARGE": "5XLARGE", "X6LARGE": "6XLARGE",
+	}
+	warehouseTypes           = []string{"STANDARD", "SNOWPARK-OPTIMIZED"}
+	warehouseScalingPolicies = []string{"STANDARD", "ECONOMY"}
+)
+
+// properties the parameters declared by the spec in a stable order
+func (spec *sfWarehouseSpecification) properties() ([]sfProperty, error) {
+	props := make([]sfProperty, 0)
+
+	if spec.Type != nil {
+		whType := normalizeWarehouseType(*spec.Type)
+		if !utility.Include(warehouseTypes, whType) {
+			return nil, utility.WrapError(fmt.Sprintf("type [%s]", *spec.Type), ErrInvalidWarehouseProperty)
+		}
+		props = append(props, sfProperty{parameter: "WAREHOUSE_TYPE", column: "type",
+			value: quoteLiteral(whType), desired: whType, normalize: normalizeWarehouseType})
+	}
+
+	if spec.Size != nil {
+		size := normalizeWarehouseSize(*spec.Size)
+		display, ok := warehouseSizes[size]
+		if !ok {
+			return nil, utility.WrapError(fmt.Sprintf("size [%s]", *spec.Size), ErrInvalidWarehouseProperty)
+		}
+		props = append(props, sfProperty{parameter: "WAREHOUSE_SIZE", column: "size",
+			value: quoteLiteral(display), desired: size, normalize: normalizeWarehouseSize})
+	}
+
+	if spec.MinClusterCount != nil && spec.MaxClusterCount != nil && *spec.MinClusterCount > *spec.MaxClusterCount {
+		return nil, utility.WrapError("minClusterCount exceeds maxClusterCount", ErrInvalidWarehouseProperty)
+	}
+	if spec.MaxClusterCount != nil {
+		if *spec.MaxClusterCount < 1 {
+			return nil, utility.WrapError("maxClusterCount must be at least 1", ErrInvalidWarehouseProperty)
+		}
+		props = append(props, newIntProperty("MAX_CLUSTER_COUNT", "max_cluster_count", *spec.MaxClusterCount))
+	}
+	if spec.MinClusterCount != nil {
+		if *spec.MinClusterCount < 1 {
+			return nil, utility.WrapError("minClusterCount must be at least 1", ErrInvalidWarehouseProperty)
+		}
+		props = append(props, newIntProperty("MIN_CLUSTER_COUNT", "min_cluster_count", *spec.MinClusterCount))
+	}
+
+	if spec.ScalingPolicy != nil {
+		policy := strings.ToUpper(strings.TrimSpace(*spec.ScalingPolicy))
+		if !utility.Include(warehouseScalingPolicies, policy) {
+			return nil, utility.WrapError(fmt.Sprintf("scalingPolicy [%s]", *spec.ScalingPolicy), ErrInvalidWarehouseProperty)
+		}
+		props = append(props, sfProperty{parameter: "SCALING_POLICY", column: "scaling_policy",
+			value: policy, desired: policy, normalize: normalizeToken})
+	}
+
+	if spec.AutoSuspend != nil {
+		if *spec.AutoSuspend < 0 {
+			return nil, utility.WrapError("autoSuspend can not be negative", ErrInvalidWarehouseProperty)
+		}
+		prop := newIntProperty("AUTO_SUSPEND", "auto_suspend", *spec.AutoSuspend)
+		//a warehouse which never suspends reports null
+		prop.normalize = func(v string) string {
+			if v = normalizeToken(v); len(v) == 0 {
+				return "0"
+			}
+			return v
+		}
+		props = append(props, prop)
+	}
+
+	if spec.AutoResume != nil {
+		val := strings.ToUpper(strconv.FormatBool(*spec.AutoResume))
+		props = append(props, sfProperty{parameter: "AUTO_RESUME", column: "auto_resume",
+			value: val, desired: val, normalize: normalizeToken})
+	}
+
+	if spec.InitiallySuspended != nil {
+		val := strings.ToUpper(strconv.FormatBool(*spec.InitiallySuspended))
+		props = append(props, sfProperty{parameter: "INITIALLY_SUSPENDED", value: val, desired: val,
+			normalize: normalizeToken})
+	}
+
+	if spec.ResourceMonitor != nil {
+		monitor := normalizeToken(*spec.ResourceMonitor)
+		props = append(props, sfProperty{parameter: "RESOURCE_MONITOR", column: "resource_monitor",
+			value: monitor, desired: monitor, normalize: normalizeToken})
+	}
+
+	if spec.Comment != nil {
+		comment := *spec.Comment
+		var value string
+		if len(comment) > 0 {
+			value = quoteLiteral(comment)
+		}
+		props = append(props, sfProperty{parameter: "COMMENT", column: "comment",
+			value: value, desired: comment, normalize: func(v string) string { return v }})
+	}
+
+	return props, nil
+}
+
+// normalizeWarehouseSize reduces the accepted spellings of a size, e.g. x-small, XSMALL, 'X-Small', to a single form
+func normalizeWarehouseSize(size string) string {
+	size = strings.ToUpper(strings.Trim(strings.TrimSpace(size), "'"))
+	size = strings.NewReplacer("-", "", "_", "", " ", "").Replace(size)
+	if alias, ok := warehouseSizeAliases[size]; ok {
+		return alias
+	}
+	return size
+}
+
+func normalizeWarehouseType(whType string) string {
+	return strings.ReplaceAll(normalizeToken(whType), "_", "-")
+}
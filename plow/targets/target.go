@@ -4,7 +4,10 @@ import (
 	"Plow/plow/objects"
 	"Plow/plow/secrets"
 	"Plow/plow/targets/common"
+	"Plow/plow/targets/generic"
+	"Plow/plow/targets/mysql"
 	sf "Plow/plow/targets/snowflake"
+	"fmt"
 	"github.com/mitchellh/mapstructure"
 	"strings"
 )
@@ -27,9 +30,75 @@ func NewTarget(target string, config map[string]interface{}, options *objects.Op
 
 			return snowflake, nil
 		}
+	case "MYSQL", "MARIADB":
+		{
+			var myconfig mysql.MySQLConfiguration
+			err := mapstructure.Decode(config, &myconfig)
+			if err != nil {
+				return nil, err
+			}
+
+			target := &mysql.MySQLTarget{}
+			err = target.Open(myconfig, options, secrets)
+			if err != nil {
+				return nil, err
+			}
+
+			return target, nil
+		}
+	case "GENERIC":
+		{
+			var gconfig generic.GenericConfiguration
+			err := mapstructure.Decode(config, &gconfig)
+			if err != nil {
+				return nil, err
+			}
+
+			target := &generic.GenericTarget{}
+			err = target.Open(gconfig, options, secrets)
+			if err != nil {
+				return nil, err
+			}
+
+			return target, nil
+		}
 	default:
 		{
 			return nil, common.ErrInvalidTargetType
 		}
 	}
 }
+
+// LintSpec checks the spec file without connecting to a target, the definition style of the spec identifies the
+// target type whose spec schema it is checked against. Yaml files which are not specs produce no findings.
+func LintSpec(file string, bytes []byte) []objects.LintFinding {
+	doc := common.NewLintDocument(file, bytes)
+	if doc.Spec == nil {
+		return doc.Findings
+	}
+
+	switch strings.TrimSpace(strings.ToUpper(doc.Spec.DefinitionStyle)) {
+	case "SNOWFLAKE", strings.ToUpper(sf.DeclarativeDefinitionStyle):
+		doc.CheckConformance(sf.SpecSchema())
+		sf.Lint(doc)
+	case "MYSQL", "MARIADB":
+		doc.CheckConformance(mysql.SpecSchema())
+		mysql.Lint(doc)
+	case "GENERIC":
+		doc.CheckConformance(generic.SpecSchema(nil))
+		generic.Lint(doc)
+	default:
+		doc.Add(doc.Line("definitionStyle"), objects.ValidationErrorCritical, common.LintRuleDefinitionStyle,
+			fmt.Sprintf("unknown definitionStyle [%s], expected one of [snowflake, declarative, mysql, generic]", doc.Spec.DefinitionStyle))
+	}
+	return doc.Findings
+}
+
+// SpecSchemas the spec schema of each target type keyed by file name, generic specs are described without a dialect
+func SpecSchemas() map[string]*common.JSONSchema {
+	return map[string]*common.JSONSchema{
+		sf.SpecSchemaID:      sf.SpecSchema(),
+		mysql.SpecSchemaID:   mysql.SpecSchema(),
+		generic.SpecSchemaID: generic.SpecSchema(nil),
+	}
+}
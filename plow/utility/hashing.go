@@ -7,7 +7,7 @@ import (
 )
 
 func Sha256Hashf(format string, args ...interface{}) string {
-	s := fmt.Sprintf(format, args)
+	s := fmt.Sprintf(format, args...)
 	return Sha256Hash(s)
 }
 